@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,7 +17,6 @@ import (
 	. "github.com/onsi/ginkgo"
 	ginkgoconfig "github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gexec"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 	"github.com/tedsuo/ifrit/grouper"
@@ -26,6 +26,7 @@ import (
 	"code.cloudfoundry.org/bbs/serviceclient"
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/helpers/buildcache"
 	"code.cloudfoundry.org/inigo/helpers/certauthority"
 	"code.cloudfoundry.org/inigo/helpers/portauthority"
 	"code.cloudfoundry.org/inigo/inigo_announcement_server"
@@ -34,8 +35,10 @@ import (
 
 var (
 	componentMaker world.ComponentMaker
+	certAuthority  certauthority.CertAuthority
 
 	plumbing, bbsProcess, gardenProcess ifrit.Process
+	bbsRunner, gardenRunner             ifrit.Runner
 	gardenClient                        garden.Client
 	bbsClient                           bbs.InternalClient
 	bbsServiceClient                    serviceclient.ServiceClient
@@ -43,6 +46,14 @@ var (
 	suiteTempDir                        string
 )
 
+// fakeGardenTag marks the specs that also pass against the fake garden; with
+// INIGO_FAKE_GARDEN=true every other spec is skipped.
+const fakeGardenTag = "[fake-garden]"
+
+func needsRealGarden() bool {
+	return componentMaker.FakeGardenEnabled() && !strings.Contains(CurrentGinkgoTestDescription().FullTestText, fakeGardenTag)
+}
+
 func overrideConvergenceRepeatInterval(conf *bbsconfig.BBSConfig) {
 	conf.ConvergeRepeatInterval = durationjson.Duration(time.Second)
 }
@@ -90,6 +101,10 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 		SQL:                 fmt.Sprintf("%sdiego_%d", dbBaseConnectionString, ginkgoconfig.GinkgoConfig.ParallelNode),
 	}
 
+	if world.LocketOnly() {
+		addresses.Consul = ""
+	}
+
 	node := GinkgoParallelProcess()
 	startPort := 1000 * node
 	portRange := 950
@@ -100,7 +115,7 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 
 	certDepot := world.TempDirWithParent(suiteTempDir, "cert-depot")
 
-	certAuthority, err := certauthority.NewCertAuthority(certDepot, "ca")
+	certAuthority, err = certauthority.NewCertAuthority(certDepot, "ca")
 	Expect(err).NotTo(HaveOccurred())
 
 	componentMaker = world.MakeComponentMaker(builtArtifacts, addresses, allocator, certAuthority)
@@ -108,25 +123,37 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 })
 
 var _ = AfterSuite(func() {
+	Expect(helpers.WriteEventuallyTimings()).To(Succeed())
+
 	if componentMaker != nil {
 		componentMaker.Teardown()
 	}
 
 	deleteSuiteTempDir := func() error { return os.RemoveAll(suiteTempDir) }
-	Eventually(deleteSuiteTempDir).Should(Succeed())
+	helpers.TimedEventually(deleteSuiteTempDir).Should(Succeed())
 })
 
 var _ = BeforeEach(func() {
+	if needsRealGarden() {
+		Skip("needs a real garden")
+	}
+
+	initialServices := grouper.Members{
+		{"sql", componentMaker.SQL()},
+		{"nats", componentMaker.NATS()},
+	}
+	if componentMaker.ConsulEnabled() {
+		initialServices = append(initialServices, grouper.Member{"consul", componentMaker.Consul()})
+	}
+
 	plumbing = ginkgomon.Invoke(grouper.NewOrdered(os.Kill, grouper.Members{
-		{"initial-services", grouper.NewParallel(os.Kill, grouper.Members{
-			{"sql", componentMaker.SQL()},
-			{"nats", componentMaker.NATS()},
-			{"consul", componentMaker.Consul()},
-		})},
+		{"initial-services", grouper.NewParallel(os.Kill, initialServices)},
 		{"locket", componentMaker.Locket()},
 	}))
-	gardenProcess = ginkgomon.Invoke(componentMaker.Garden())
-	bbsProcess = ginkgomon.Invoke(componentMaker.BBS())
+	gardenRunner = componentMaker.Garden()
+	gardenProcess = ginkgomon.Invoke(gardenRunner)
+	bbsRunner = componentMaker.BBS()
+	bbsProcess = ginkgomon.Invoke(bbsRunner)
 
 	helpers.ConsulWaitUntilReady(componentMaker.Addresses())
 	lgr = lager.NewLogger("test")
@@ -140,13 +167,17 @@ var _ = BeforeEach(func() {
 })
 
 var _ = AfterEach(func() {
+	if needsRealGarden() {
+		return
+	}
+
 	inigo_announcement_server.Stop()
 
 	destroyContainerErrors := helpers.CleanupGarden(gardenClient)
 
-	helpers.StopProcesses(bbsProcess)
-	helpers.StopProcesses(gardenProcess)
-	helpers.StopProcesses(plumbing)
+	helpers.StopNamed(helpers.NewNamedProcess("bbs", bbsRunner, bbsProcess))
+	helpers.StopNamed(helpers.NewNamedProcess("garden", gardenRunner, gardenProcess))
+	helpers.StopNamed(helpers.NamedProcess{Name: "plumbing", Process: plumbing})
 
 	Expect(destroyContainerErrors).To(
 		BeEmpty(),
@@ -156,7 +187,10 @@ var _ = AfterEach(func() {
 })
 
 func TestCell(t *testing.T) {
-	helpers.RegisterDefaultTimeouts()
+	err := helpers.RegisterDefaultTimeouts()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	RegisterFailHandler(Fail)
 
@@ -165,10 +199,10 @@ func TestCell(t *testing.T) {
 
 func CompileHealthcheckExecutable(tmpDir string) string {
 	healthcheckDir := world.TempDirWithParent(tmpDir, "healthcheck")
-	healthcheckPath, err := gexec.Build("code.cloudfoundry.org/healthcheck/cmd/healthcheck", "-race")
+	healthcheckPath, err := buildcache.Build("code.cloudfoundry.org/healthcheck/cmd/healthcheck", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	err = os.Rename(healthcheckPath, filepath.Join(healthcheckDir, "healthcheck"))
+	err = buildcache.Link(healthcheckPath, filepath.Join(healthcheckDir, "healthcheck"))
 	Expect(err).NotTo(HaveOccurred())
 
 	return healthcheckDir
@@ -181,45 +215,45 @@ func CompileTestedExecutables() world.BuiltExecutables {
 
 	cwd, err := os.Getwd()
 	Expect(err).NotTo(HaveOccurred())
-	Expect(os.Chdir(os.Getenv("GARDEN_GOPATH"))).To(Succeed())
-	builtExecutables["garden"], err = gexec.Build("./cmd/gdn", "-race", "-a", "-tags", "daemon")
-	Expect(err).NotTo(HaveOccurred())
-	Expect(os.Chdir(cwd)).To(Succeed())
+	if !world.UseFakeGarden() {
+		Expect(os.Chdir(os.Getenv("GARDEN_GOPATH"))).To(Succeed())
+		builtExecutables["garden"], err = buildcache.Build("./cmd/gdn", "-race", "-a", "-tags", "daemon")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chdir(cwd)).To(Succeed())
+	}
 
-	builtExecutables["auctioneer"], err = gexec.Build("code.cloudfoundry.org/auctioneer/cmd/auctioneer", "-race")
+	builtExecutables["auctioneer"], err = buildcache.Build("code.cloudfoundry.org/auctioneer/cmd/auctioneer", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["rep"], err = gexec.Build("code.cloudfoundry.org/rep/cmd/rep", "-race")
+	builtExecutables["rep"], err = buildcache.Build("code.cloudfoundry.org/rep/cmd/rep", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["bbs"], err = gexec.Build("code.cloudfoundry.org/bbs/cmd/bbs", "-race")
+	builtExecutables["bbs"], err = buildcache.Build("code.cloudfoundry.org/bbs/cmd/bbs", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["locket"], err = gexec.Build("code.cloudfoundry.org/locket/cmd/locket", "-race")
+	builtExecutables["locket"], err = buildcache.Build("code.cloudfoundry.org/locket/cmd/locket", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["file-server"], err = gexec.Build("code.cloudfoundry.org/fileserver/cmd/file-server", "-race")
+	builtExecutables["file-server"], err = buildcache.Build("code.cloudfoundry.org/fileserver/cmd/file-server", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["route-emitter"], err = gexec.Build("code.cloudfoundry.org/route-emitter/cmd/route-emitter", "-race")
+	builtExecutables["route-emitter"], err = buildcache.Build("code.cloudfoundry.org/route-emitter/cmd/route-emitter", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
 	if runtime.GOOS != "windows" {
 		Expect(os.Chdir(os.Getenv("ROUTER_GOPATH"))).To(Succeed())
-		builtExecutables["router"], err = gexec.Build("code.cloudfoundry.org/gorouter", "-race")
+		builtExecutables["router"], err = buildcache.Build("code.cloudfoundry.org/gorouter", "-race")
 		Expect(err).NotTo(HaveOccurred())
 		Expect(os.Chdir(cwd)).To(Succeed())
 	}
 
-	builtExecutables["routing-api"], err = gexec.Build("code.cloudfoundry.org/routing-api/cmd/routing-api", "-race")
+	builtExecutables["routing-api"], err = buildcache.Build("code.cloudfoundry.org/routing-api/cmd/routing-api", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["ssh-proxy"], err = gexec.Build("code.cloudfoundry.org/diego-ssh/cmd/ssh-proxy", "-race")
+	builtExecutables["ssh-proxy"], err = buildcache.Build("code.cloudfoundry.org/diego-ssh/cmd/ssh-proxy", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	os.Setenv("CGO_ENABLED", "0")
-	builtExecutables["sshd"], err = gexec.Build("code.cloudfoundry.org/diego-ssh/cmd/sshd", "-a", "-installsuffix", "static")
-	os.Unsetenv("CGO_ENABLED")
+	builtExecutables["sshd"], err = buildcache.BuildWithEnvironment("code.cloudfoundry.org/diego-ssh/cmd/sshd", []string{"CGO_ENABLED=0"}, "-a", "-installsuffix", "static")
 	Expect(err).NotTo(HaveOccurred())
 
 	return builtExecutables
@@ -0,0 +1,158 @@
+package cell_test
+
+import (
+	"runtime"
+	"time"
+
+	auctioneerconfig "code.cloudfoundry.org/auctioneer/cmd/auctioneer/config"
+	bbsconfig "code.cloudfoundry.org/bbs/cmd/bbs/config"
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/world"
+	"code.cloudfoundry.org/locket"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Control plane failover", func() {
+	var (
+		locketClient locketmodels.LocketClient
+		processes    map[string]ifrit.Process
+	)
+
+	// a standby takes over once the holder's lock has expired
+	failoverBound := 2*locket.DefaultSessionTTL + 5*time.Second
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip(" not yet working on windows")
+		}
+
+		locketClient = componentMaker.LocketClient(lgr)
+		processes = map[string]ifrit.Process{}
+	})
+
+	AfterEach(func() {
+		for _, process := range processes {
+			helpers.StopProcesses(process)
+		}
+	})
+
+	Context("with two auctioneers", func() {
+		var auctioneers []world.ControlPlaneInstance
+
+		BeforeEach(func() {
+			auctioneers = []world.ControlPlaneInstance{
+				componentMaker.AuctioneerN(0),
+				componentMaker.AuctioneerN(1),
+			}
+
+			processes[auctioneers[0].Name] = ginkgomon.Invoke(auctioneers[0].Runner)
+			processes[auctioneers[1].Name] = ifrit.Background(auctioneers[1].Runner)
+		})
+
+		It("hands the auctioneer lock to the standby when the holder dies", func() {
+			holder := helpers.LockHoldingInstance(locketClient, world.AuctioneerLockKey, auctioneers)
+			Expect(holder.Name).To(Equal(auctioneers[0].Name))
+
+			ginkgomon.Kill(processes[holder.Name])
+
+			newHolder := helpers.ExpectFailover(locketClient, world.AuctioneerLockKey, holder, auctioneers, failoverBound)
+			Expect(newHolder.Name).To(Equal(auctioneers[1].Name))
+			helpers.TimedEventually(processes[newHolder.Name].Ready()).Should(BeClosed())
+		})
+	})
+
+	Context("with two BBSes", func() {
+		var bbses []world.ControlPlaneInstance
+
+		BeforeEach(func() {
+			// the suite's BBS is the first instance and already holds the lock
+			bbses = []world.ControlPlaneInstance{
+				componentMaker.BBSN(0),
+				componentMaker.BBSN(1),
+			}
+
+			processes[bbses[1].Name] = ifrit.Background(bbses[1].Runner)
+		})
+
+		It("lets clients reach the standby once the holder dies", func() {
+			holder := helpers.LockHoldingInstance(locketClient, world.BBSLockKey, bbses)
+			Expect(holder.Name).To(Equal(bbses[0].Name))
+
+			standbyClient := componentMaker.BBSClientAt(bbses[1].Address)
+			Expect(standbyClient.Ping(lgr)).To(BeFalse())
+
+			ginkgomon.Kill(bbsProcess)
+
+			newHolder := helpers.ExpectFailover(locketClient, world.BBSLockKey, holder, bbses, failoverBound)
+			Expect(newHolder.Name).To(Equal(bbses[1].Name))
+			helpers.TimedEventually(processes[newHolder.Name].Ready(), failoverBound).Should(BeClosed())
+			helpers.TimedEventually(func() bool { return standbyClient.Ping(lgr) }, failoverBound).Should(BeTrue())
+
+			_, err := standbyClient.Domains(lgr)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("with two Locket servers", func() {
+		var (
+			lockets    []world.ControlPlaneInstance
+			bbs        world.ControlPlaneInstance
+			auctioneer world.ControlPlaneInstance
+		)
+
+		BeforeEach(func() {
+			lockets = []world.ControlPlaneInstance{
+				componentMaker.LocketN(1),
+				componentMaker.LocketN(2),
+			}
+			processes[lockets[0].Name] = ginkgomon.Invoke(lockets[0].Runner)
+			processes[lockets[1].Name] = ginkgomon.Invoke(lockets[1].Runner)
+
+			proxy, locketAddress := componentMaker.FailoverProxy(lockets[0].Address, lockets[1].Address)
+			processes["locket-proxy"] = ginkgomon.Invoke(proxy)
+
+			// swap the suite's BBS for one that reaches Locket through the
+			// proxy, i.e. through the first server while it is up
+			ginkgomon.Interrupt(bbsProcess)
+			bbs = componentMaker.BBSN(0, func(cfg *bbsconfig.BBSConfig) {
+				cfg.ClientLocketConfig.LocketAddress = locketAddress
+			})
+			bbsRunner = bbs.Runner
+			bbsProcess = ginkgomon.Invoke(bbsRunner)
+
+			auctioneer = componentMaker.AuctioneerN(0, func(cfg *auctioneerconfig.AuctioneerConfig) {
+				cfg.ClientLocketConfig.LocketAddress = locketAddress
+			})
+			processes[auctioneer.Name] = ginkgomon.Invoke(auctioneer.Runner)
+		})
+
+		It("keeps the BBS and the lock holders working through the other server when one dies", func() {
+			survivor := componentMaker.LocketClientAt(lgr, lockets[1].Address)
+			helpers.TimedEventually(helpers.LockHolderPoller(survivor, world.BBSLockKey)).Should(Equal(bbs.UUID))
+			helpers.TimedEventually(helpers.LockHolderPoller(survivor, world.AuctioneerLockKey)).Should(Equal(auctioneer.UUID))
+
+			ginkgomon.Kill(processes[lockets[0].Name])
+
+			// cells are looked up in Locket, so this only works once the BBS
+			// has reconnected to the survivor
+			helpers.TimedEventually(func() error {
+				_, err := bbsClient.Cells(lgr)
+				return err
+			}, failoverBound).Should(Succeed())
+
+			// holding on past the session TTL means the locks were renewed
+			// through the survivor
+			Consistently(helpers.LockHolderPoller(survivor, world.BBSLockKey), failoverBound).Should(Equal(bbs.UUID))
+			Expect(helpers.LockHolder(survivor, world.AuctioneerLockKey)).To(Equal(auctioneer.UUID))
+
+			Expect(bbsProcess.Wait()).NotTo(Receive())
+			Expect(processes[auctioneer.Name].Wait()).NotTo(Receive())
+			Expect(bbsClient.Ping(lgr)).To(BeTrue())
+		})
+	})
+})
@@ -90,15 +90,14 @@ var _ = Describe("Convergence to desired state", func() {
 
 				By("restarting the bbs with smaller convergeRepeatInterval")
 				ginkgomon.Interrupt(bbsProcess)
-				bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-					overrideConvergenceRepeatInterval,
-				))
+				bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval)
+				bbsProcess = ginkgomon.Invoke(bbsRunner)
 
 				By("creating and ActualLRP")
 				err := bbsClient.DesireLRP(lgr, helpers.DefaultLRPCreateRequest(componentMaker.Addresses(), processGuid, appId, 2))
 				Expect(err).NotTo(HaveOccurred())
-				Eventually(runningLRPsPoller).Should(HaveLen(2))
-				Eventually(helloWorldInstancePoller).Should(Equal([]string{"0", "1"}))
+				helpers.TimedEventually(runningLRPsPoller).Should(HaveLen(2))
+				helpers.TimedEventually(helloWorldInstancePoller).Should(Equal([]string{"0", "1"}))
 
 				By("collecting the ActualLRP instance guids")
 				initialActuals := runningLRPsPoller()
@@ -106,24 +105,26 @@ var _ = Describe("Convergence to desired state", func() {
 			})
 
 			It("marks the LRPs as Suspect until the rep comes back, and then marks the LRPs as Ordinary", func() {
-				By("killing the lone rep")
-				ginkgomon.Interrupt(rep)
-
-				By("Asserting that the LRPs are marked as Suspect")
-				Eventually(runningLRPsPresencePoller(models.ActualLRP_Suspect)).Should(HaveLen(2))
-
-				By("bringing back the original rep")
-				rep = ginkgomon.Invoke(componentMaker.Rep())
-
-				Eventually(runningLRPsPoller).Should(HaveLen(2))
-				Eventually(helloWorldInstancePoller).Should(Equal([]string{"0", "1"}))
+				By("killing the lone rep, waiting for the LRPs to be marked as Suspect and bringing back the original rep")
+				schedule := helpers.NewFaultSchedule().
+					Target("rep", rep, func() ifrit.Runner { return componentMaker.Rep() }).
+					Stop("rep").
+					WaitUntil("the LRPs are marked as Suspect", func() bool {
+						return len(runningLRPsPresencePoller(models.ActualLRP_Suspect)()) == 2
+					}, helpers.DEFAULT_EVENTUALLY_TIMEOUT).
+					Restart("rep")
+				Expect(schedule.Run()).To(Succeed())
+				rep = schedule.Process("rep")
+
+				helpers.TimedEventually(runningLRPsPoller).Should(HaveLen(2))
+				helpers.TimedEventually(helloWorldInstancePoller).Should(Equal([]string{"0", "1"}))
 
 				By("Asserting that the LRPs marked as Ordinary")
 				currentActuals := runningLRPsPoller()
 				instanceGuids := []string{currentActuals[0].InstanceGuid, currentActuals[1].InstanceGuid}
 				Expect(instanceGuids).NotTo(ContainElement(initialInstanceGuids[0]))
 				Expect(instanceGuids).NotTo(ContainElement(initialInstanceGuids[1]))
-				Eventually(runningLRPsPresencePoller(models.ActualLRP_Ordinary)).Should(HaveLen(2))
+				helpers.TimedEventually(runningLRPsPresencePoller(models.ActualLRP_Ordinary)).Should(HaveLen(2))
 			})
 
 			Context("and a second rep is running", func() {
@@ -144,10 +145,10 @@ var _ = Describe("Convergence to desired state", func() {
 					ginkgomon.Interrupt(rep)
 
 					By("Asserting that the LRPs are marked as Suspect")
-					Eventually(runningLRPsPresencePoller(models.ActualLRP_Suspect)).Should(HaveLen(2))
+					helpers.TimedEventually(runningLRPsPresencePoller(models.ActualLRP_Suspect)).Should(HaveLen(2))
 
 					By("Asserting that the LRPs are started on the second rep")
-					Eventually(func() bool {
+					helpers.TimedEventually(func() bool {
 						secondActualLRPs := runningLRPsPoller()
 						if len(secondActualLRPs) != 2 {
 							return false
@@ -163,9 +164,8 @@ var _ = Describe("Convergence to desired state", func() {
 			BeforeEach(func() {
 				By("restarting the bbs with smaller convergeRepeatInterval")
 				ginkgomon.Interrupt(bbsProcess)
-				bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-					overrideConvergenceRepeatInterval,
-				))
+				bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval)
+				bbsProcess = ginkgomon.Invoke(bbsRunner)
 			})
 
 			Context("and an LRP is desired", func() {
@@ -183,8 +183,8 @@ var _ = Describe("Convergence to desired state", func() {
 					})
 
 					It("eventually brings the LRP up", func() {
-						Eventually(runningLRPsPoller).Should(HaveLen(1))
-						Eventually(helloWorldInstancePoller).Should(Equal([]string{"0"}))
+						helpers.TimedEventually(runningLRPsPoller).Should(HaveLen(1))
+						helpers.TimedEventually(helloWorldInstancePoller).Should(Equal([]string{"0"}))
 					})
 				})
 			})
@@ -195,9 +195,8 @@ var _ = Describe("Convergence to desired state", func() {
 		BeforeEach(func() {
 			By("restarting the bbs with smaller convergeRepeatInterval")
 			ginkgomon.Interrupt(bbsProcess)
-			bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-				overrideConvergenceRepeatInterval,
-			))
+			bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval)
+			bbsProcess = ginkgomon.Invoke(bbsRunner)
 		})
 
 		Context("when a rep is running with no auctioneer", func() {
@@ -220,8 +219,8 @@ var _ = Describe("Convergence to desired state", func() {
 					})
 
 					It("eventually brings it up", func() {
-						Eventually(runningLRPsPoller).Should(HaveLen(1))
-						Eventually(helloWorldInstancePoller).Should(Equal([]string{"0"}))
+						helpers.TimedEventually(runningLRPsPoller).Should(HaveLen(1))
+						helpers.TimedEventually(helloWorldInstancePoller).Should(Equal([]string{"0"}))
 					})
 				})
 			})
@@ -247,8 +246,8 @@ var _ = Describe("Convergence to desired state", func() {
 					})
 
 					It("eventually brings it up", func() {
-						Eventually(runningLRPsPoller).Should(HaveLen(1))
-						Eventually(helloWorldInstancePoller).Should(Equal([]string{"0"}))
+						helpers.TimedEventually(runningLRPsPoller).Should(HaveLen(1))
+						helpers.TimedEventually(helloWorldInstancePoller).Should(Equal([]string{"0"}))
 					})
 				})
 			})
@@ -0,0 +1,129 @@
+package cell_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"runtime"
+
+	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/inigo/helpers"
+	repconfig "code.cloudfoundry.org/rep/cmd/rep/config"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Download cache", func() {
+	const dependencyPath = "/dependency.tgz"
+
+	var (
+		cellProcess ifrit.Process
+		server      *helpers.FaultServer
+		cfgs        []func(*repconfig.RepConfig)
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip(" not yet working on windows")
+		}
+
+		server = helpers.NewFaultServer(os.Getenv("EXTERNAL_ADDRESS"))
+		server.Serve(dependencyPath, dependencyArchive())
+
+		cfgs = nil
+	})
+
+	JustBeforeEach(func() {
+		cellProcess = ginkgomon.Invoke(grouper.NewParallel(os.Interrupt, grouper.Members{
+			{"rep", componentMaker.Rep(cfgs...)},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		helpers.TimedEventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(lgr) }).Should(HaveLen(1))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(cellProcess)
+		server.Close()
+	})
+
+	runTasks := func(n int) {
+		for i := 0; i < n; i++ {
+			guid := helpers.GenerateGuid()
+			task := helpers.TaskCreateRequest(guid, &models.RunAction{
+				User: "vcap",
+				Path: "test",
+				Args: []string{"-f", "/home/vcap/dependency/payload"},
+			})
+			task.CachedDependencies = []*models.CachedDependency{{
+				Name:     "dependency",
+				From:     server.URL(dependencyPath),
+				To:       "/home/vcap/dependency",
+				CacheKey: "download-cache-dependency",
+			}}
+
+			err := bbsClient.DesireTask(lgr, task.TaskGuid, task.Domain, task.TaskDefinition)
+			Expect(err).NotTo(HaveOccurred())
+			helpers.TimedEventually(helpers.TaskStatePoller(lgr, bbsClient, guid, nil)).Should(Equal(models.Task_Completed))
+		}
+	}
+
+	cacheStats := func() helpers.DownloadCacheStats {
+		return server.CacheStats(dependencyPath)
+	}
+
+	It("downloads the dependency once across containers and revalidates it with its ETag", func() {
+		runTasks(3)
+
+		Expect(cacheStats()).To(helpers.BeDownloadedOnce())
+		Expect(cacheStats()).To(helpers.BeRevalidatedWithETag())
+	})
+
+	Context("when the cache is smaller than the dependency", func() {
+		var cachePath string
+
+		BeforeEach(func() {
+			cfgs = append(cfgs,
+				helpers.WithDownloadCacheSize(1024),
+				helpers.CaptureDownloadCachePath(&cachePath),
+			)
+		})
+
+		It("evicts it and downloads it again", func() {
+			runTasks(2)
+
+			Expect(cacheStats()).To(helpers.BeRefetched())
+			Expect(helpers.DirSize(cachePath)).To(BeNumerically("<=", 1024))
+		})
+	})
+})
+
+// dependencyArchive is a tgz larger than the 1k cache used above, so that it
+// cannot stay cached there.
+func dependencyArchive() []byte {
+	archive, err := ioutil.TempFile("", "dependency")
+	Expect(err).NotTo(HaveOccurred())
+	archive.Close()
+	defer os.Remove(archive.Name())
+
+	archive_helper.CreateTarGZArchive(archive.Name(), []archive_helper.ArchiveFile{
+		{Name: "payload", Body: randomBody(64 * 1024)},
+	})
+
+	content, err := ioutil.ReadFile(archive.Name())
+	Expect(err).NotTo(HaveOccurred())
+	return content
+}
+
+func randomBody(size int) string {
+	var body bytes.Buffer
+	for body.Len() < size {
+		body.WriteString(helpers.GenerateGuid())
+	}
+	return body.String()
+}
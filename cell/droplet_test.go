@@ -0,0 +1,145 @@
+package cell_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/inigo/fixtures"
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/inigo_announcement_server"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Droplets", func() {
+	var (
+		ifritRuntime ifrit.Process
+		fileServer   *helpers.FileServerHandle
+
+		processGuid string
+		droplet     *fixtures.DropletBuilder
+		lrp         *models.DesiredLRP
+	)
+
+	// launch runs command through the buildpack lifecycle's launcher, from
+	// the droplet's app directory. An empty command starts the droplet's
+	// start_command.
+	launch := func(command string) *models.RunAction {
+		return &models.RunAction{
+			User: "vcap",
+			Path: "/tmp/lifecycle/launcher",
+			Args: []string{"app", command, "{}"},
+			Env:  []*models.EnvironmentVariable{{"PORT", "8080"}},
+		}
+	}
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip(" not yet working on windows")
+		}
+
+		fileServerRunner, fileServerStaticDir := componentMaker.FileServer()
+		fileServer = helpers.NewFileServerHandle(fileServerStaticDir, componentMaker.Addresses().FileServer)
+
+		ifritRuntime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", componentMaker.Router()},
+			{"file-server", fileServerRunner},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"route-emitter", componentMaker.RouteEmitter()},
+		}))
+
+		processGuid = helpers.GenerateGuid()
+
+		droplet = fixtures.NewDroplet().
+			WithApp(fixtures.GoServer).
+			WithApp(fixtures.SleepThenAnnounce).
+			WithBuildpack(fixtures.Buildpack{Key: "inigo-buildpack", Name: "inigo_buildpack"}).
+			WithProfileScript("buildpack.sh", "export BUILDPACK_PROFILE=sourced\nexport PROFILE_ORDER=buildpack\n").
+			WithAppProfileScript("app.sh", "export APP_PROFILE=sourced\nexport PROFILE_ORDER=\"${PROFILE_ORDER},app\"\n").
+			WithProcessType("worker", "PROCESS_TYPE=worker ./go-server")
+
+		lrp = helpers.DefaultLRPCreateRequest(componentMaker.Addresses(), processGuid, "log-guid", 1)
+		lrp.CachedDependencies = []*models.CachedDependency{{
+			Name:      "buildpack app lifecycle",
+			From:      fmt.Sprintf("http://%s/v1/static/buildpack_app_lifecycle/buildpack_app_lifecycle.tgz", componentMaker.Addresses().FileServer),
+			To:        "/tmp/lifecycle",
+			CacheKey:  "buildpack-app-lifecycle",
+			LogSource: "buildpack-app-lifecycle",
+		}}
+		lrp.Action = models.WrapAction(launch(""))
+	})
+
+	JustBeforeEach(func() {
+		published := fileServer.PublishDroplet("droplet.tgz", droplet)
+		lrp.Setup = models.WrapAction(&models.DownloadAction{
+			From: published.URL,
+			To:   "/home/vcap",
+			User: "vcap",
+		})
+
+		err := bbsClient.DesireLRP(lgr, lrp)
+		Expect(err).NotTo(HaveOccurred())
+
+		helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+		helpers.TimedEventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(Equal(http.StatusOK))
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(ifritRuntime)
+	})
+
+	appEnv := func() string {
+		response, status, err := helpers.ResponseBodyAndStatusCodeFromHost(componentMaker.Addresses().Router, helpers.DefaultHost, "env")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status).To(Equal(http.StatusOK))
+		return string(response)
+	}
+
+	It("starts the droplet's start command with its profile scripts sourced, buildpack ones first", func() {
+		env := appEnv()
+		Expect(env).NotTo(ContainSubstring("PROCESS_TYPE=worker"))
+		Expect(env).To(ContainSubstring("BUILDPACK_PROFILE=sourced\n"))
+		Expect(env).To(ContainSubstring("APP_PROFILE=sourced\n"))
+		Expect(env).To(ContainSubstring("PROFILE_ORDER=buildpack,app\n"))
+	})
+
+	Context("when running another process type", func() {
+		BeforeEach(func() {
+			lrp.Action = models.WrapAction(launch(droplet.StartCommand("worker")))
+		})
+
+		It("runs that process type's command", func() {
+			Expect(appEnv()).To(ContainSubstring("PROCESS_TYPE=worker\n"))
+		})
+	})
+
+	Context("when the droplet has a sidecar", func() {
+		BeforeEach(func() {
+			// sidecars must keep running, or they take the app down with them
+			sidecar := fixtures.Sidecar{
+				Name:         "announcer",
+				ProcessTypes: []string{"web"},
+				Command:      "./sleep-then-announce -url " + inigo_announcement_server.AnnounceURL(processGuid+"-sidecar") + " && sleep 3600",
+				MemoryMb:     32,
+			}
+			droplet.WithSidecar(sidecar)
+
+			lrp.Sidecars = []*models.Sidecar{{
+				Action:   models.WrapAction(launch(sidecar.Command)),
+				MemoryMb: int32(sidecar.MemoryMb),
+			}}
+		})
+
+		It("runs the sidecar alongside the app", func() {
+			helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(processGuid + "-sidecar"))
+		})
+	})
+})
@@ -1,8 +1,6 @@
 package cell_test
 
 import (
-	"fmt"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -12,11 +10,11 @@ import (
 	"code.cloudfoundry.org/archiver/extractor/test_helper"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/durationjson"
-	"code.cloudfoundry.org/guardian/gqt/runner"
 	"code.cloudfoundry.org/inigo/fixtures"
 	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/helpers/fakegarden"
+	"code.cloudfoundry.org/inigo/world"
 	repconfig "code.cloudfoundry.org/rep/cmd/rep/config"
-	"code.cloudfoundry.org/tlsconfig"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 	"github.com/tedsuo/ifrit/grouper"
@@ -29,25 +27,19 @@ var _ = Describe("Evacuation", func() {
 	var (
 		ifritRuntime ifrit.Process
 
-		cellAID            string
-		cellARepAddr       string
-		cellARepSecureAddr string
+		cellA *world.CellHandle
+		cellB *world.CellHandle
 
-		cellBID            string
-		cellBRepAddr       string
-		cellBRepSecureAddr string
+		cellAConfigs []func(*repconfig.RepConfig)
 
-		cellARepRunner *ginkgomon.Runner
-		cellBRepRunner *ginkgomon.Runner
+		cellAProcess ifrit.Process
+		cellBProcess ifrit.Process
 
-		cellA ifrit.Process
-		cellB ifrit.Process
+		monitor        *helpers.InvariantMonitor
+		monitorProcess ifrit.Process
 
-		processGuid    string
-		lrp            *models.DesiredLRP
-		cellPortsStart uint16
-
-		httpClient *http.Client
+		processGuid string
+		lrp         *models.DesiredLRP
 	)
 
 	BeforeEach(func() {
@@ -60,9 +52,8 @@ var _ = Describe("Evacuation", func() {
 
 		By("restarting the bbs with smaller convergeRepeatInterval")
 		ginkgomon.Interrupt(bbsProcess)
-		bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-			overrideConvergenceRepeatInterval,
-		))
+		bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval)
+		bbsProcess = ginkgomon.Invoke(bbsRunner)
 
 		ifritRuntime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
 			{"router", componentMaker.Router()},
@@ -71,49 +62,9 @@ var _ = Describe("Evacuation", func() {
 			{"route-emitter", componentMaker.RouteEmitter()},
 		}))
 
-		cellAID = "cell-a"
-		cellBID = "cell-b"
-
-		var err error
-		cellPortsStart, err = componentMaker.PortAllocator().ClaimPorts(4)
-		Expect(err).NotTo(HaveOccurred())
-
-		tlscfg, err := tlsconfig.Build(
-			tlsconfig.WithInternalServiceDefaults(),
-			tlsconfig.WithIdentityFromFile(componentMaker.RepSSLConfig().ServerCert, componentMaker.RepSSLConfig().ServerKey),
-		).Client(
-			tlsconfig.WithAuthorityFromFile(componentMaker.RepSSLConfig().CACert),
-		)
-		Expect(err).NotTo(HaveOccurred())
-
-		httpClient = &http.Client{
-			Timeout: 5 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: tlscfg,
-			},
-		}
-
-		cellARepAddr = fmt.Sprintf("0.0.0.0:%d", cellPortsStart)
-		cellARepSecureAddr = fmt.Sprintf("0.0.0.0:%d", cellPortsStart+1)
-		cellBRepAddr = fmt.Sprintf("0.0.0.0:%d", cellPortsStart+2)
-		cellBRepSecureAddr = fmt.Sprintf("0.0.0.0:%d", cellPortsStart+3)
-
-		cellARepRunner = componentMaker.RepN(0,
-			func(config *repconfig.RepConfig) {
-				config.CellID = cellAID
-				config.ListenAddr = cellARepAddr
-				config.ListenAddrSecurable = cellARepSecureAddr
-				config.EvacuationTimeout = durationjson.Duration(30 * time.Second)
-			},
-		)
-
-		cellBRepRunner = componentMaker.RepN(1,
-			func(config *repconfig.RepConfig) {
-				config.CellID = cellBID
-				config.ListenAddr = cellBRepAddr
-				config.ListenAddrSecurable = cellBRepSecureAddr
-				config.EvacuationTimeout = durationjson.Duration(30 * time.Second)
-			})
+		cellAConfigs = []func(*repconfig.RepConfig){func(config *repconfig.RepConfig) {
+			config.EvacuationTimeout = durationjson.Duration(30 * time.Second)
+		}}
 
 		test_helper.CreateZipArchive(
 			filepath.Join(fileServerStaticDir, "lrp.zip"),
@@ -124,12 +75,23 @@ var _ = Describe("Evacuation", func() {
 	})
 
 	JustBeforeEach(func() {
-		cellA = ginkgomon.Invoke(cellARepRunner)
-		cellB = ginkgomon.Invoke(cellBRepRunner)
+		cellA = componentMaker.Cell(0, cellAConfigs...)
+		cellB = componentMaker.Cell(1, func(config *repconfig.RepConfig) {
+			config.EvacuationTimeout = durationjson.Duration(30 * time.Second)
+		})
+
+		cellAProcess = ginkgomon.Invoke(cellA.Runner)
+		cellBProcess = ginkgomon.Invoke(cellB.Runner)
+
+		// instances move between cells throughout these specs; none of the
+		// Diego invariants may break while they do
+		monitor = helpers.NewInvariantMonitor(lgr, bbsClient, componentMaker.Addresses().Router, helpers.DefaultInvariants()...)
+		monitorProcess = ginkgomon.Invoke(monitor)
 	})
 
 	AfterEach(func() {
-		helpers.StopProcesses(ifritRuntime, cellA, cellB)
+		helpers.StopProcesses(monitorProcess, ifritRuntime, cellAProcess, cellBProcess)
+		Expect(monitor.Violations()).To(BeEmpty())
 	})
 
 	It("handles evacuation", func() {
@@ -138,8 +100,8 @@ var _ = Describe("Evacuation", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		By("running an actual LRP instance")
-		Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-		Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(Equal(http.StatusOK))
+		helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+		helpers.TimedEventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(Equal(http.StatusOK))
 
 		index := int32(0)
 		lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid, Index: &index})
@@ -147,82 +109,87 @@ var _ = Describe("Evacuation", func() {
 		Expect(len(lrps)).To(Equal(1))
 		Expect(lrps[0].Presence).NotTo(Equal(models.ActualLRP_Evacuating))
 
-		var evacuatingRepPort uint16
-		var evacuatingRepRunner *ginkgomon.Runner
+		var evacuatingCell *world.CellHandle
 
 		switch lrps[0].CellId {
-		case cellAID:
-			evacuatingRepRunner = cellARepRunner
-			evacuatingRepPort = cellPortsStart
-		case cellBID:
-			evacuatingRepRunner = cellBRepRunner
-			evacuatingRepPort = cellPortsStart + 2
+		case cellA.ID:
+			evacuatingCell = cellA
+		case cellB.ID:
+			evacuatingCell = cellB
 		default:
 			panic("what? who?")
 		}
 
+		Expect(evacuatingCell.Ping()).To(Succeed())
+
 		By("posting the evacuation endpoint")
-		// Rep admin endpoint verifies and validate 127.0.0.1 for IP SAN
-		resp, err := httpClient.Post(fmt.Sprintf("https://127.0.0.1:%d/evacuate", evacuatingRepPort), "text/html", nil)
+		evacuatingCell.Evacuate()
+
+		By("reporting that it is evacuating")
+		state, err := evacuatingCell.State(lgr)
 		Expect(err).NotTo(HaveOccurred())
-		resp.Body.Close()
-		Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+		Expect(state.Evacuating).To(BeTrue())
 
 		By("staying routable so long as its rep is alive")
-		Eventually(func() int {
+		helpers.TimedEventually(func() int {
 			Expect(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost)()).To(Equal(http.StatusOK))
-			return evacuatingRepRunner.ExitCode()
+			return evacuatingCell.Runner.ExitCode()
 		}).Should(Equal(0))
 
+		By("leaving no containers behind")
+		evacuatingCell.WaitUntilDrained(10 * time.Second)
+
 		By("running immediately after the rep exits and is routable")
 		Expect(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)()).To(Equal(models.ActualLRPStateRunning))
 		Consistently(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(Equal(http.StatusOK))
 	})
 
 	Context("when garden Destroy hangs", func() {
+		var (
+			proxy        *fakegarden.Proxy
+			proxyProcess ifrit.Process
+		)
+
 		BeforeEach(func() {
-			replaceGrootFSWithHangingVersion()
-			cellARepRunner = componentMaker.RepN(0,
-				func(config *repconfig.RepConfig) {
-					config.CellID = cellAID
-					config.ListenAddr = cellARepAddr
-					config.ListenAddrSecurable = cellARepSecureAddr
-					config.GracefulShutdownInterval = 1 // 1 nanosecond otherwise a 0 is treated as omitted value
-				},
-			)
+			proxy = fakegarden.NewProxy(componentMaker.GardenClient())
+
+			proxyRunner, proxyAddress := componentMaker.GardenProxy(proxy)
+			proxyProcess = ginkgomon.Invoke(proxyRunner)
+
+			cellAConfigs = append(cellAConfigs, func(config *repconfig.RepConfig) {
+				config.GracefulShutdownInterval = 1 // 1 nanosecond otherwise a 0 is treated as omitted value
+				config.GardenAddr = proxyAddress
+			})
+		})
+
+		AfterEach(func() {
+			// let the hung Destroys through so that the containers get cleaned up
+			proxy.Clear()
+			ginkgomon.Interrupt(proxyProcess)
 		})
 
 		JustBeforeEach(func() {
 			// kill cell-b to simplify the test. otherwise, we will have to figure
 			// out which cell to evacuate
-			ginkgomon.Kill(cellB)
+			ginkgomon.Kill(cellBProcess)
 		})
 
 		It("shuts down gracefully after the evacuation timeout", func() {
-			time.Sleep(time.Minute)
-
 			err := bbsClient.DesireLRP(lgr, lrp)
 			Expect(err).NotTo(HaveOccurred())
 
 			By("running an actual LRP instance")
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+
+			proxy.Inject(fakegarden.CallDestroy, fakegarden.Hang())
 
 			By("posting the evacuation endpoint")
-			// Rep admin endpoint verifies and validate 127.0.0.1 for IP SAN
-			resp, err := httpClient.Post(fmt.Sprintf("https://127.0.0.1:%d/evacuate", cellPortsStart), "text/html", nil)
-			Expect(err).NotTo(HaveOccurred())
-			resp.Body.Close()
-			Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
-
-			factory := componentMaker.RepClientFactory()
-			addr := fmt.Sprintf("https://%s.cell.service.cf.internal:%d", cellAID, cellPortsStart)
-			secureAddr := fmt.Sprintf("https://%s.cell.service.cf.internal:%d", cellAID, cellPortsStart+1)
-			// NewClientFactory <<- tls
-			client, err := factory.CreateClient(addr, secureAddr)
-			Expect(err).NotTo(HaveOccurred())
+			cellA.Evacuate()
+
+			client := cellA.Client()
 
 			index := int32(0)
-			lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: lrp.ProcessGuid, Index: &index, CellID: cellAID})
+			lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: lrp.ProcessGuid, Index: &index, CellID: cellA.ID})
 			Expect(err).NotTo(HaveOccurred())
 			// This list of LRPs can have one or both of an EVACUATING LRP and an ORDINARY LRP (if we
 			// happen to catch the BBS before it has transitioned the ORDINARY one to UNCLAIMED). We don't
@@ -230,7 +197,7 @@ var _ = Describe("Evacuation", func() {
 			// is at least one.
 			Expect(lrps).NotTo(BeEmpty())
 
-			// the following requests will hang since garden is stuck trying to destroy the containers
+			// the following requests will hang since the proxy holds on to every Destroy
 			go func() {
 				for i := 0; i < 100; i++ {
 					err := client.StopLRPInstance(lgr, lrps[0].ActualLRPKey, lrps[0].ActualLRPInstanceKey)
@@ -242,31 +209,8 @@ var _ = Describe("Evacuation", func() {
 			}()
 
 			// hanging http requests shouldn't prevent the process from exiting
-			Eventually(cellA.Wait(), 10*time.Second).Should(Receive())
+			helpers.TimedEventually(cellAProcess.Wait(), 10*time.Second).Should(Receive())
+			Expect(proxy.CallCount(fakegarden.CallDestroy)).To(BeNumerically(">", 0))
 		})
 	})
 })
-
-func replaceGrootFSWithHangingVersion() {
-	f, err := ioutil.TempFile(os.TempDir(), "image_plugin")
-	Expect(err).NotTo(HaveOccurred())
-	Expect(f.Chmod(0755)).To(Succeed())
-	path := filepath.Join(os.Getenv("GROOTFS_BINPATH"), "grootfs")
-	os.Remove(fmt.Sprintf("/tmp/image_plugin_sleep_%d", GinkgoParallelProcess()))
-	fmt.Fprintf(f, `#!/usr/bin/env bash
-echo $(date +%%s) "$@" >> /tmp/image_plugin_trace
-lock_file=/tmp/image_plugin_sleep_%d
-# sleep on delete of non healthcheck container and only the first time
-if [[ "x$3" == "xdelete" && ! ( "$4" =~ .*healthcheck.* ) && ! -f $lock_file ]]; then
-  touch $lock_file
-  sleep 10
-fi
-%s "$@"
-`, GinkgoParallelProcess(), path)
-	Expect(f.Close()).To(Succeed())
-	ginkgomon.Interrupt(gardenProcess)
-	gardenProcess = ginkgomon.Invoke(componentMaker.Garden(func(config *runner.GdnRunnerConfig) {
-		config.ImagePluginBin = f.Name()
-		config.PrivilegedImagePluginBin = f.Name()
-	}))
-}
@@ -48,8 +48,6 @@ import (
 	"github.com/tedsuo/ifrit/grouper"
 )
 
-const GraceBusyboxImageURL = "docker:///cfdiegodocker/grace"
-
 var _ = Describe("InstanceIdentity", func() {
 	var (
 		validityPeriod                              time.Duration
@@ -159,7 +157,7 @@ var _ = Describe("InstanceIdentity", func() {
 		}
 		cellProcess = ginkgomon.Invoke(grouper.NewParallel(terminationSignal, cellGroup))
 
-		Eventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(lgr) }).Should(HaveLen(1))
+		helpers.TimedEventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(lgr) }).Should(HaveLen(1))
 	})
 
 	AfterEach(func() {
@@ -257,7 +255,7 @@ var _ = Describe("InstanceIdentity", func() {
 		JustBeforeEach(func() {
 			err := bbsClient.DesireLRP(lgr, lrp)
 			Expect(err).NotTo(HaveOccurred())
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
 
 			address = getContainerInternalAddress(bbsClient, processGUID, 8081, false)
 			ipAddress, _, err = net.SplitHostPort(address)
@@ -283,7 +281,7 @@ var _ = Describe("InstanceIdentity", func() {
 		JustBeforeEach(func() {
 			err := bbsClient.DesireLRP(lgr, lrp)
 			Expect(err).NotTo(HaveOccurred())
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
 
 			address = getContainerInternalAddress(bbsClient, processGUID, 8081, false)
 		})
@@ -431,7 +429,7 @@ var _ = Describe("InstanceIdentity", func() {
 			JustBeforeEach(func() {
 				err := bbsClient.DesireLRP(lgr, lrp)
 				Expect(err).NotTo(HaveOccurred())
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
 
 				address = getContainerInternalAddress(bbsClient, processGUID, 8080, true)
 			})
@@ -449,12 +447,54 @@ var _ = Describe("InstanceIdentity", func() {
 				})
 
 				It("should fail", func() {
-					Eventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: handshake failure")))
+					helpers.TimedEventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: handshake failure")))
 				})
 			})
 
 			It("should have a container with envoy enabled on it", func() {
-				Eventually(connect, 10*time.Second).Should(Succeed())
+				helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
+			})
+
+			Context("and the app ignores SIGTERM", func() {
+				BeforeEach(func() {
+					controllable := helpers.ControllableLRPCreateRequest(componentMaker.Addresses(), processGUID)
+					lrp.Action = controllable.Action
+					lrp.Monitor = controllable.Monitor
+					lrp.CheckDefinition = nil
+				})
+
+				Context("and is killed", func() {
+					JustBeforeEach(func() {
+						helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
+
+						app := helpers.NewGoServerClient(getContainerInternalAddress(bbsClient, processGUID, 8080, false), "")
+						Expect(app.IgnoreSIGTERM(true)).To(Succeed())
+
+						err := bbsClient.RemoveDesiredLRP(lgr, lrp.ProcessGuid)
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("continues to serve traffic", func() {
+						Consistently(connect, 5*time.Second).Should(Succeed())
+					})
+				})
+			})
+
+			Context("and the app echoes raw TCP", func() {
+				BeforeEach(func() {
+					protocols := helpers.ProtocolsLRPCreateRequest(componentMaker.Addresses(), processGUID)
+					lrp.Action = protocols.Action
+					lrp.Ports = protocols.Ports
+				})
+
+				It("passes the bytes through envoy's TLS listener", func() {
+					echoAddress := getContainerInternalAddress(bbsClient, processGUID, 9000, true)
+					tlsConfig := &tls.Config{RootCAs: rootCAs}
+
+					helpers.TimedEventually(func() (string, error) {
+						return helpers.TLSEcho(echoAddress, "hello through envoy", tlsConfig)
+					}, 10*time.Second).Should(Equal("hello through envoy"))
+				})
 			})
 
 			Context("when rep is configured for mutual tls", func() {
@@ -502,7 +542,7 @@ var _ = Describe("InstanceIdentity", func() {
 						})
 
 						It("should connect successfully", func() {
-							Eventually(connect, 10*time.Second).Should(Succeed())
+							helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
 						})
 					})
 
@@ -518,7 +558,7 @@ var _ = Describe("InstanceIdentity", func() {
 						})
 
 						It("should fail to connect", func() {
-							Eventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: unknown certificate")))
+							helpers.TimedEventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: unknown certificate")))
 						})
 					})
 
@@ -531,7 +571,7 @@ var _ = Describe("InstanceIdentity", func() {
 						})
 
 						It("should connect successfully", func() {
-							Eventually(connect, 10*time.Second).Should(Succeed())
+							helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
 						})
 					})
 				})
@@ -554,7 +594,7 @@ var _ = Describe("InstanceIdentity", func() {
 					})
 
 					It("should fail to connect with the wrong server cert", func() {
-						Eventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: certificate required")))
+						helpers.TimedEventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: certificate required")))
 					})
 				})
 
@@ -580,7 +620,7 @@ var _ = Describe("InstanceIdentity", func() {
 					})
 
 					It("should fail to connect", func() {
-						Eventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: certificate required")))
+						helpers.TimedEventually(connect, 10*time.Second).Should(MatchError(ContainSubstring("tls: certificate required")))
 					})
 				})
 			})
@@ -610,7 +650,7 @@ var _ = Describe("InstanceIdentity", func() {
 				})
 
 				It("should have a container with envoy enabled on it", func() {
-					Eventually(connect, 10*time.Second).Should(Succeed())
+					helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
 				})
 			})
 
@@ -623,33 +663,7 @@ var _ = Describe("InstanceIdentity", func() {
 				})
 
 				It("should have a container with envoy enabled on it", func() {
-					Eventually(connect, 10*time.Second).Should(Succeed())
-				})
-
-				Context("and the app ignores SIGTERM", func() {
-					BeforeEach(func() {
-						lrp.RootFs = GraceBusyboxImageURL
-						lrp.Monitor = nil
-						lrp.Setup = nil
-						lrp.Action = models.WrapAction(&models.RunAction{
-							Path: "/grace",
-							User: "root",
-							Env:  []*models.EnvironmentVariable{{Name: "PORT", Value: "8080"}},
-							Args: []string{"-catchTerminate"},
-						})
-					})
-
-					Context("and is killed", func() {
-						JustBeforeEach(func() {
-							Eventually(connect, 10*time.Second).Should(Succeed())
-							err := bbsClient.RemoveDesiredLRP(lgr, lrp.ProcessGuid)
-							Expect(err).NotTo(HaveOccurred())
-						})
-
-						It("continues to serve traffic", func() {
-							Consistently(connect, 5*time.Second).Should(Succeed())
-						})
-					})
+					helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
 				})
 			})
 
@@ -663,7 +677,7 @@ var _ = Describe("InstanceIdentity", func() {
 				})
 
 				It("should have a container with envoy enabled on it", func() {
-					Eventually(connect, 10*time.Second).Should(Succeed())
+					helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
 				})
 			})
 
@@ -686,7 +700,7 @@ var _ = Describe("InstanceIdentity", func() {
 				})
 
 				It("should have a container with envoy enabled on it", func() {
-					Eventually(connect, 10*time.Second).Should(Succeed())
+					helpers.TimedEventually(connect, 10*time.Second).Should(Succeed())
 				})
 			})
 
@@ -702,7 +716,7 @@ var _ = Describe("InstanceIdentity", func() {
 				})
 
 				It("should be able to reconnect with the updated certs", func() {
-					Eventually(connect).Should(Succeed())
+					helpers.TimedEventually(connect).Should(Succeed())
 					Consistently(connect, 90*time.Second, 20*time.Millisecond).Should(Succeed())
 				})
 			})
@@ -793,11 +807,11 @@ var _ = Describe("InstanceIdentity", func() {
 					})
 
 					It("should receive rescaled memory usage", func() {
-						Eventually(metricsChan, 10*time.Second).Should(Receive(scaledDownMemory(memoryLimit, 5)))
+						helpers.TimedEventually(metricsChan, 10*time.Second).Should(Receive(scaledDownMemory(memoryLimit, 5)))
 					})
 
 					It("should receive rescaled memory limit", func() {
-						Eventually(metricsChan, 10*time.Second).Should(Receive(HaveKeyWithValue("memory_quota", memoryInBytes(memoryLimit))))
+						helpers.TimedEventually(metricsChan, 10*time.Second).Should(Receive(HaveKeyWithValue("memory_quota", memoryInBytes(memoryLimit))))
 					})
 
 					Context("when additional memory is set and the LRP has unlimited memory", func() {
@@ -806,7 +820,7 @@ var _ = Describe("InstanceIdentity", func() {
 						})
 
 						It("should have unlimited memory", func() {
-							Eventually(metricsChan, 10*time.Second).Should(Receive(unlimitedMemory()))
+							helpers.TimedEventually(metricsChan, 10*time.Second).Should(Receive(unlimitedMemory()))
 						})
 					})
 
@@ -827,11 +841,11 @@ var _ = Describe("InstanceIdentity", func() {
 						})
 
 						It("should not scale the memory usage", func() {
-							Eventually(metricsChan, 10*time.Second).Should(Receive(unscaledDownMemory()))
+							helpers.TimedEventually(metricsChan, 10*time.Second).Should(Receive(unscaledDownMemory()))
 						})
 
 						It("should receive the right memory limit", func() {
-							Eventually(metricsChan, 10*time.Second).Should(Receive(HaveKeyWithValue("memory_quota", memoryInBytes(memoryLimit))))
+							helpers.TimedEventually(metricsChan, 10*time.Second).Should(Receive(HaveKeyWithValue("memory_quota", memoryInBytes(memoryLimit))))
 						})
 					})
 
@@ -846,11 +860,11 @@ var _ = Describe("InstanceIdentity", func() {
 						})
 
 						It("should receive rescaled memory limit", func() {
-							Eventually(metricsChan, 10*time.Second).Should(Receive(scaledDownMemory(memoryLimit, 5)))
+							helpers.TimedEventually(metricsChan, 10*time.Second).Should(Receive(scaledDownMemory(memoryLimit, 5)))
 						})
 
 						It("should receive the rescaled memory usage", func() {
-							Eventually(metricsChan, 10*time.Second).Should(Receive(HaveKeyWithValue("memory_quota", memoryInBytes(memoryLimit))))
+							helpers.TimedEventually(metricsChan, 10*time.Second).Should(Receive(HaveKeyWithValue("memory_quota", memoryInBytes(memoryLimit))))
 						})
 					})
 				})
@@ -886,7 +900,7 @@ var _ = Describe("InstanceIdentity", func() {
 
 			envoyIsHealthChecked := func() {
 				It("should be marked running only when both envoy and the app are available", func() {
-					Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
+					helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGUID, nil)).Should(Equal(models.ActualLRPStateRunning))
 					address = getContainerInternalAddress(bbsClient, processGUID, 8080, true)
 
 					Consistently(connect).Should(Succeed())
@@ -898,7 +912,7 @@ var _ = Describe("InstanceIdentity", func() {
 					})
 
 					It("crashes the lrp with a descriptive error", func() {
-						Eventually(func() *models.ActualLRP {
+						helpers.TimedEventually(func() *models.ActualLRP {
 							index := int32(0)
 							lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGUID, Index: &index})
 							Expect(err).NotTo(HaveOccurred())
@@ -1016,7 +1030,7 @@ func runTaskAndGetCommandOutput(command string, organizationalUnits []string) st
 	Expect(err).NotTo(HaveOccurred())
 
 	var task *models.Task
-	Eventually(func() interface{} {
+	helpers.TimedEventually(func() interface{} {
 		var err error
 
 		task, err = bbsClient.TaskByGuid(lgr, guid)
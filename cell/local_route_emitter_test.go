@@ -2,10 +2,12 @@ package cell_test
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"time"
 
 	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
@@ -39,6 +41,7 @@ var _ = Describe("LocalRouteEmitter", func() {
 		cellAID, cellBID, cellARepAddr, cellBRepAddr string
 		routeEmitterConfigs                          []func(*routeemitterconfig.RouteEmitterConfig)
 		cellAPort, cellBPort                         uint16
+		recorder                                     *helpers.RouteMessageRecorder
 	)
 
 	BeforeEach(func() {
@@ -46,6 +49,7 @@ var _ = Describe("LocalRouteEmitter", func() {
 			Skip(" not yet working on windows")
 		}
 		processGuid = helpers.GenerateGuid()
+		routeEmitterConfigs = nil
 
 		var fileServer ifrit.Runner
 		fileServer, fileServerStaticDir = componentMaker.FileServer()
@@ -63,15 +67,19 @@ var _ = Describe("LocalRouteEmitter", func() {
 		cellBRepAddr = fmt.Sprintf("0.0.0.0:%d", cellBPort)
 
 		ifritRuntime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
-			{"router", componentMaker.Router()},
 			{"file-server", fileServer},
 			{"auctioneer", componentMaker.Auctioneer()},
 		}))
 
+		// the route-emitters' NATS messages are what the router would act on
+		user, password := componentMaker.NATSCredentials()
+		recorder = helpers.NewRouteMessageRecorder(componentMaker.Addresses().NATS, user, password)
+
 		archiveFiles = fixtures.GoServerApp()
 	})
 
 	AfterEach(func() {
+		recorder.Close()
 		helpers.StopProcesses(ifritRuntime, cellAProcess, cellBProcess)
 	})
 
@@ -115,6 +123,7 @@ var _ = Describe("LocalRouteEmitter", func() {
 		var (
 			lrp       *models.DesiredLRP
 			instances int32
+			runningAt time.Time
 		)
 
 		BeforeEach(func() {
@@ -126,15 +135,18 @@ var _ = Describe("LocalRouteEmitter", func() {
 			lrp.Instances = instances
 			err := bbsClient.DesireLRP(lgr, lrp)
 			Expect(err).NotTo(HaveOccurred())
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			runningAt = time.Now()
 		})
 
-		It("eventually is accessible through the router within a second", func() {
-			Eventually(
-				helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost),
-				time.Second,
-				10*time.Millisecond,
-			).Should(Equal(http.StatusOK))
+		It("registers the lrp's route within a second", func() {
+			helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).Should(
+				HaveKeyWithValue(helpers.DefaultHost, ConsistOf(routeEndpoints(processGuid))),
+			)
+
+			registeredAt, registered := recorder.FirstRegistration(helpers.DefaultHost)
+			Expect(registered).To(BeTrue())
+			Expect(registeredAt).To(BeTemporally("<", runningAt.Add(time.Second)))
 		})
 
 		Context("when tcp route emitting is enabled", func() {
@@ -188,7 +200,7 @@ var _ = Describe("LocalRouteEmitter", func() {
 				})
 
 				It("emits the tcp route of the lrp", func() {
-					Eventually(func() error {
+					helpers.TimedEventually(func() error {
 						routes, err := routingAPIClient.TcpRouteMappings()
 						if err != nil {
 							return err
@@ -202,6 +214,40 @@ var _ = Describe("LocalRouteEmitter", func() {
 			})
 		})
 
+		Context("when tcp routes are emitted to a fake routing api", func() {
+			var routingAPI *helpers.FakeRoutingAPI
+
+			BeforeEach(func() {
+				routingAPI = helpers.NewFakeRoutingAPI()
+				routeEmitterConfigs = append(routeEmitterConfigs, routingAPI.RouteEmitterConfig())
+
+				tcpRoute := tcp_routes.TCPRoutes{
+					tcp_routes.TCPRoute{
+						RouterGroupGuid: helpers.FakeRouterGroupGuid,
+						ExternalPort:    1234,
+						ContainerPort:   8080,
+					},
+				}
+				lrp.Routes = tcpRoute.RoutingInfo()
+			})
+
+			AfterEach(func() {
+				routingAPI.Close()
+			})
+
+			It("records the upsert of the lrp's tcp route", func() {
+				helpers.TimedEventually(routingAPI.TCPRoutes, 2*time.Second).Should(HaveLen(1))
+
+				route := routingAPI.TCPRoutes()[0]
+				Expect(route.RouterGroupGuid).To(Equal(helpers.FakeRouterGroupGuid))
+				Expect(route.Port).To(BeEquivalentTo(1234))
+
+				events := routingAPI.Events()
+				Expect(events).NotTo(BeEmpty())
+				Expect(events[0].Action).To(Equal("upsert"))
+			})
+		})
+
 		Context("when there are 3 instances", func() {
 			BeforeEach(func() {
 				instances = 3
@@ -219,12 +265,15 @@ var _ = Describe("LocalRouteEmitter", func() {
 					)
 				})
 
-				It("eventually should make the new lrp routable within a second", func() {
-					Eventually(
-						helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost),
-						time.Second,
-						10*time.Millisecond,
-					).Should(ConsistOf([]string{"0", "1", "2"}))
+				It("registers the new instances' routes within a second", func() {
+					endpoints := routeEndpoints(processGuid)
+					Expect(endpoints).To(HaveLen(3))
+
+					for _, endpoint := range endpoints {
+						helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).Should(
+							HaveKeyWithValue(helpers.DefaultHost, ContainElement(endpoint)),
+						)
+					}
 				})
 			})
 
@@ -234,12 +283,8 @@ var _ = Describe("LocalRouteEmitter", func() {
 					Expect(err).NotTo(HaveOccurred())
 				})
 
-				It("eventually is not accessible through the router within a second", func() {
-					Eventually(
-						helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost),
-						time.Second,
-						10*time.Millisecond,
-					).Should(BeEmpty())
+				It("unregisters the lrp's route within a second", func() {
+					helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).ShouldNot(HaveKey(helpers.DefaultHost))
 				})
 			})
 
@@ -263,12 +308,12 @@ var _ = Describe("LocalRouteEmitter", func() {
 						desiredLRPUdate.SetInstances(newInstances)
 					})
 
-					It("eventually extra routes are removed within a second", func() {
-						Eventually(
-							helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost),
-							time.Second,
-							10*time.Millisecond,
-						).Should(ConsistOf([]string{"0"}))
+					It("unregisters the extra routes within a second", func() {
+						helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).Should(
+							HaveKeyWithValue(helpers.DefaultHost, HaveLen(1)),
+						)
+						helpers.TimedEventually(func() []string { return routeEndpoints(processGuid) }).Should(HaveLen(1))
+						Expect(recorder.RoutingTable()).To(HaveKeyWithValue(helpers.DefaultHost, ConsistOf(routeEndpoints(processGuid))))
 					})
 				})
 
@@ -278,12 +323,10 @@ var _ = Describe("LocalRouteEmitter", func() {
 						desiredLRPUdate.Routes = &routes
 					})
 
-					It("eventually is accessible using the new route within a second", func() {
-						Eventually(
-							helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, "some-other-route"),
-							time.Second,
-							10*time.Millisecond,
-						).Should(ConsistOf([]string{"0", "1", "2"}))
+					It("registers the new route within a second", func() {
+						helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).Should(
+							HaveKeyWithValue("some-other-route", ConsistOf(routeEndpoints(processGuid))),
+						)
 					})
 				})
 
@@ -293,12 +336,8 @@ var _ = Describe("LocalRouteEmitter", func() {
 						desiredLRPUdate.Routes = &routes
 					})
 
-					It("eventually not accessible using its route within a second", func() {
-						Eventually(
-							helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost),
-							time.Second,
-							10*time.Millisecond,
-						).Should(Equal(404))
+					It("unregisters its route within a second", func() {
+						helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).ShouldNot(HaveKey(helpers.DefaultHost))
 					})
 				})
 			})
@@ -323,16 +362,14 @@ var _ = Describe("LocalRouteEmitter", func() {
 
 				JustBeforeEach(func() {
 					for i := 1; i < int(newInstances); i++ {
-						Eventually(helpers.LRPInstanceStatePoller(lgr, bbsClient, processGuid, i, nil)).Should(Equal(models.ActualLRPStateRunning))
+						helpers.TimedEventually(helpers.LRPInstanceStatePoller(lgr, bbsClient, processGuid, i, nil)).Should(Equal(models.ActualLRPStateRunning))
 					}
 				})
 
-				It("eventually is accessible through the router within a second", func() {
-					Eventually(
-						helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost),
-						time.Second,
-						10*time.Millisecond,
-					).Should(ConsistOf([]string{"0", "1", "2"}))
+				It("registers the new instances' routes within a second", func() {
+					helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).Should(
+						HaveKeyWithValue(helpers.DefaultHost, ConsistOf(routeEndpoints(processGuid))),
+					)
 				})
 			})
 
@@ -341,12 +378,8 @@ var _ = Describe("LocalRouteEmitter", func() {
 					newInstances = 0
 				})
 
-				It("eventually is not accessible through the router within a second", func() {
-					Eventually(
-						helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost),
-						time.Second,
-						10*time.Millisecond,
-					).Should(BeEmpty())
+				It("unregisters the lrp's route within a second", func() {
+					helpers.TimedEventually(recorder.RoutingTable, time.Second, 10*time.Millisecond).ShouldNot(HaveKey(helpers.DefaultHost))
 				})
 			})
 		})
@@ -374,6 +407,20 @@ func createDesiredLRP(processGuid string) *models.DesiredLRP {
 	return lrp
 }
 
+// routeEndpoints returns the host:port every running instance of processGuid
+// is registered with the router as.
+func routeEndpoints(processGuid string) []string {
+	endpoints := []string{}
+	for _, actualLRP := range helpers.RunningActualLRPs(lgr, bbsClient, processGuid) {
+		for _, mapping := range actualLRP.Ports {
+			if mapping.ContainerPort == 8080 {
+				endpoints = append(endpoints, net.JoinHostPort(actualLRP.Address, strconv.Itoa(int(mapping.HostPort))))
+			}
+		}
+	}
+	return endpoints
+}
+
 func evacuateARep(
 	processGuid string,
 	logger lager.Logger,
@@ -434,7 +481,7 @@ func evacuateARep(
 	Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
 
 	By("waiting for the lrp to run on the new cell")
-	Eventually(func() map[string]int {
+	helpers.TimedEventually(func() map[string]int {
 		lrps := helpers.RunningActualLRPs(logger, bbsClient, processGuid)
 		cellIDs := map[string]int{}
 		for _, lrp := range lrps {
@@ -3,10 +3,13 @@ package cell_test
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"sync"
 	"time"
 
@@ -18,7 +21,6 @@ import (
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/routing-info/cfroutes"
-	. "code.cloudfoundry.org/vizzini/matchers"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 	"github.com/tedsuo/ifrit/grouper"
@@ -117,15 +119,56 @@ var _ = Describe("LRP", func() {
 		})
 
 		It("eventually runs", func() {
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-			Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
 		})
 
-		It("should send events as the LRP goes through its lifecycle ", func() {
-			Eventually(getEvents).Should(ContainElement(MatchDesiredLRPCreatedEvent(processGuid)))
-			Eventually(getEvents).Should(ContainElement(MatchActualLRPCreatedEvent(processGuid, 0)))
-			Eventually(getEvents).Should(ContainElement(MatchActualLRPChangedEvent(processGuid, 0, models.ActualLRPStateClaimed)))
-			Eventually(getEvents).Should(ContainElement(MatchActualLRPChangedEvent(processGuid, 0, models.ActualLRPStateRunning)))
+		Context("when recording route messages", func() {
+			var recorder *helpers.RouteMessageRecorder
+
+			BeforeEach(func() {
+				user, password := componentMaker.NATSCredentials()
+				recorder = helpers.NewRouteMessageRecorder(componentMaker.Addresses().NATS, user, password)
+			})
+
+			AfterEach(func() {
+				recorder.Close()
+			})
+
+			It("registers the LRP's route over NATS", func() {
+				helpers.TimedEventually(recorder.RegisteredURIs).Should(ContainElement(helpers.DefaultHost))
+			})
+		})
+
+		Context("when watching its instance events", func() {
+			var (
+				instanceEvents <-chan models.Event
+				done           chan struct{}
+			)
+
+			BeforeEach(func() {
+				// subscribe before the LRP is desired, so that no event is missed
+				instanceEventSource, err := bbsClient.SubscribeToInstanceEvents(lgr)
+				Expect(err).NotTo(HaveOccurred())
+
+				done = make(chan struct{})
+				instanceEvents = helpers.EventChannel(instanceEventSource, done)
+			})
+
+			AfterEach(func() {
+				close(done)
+			})
+
+			It("should send events as the LRP goes through its lifecycle ", func() {
+				// the BBS emits the desired and actual LRP events concurrently,
+				// so only the instance's own events have an order
+				helpers.TimedEventually(getEvents).Should(ContainElement(helpers.MatchDesiredLRPCreatedEvent(helpers.WithProcessGuid(processGuid))))
+				helpers.TimedEventually(instanceEvents).Should(helpers.ReceiveEventsInOrder(
+					helpers.MatchActualLRPInstanceCreatedEvent(helpers.WithProcessGuid(processGuid), helpers.WithIndex(0)),
+					helpers.MatchActualLRPInstanceChangedEvent(helpers.WithProcessGuid(processGuid), helpers.WithIndex(0), helpers.WithState(models.ActualLRPStateClaimed)),
+					helpers.MatchActualLRPInstanceChangedEvent(helpers.WithProcessGuid(processGuid), helpers.WithIndex(0), helpers.WithState(models.ActualLRPStateRunning)),
+				))
+			})
 		})
 
 		Context("when using a private image", func() {
@@ -140,8 +183,8 @@ var _ = Describe("LRP", func() {
 			})
 
 			It("eventually runs", func() {
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-				Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
 			})
 		})
 
@@ -157,8 +200,8 @@ var _ = Describe("LRP", func() {
 			})
 
 			It("eventually runs", func() {
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-				Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
 			})
 		})
 
@@ -181,8 +224,8 @@ var _ = Describe("LRP", func() {
 			}
 
 			validateLRPDesired := func() {
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-				Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
 			}
 
 			Context("for CachedDependency", func() {
@@ -261,6 +304,51 @@ var _ = Describe("LRP", func() {
 				})
 			})
 
+			Context("when the download comes from a faulty server", func() {
+				var (
+					server  *helpers.FaultServer
+					content []byte
+				)
+
+				BeforeEach(func() {
+					createChecksum("sha256")
+
+					var err error
+					content, err = ioutil.ReadFile(archiveFilePath)
+					Expect(err).NotTo(HaveOccurred())
+
+					server = helpers.NewFaultServer(os.Getenv("EXTERNAL_ADDRESS"))
+					lrp.CachedDependencies[0].From = server.URL("/lrp.zip")
+				})
+
+				AfterEach(func() {
+					server.Close()
+					Expect(server.Errors()).To(BeEmpty())
+				})
+
+				Context("that fails the first requests", func() {
+					BeforeEach(func() {
+						server.Serve("/lrp.zip", content, helpers.FailFirst(2, http.StatusServiceUnavailable))
+					})
+
+					It("checks the checksum of the download that succeeds", func() {
+						validateLRPDesired()
+						Expect(server.RequestCount("/lrp.zip")).To(Equal(3))
+					})
+				})
+
+				Context("that announces fewer bytes than the archive has", func() {
+					BeforeEach(func() {
+						// the download looks complete, so only the checksum can
+						// tell that it is not
+						server.Serve("/lrp.zip", content, helpers.WrongContentLength(-1024))
+					})
+
+					It("eventually crashes", func() {
+						helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
+					})
+				})
+			})
 		})
 
 		Context("when properties are present on the desired LRP", func() {
@@ -273,7 +361,7 @@ var _ = Describe("LRP", func() {
 			})
 
 			It("passes them to garden", func() {
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 
 				lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 				Expect(err).NotTo(HaveOccurred())
@@ -302,7 +390,7 @@ var _ = Describe("LRP", func() {
 			})
 
 			It("eventually marks the LRP as crashed", func() {
-				Eventually(
+				helpers.TimedEventually(
 					helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil),
 				).Should(Equal(models.ActualLRPStateCrashed))
 			})
@@ -322,7 +410,7 @@ var _ = Describe("LRP", func() {
 			})
 
 			It("can not access container ports without routes", func() {
-				Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-8080")).Should(Equal(http.StatusOK))
+				helpers.TimedEventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-8080")).Should(Equal(http.StatusOK))
 				Consistently(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-8080")).Should(Equal(http.StatusOK))
 				Consistently(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-9080")).Should(Equal(http.StatusNotFound))
 			})
@@ -353,10 +441,10 @@ var _ = Describe("LRP", func() {
 				})
 
 				It("can immediately access the container port with the associated routes", func() {
-					Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-8080")).Should(Equal(http.StatusOK))
+					helpers.TimedEventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-8080")).Should(Equal(http.StatusOK))
 					Consistently(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-8080")).Should(Equal(http.StatusOK))
 
-					Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-9080")).Should(Equal(http.StatusOK))
+					helpers.TimedEventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-9080")).Should(Equal(http.StatusOK))
 					Consistently(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, "lrp-route-9080")).Should(Equal(http.StatusOK))
 				})
 			})
@@ -368,13 +456,13 @@ var _ = Describe("LRP", func() {
 			})
 
 			JustBeforeEach(func() {
-				Eventually(func() []*models.ActualLRP {
+				helpers.TimedEventually(func() []*models.ActualLRP {
 					lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 					Expect(err).NotTo(HaveOccurred())
 
 					return lrps
 				}).Should(HaveLen(2))
-				Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0", "1"}))
+				helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0", "1"}))
 			})
 
 			Describe("changing the instances", func() {
@@ -397,14 +485,14 @@ var _ = Describe("LRP", func() {
 					})
 
 					It("scales up to the correct number of instances", func() {
-						Eventually(func() []*models.ActualLRP {
+						helpers.TimedEventually(func() []*models.ActualLRP {
 							lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 							Expect(err).NotTo(HaveOccurred())
 
 							return lrps
 						}).Should(HaveLen(3))
 
-						Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0", "1", "2"}))
+						helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0", "1", "2"}))
 					})
 				})
 
@@ -414,14 +502,14 @@ var _ = Describe("LRP", func() {
 					})
 
 					It("scales down to the correct number of instances", func() {
-						Eventually(func() []*models.ActualLRP {
+						helpers.TimedEventually(func() []*models.ActualLRP {
 							lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 							Expect(err).NotTo(HaveOccurred())
 
 							return lrps
 						}).Should(HaveLen(1))
 
-						Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
+						helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
 					})
 				})
 
@@ -431,14 +519,14 @@ var _ = Describe("LRP", func() {
 					})
 
 					It("scales down to the correct number of instances", func() {
-						Eventually(func() []*models.ActualLRP {
+						helpers.TimedEventually(func() []*models.ActualLRP {
 							lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 							Expect(err).NotTo(HaveOccurred())
 
 							return lrps
 						}).Should(BeEmpty())
 
-						Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(BeEmpty())
+						helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(BeEmpty())
 					})
 
 					It("can be scaled back up", func() {
@@ -448,14 +536,14 @@ var _ = Describe("LRP", func() {
 						err := bbsClient.UpdateDesiredLRP(lgr, processGuid, dlu)
 						Expect(err).NotTo(HaveOccurred())
 
-						Eventually(func() []*models.ActualLRP {
+						helpers.TimedEventually(func() []*models.ActualLRP {
 							lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 							Expect(err).NotTo(HaveOccurred())
 
 							return lrps
 						}).Should(HaveLen(1))
 
-						Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
+						helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
 					})
 				})
 			})
@@ -467,73 +555,83 @@ var _ = Describe("LRP", func() {
 				})
 
 				It("stops all instances", func() {
-					Eventually(func() []*models.ActualLRP {
+					helpers.TimedEventually(func() []*models.ActualLRP {
 						lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 						Expect(err).NotTo(HaveOccurred())
 
 						return lrps
 					}).Should(BeEmpty())
 
-					Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(BeEmpty())
+					helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(BeEmpty())
 				})
 			})
 		})
 
 		Context("Egress Rules", func() {
+			var (
+				client *helpers.GoServerClient
+
+				listener     *httptest.Server
+				listenerAddr string
+			)
+
+			BeforeEach(func() {
+				// a local target, so that the specs run offline
+				listener, listenerAddr = helpers.Callback(os.Getenv("EXTERNAL_ADDRESS"), func(http.ResponseWriter, *http.Request) {})
+			})
+
+			AfterEach(func() {
+				listener.Close()
+			})
+
+			JustBeforeEach(func() {
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				client = helpers.NewGoServerClient(componentMaker.Addresses().Router, helpers.DefaultHost)
+			})
+
+			egress := func() (helpers.EgressResult, error) {
+				return client.Egress(listenerAddr, "tcp")
+			}
+
 			Context("default networking", func() {
 				It("rejects outbound tcp traffic", func() {
-					Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-
-					var bytes []byte
-					Eventually(func() int {
-						var statusCode int
+					var result helpers.EgressResult
+					helpers.TimedEventually(func() error {
 						var err error
-						bytes, statusCode, err = helpers.ResponseBodyAndStatusCodeFromHost(
-							componentMaker.Addresses().Router,
-							helpers.DefaultHost,
-							"curl",
-						)
-						Expect(err).NotTo(HaveOccurred())
-						return statusCode
-					}).Should(Equal(http.StatusOK))
-					Expect(string(bytes)).To(Equal("7"))
+						result, err = egress()
+						return err
+					}).Should(Succeed())
+
+					Expect(result.Connected).To(BeFalse())
+					Expect(result.ErrorClass).NotTo(BeEmpty())
 				})
 			})
 
 			Context("with appropriate security group setting", func() {
 				BeforeEach(func() {
+					host, port, err := net.SplitHostPort(listenerAddr)
+					Expect(err).NotTo(HaveOccurred())
+					portNumber, err := strconv.Atoi(port)
+					Expect(err).NotTo(HaveOccurred())
+
 					lrp.EgressRules = []*models.SecurityGroupRule{
 						{
 							Protocol:     models.TCPProtocol,
-							Destinations: []string{"9.0.0.0-89.255.255.255", "90.0.0.0-94.0.0.0"},
-							Ports:        []uint32{80},
-						},
-						{
-							Protocol:     models.UDPProtocol,
-							Destinations: []string{"0.0.0.0/0"},
-							PortRange: &models.PortRange{
-								Start: 53,
-								End:   53,
-							},
+							Destinations: []string{host},
+							Ports:        []uint32{uint32(portNumber)},
 						},
 					}
 				})
 
 				It("allows outbound tcp traffic", func() {
-					Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-					var bytes []byte
-					Eventually(func() int {
-						var statusCode int
+					var result helpers.EgressResult
+					helpers.TimedEventually(func() error {
 						var err error
-						bytes, statusCode, err = helpers.ResponseBodyAndStatusCodeFromHost(
-							componentMaker.Addresses().Router,
-							helpers.DefaultHost,
-							"curl",
-						)
-						Expect(err).NotTo(HaveOccurred())
-						return statusCode
-					}).Should(Equal(http.StatusOK))
-					Expect(string(bytes)).To(Equal("0"))
+						result, err = egress()
+						return err
+					}).Should(Succeed())
+
+					Expect(result.Connected).To(BeTrue(), "egress failed with %s: %s", result.ErrorClass, result.Error)
 				})
 			})
 		})
@@ -553,7 +651,7 @@ var _ = Describe("LRP", func() {
 					return lrps[0].PlacementError
 				}
 
-				Eventually(lrpFunc).Should(ContainSubstring("found no compatible cell"))
+				helpers.TimedEventually(lrpFunc).Should(ContainSubstring("found no compatible cell"))
 			})
 		})
 
@@ -572,7 +670,7 @@ var _ = Describe("LRP", func() {
 					return lrps[0].PlacementError
 				}
 
-				Eventually(lrpFunc).Should(ContainSubstring("found no compatible cell"))
+				helpers.TimedEventually(lrpFunc).Should(ContainSubstring("found no compatible cell"))
 			})
 		})
 
@@ -585,15 +683,15 @@ var _ = Describe("LRP", func() {
 			})
 
 			It("runs", func() {
-				Eventually(func() []*models.ActualLRP {
+				helpers.TimedEventually(func() []*models.ActualLRP {
 					lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: processGuid})
 					Expect(err).NotTo(HaveOccurred())
 					return lrps
 				}).Should(HaveLen(1))
 
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 				poller := helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)
-				Eventually(poller).Should(ConsistOf([]string{"0"}))
+				helpers.TimedEventually(poller).Should(ConsistOf([]string{"0"}))
 			})
 		})
 	})
@@ -612,9 +710,8 @@ var _ = Describe("LRP", func() {
 		BeforeEach(func() {
 			By("restarting the bbs with smaller convergeRepeatInterval")
 			ginkgomon.Interrupt(bbsProcess)
-			bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-				overrideConvergenceRepeatInterval,
-			))
+			bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval)
+			bbsProcess = ginkgomon.Invoke(bbsRunner)
 		})
 
 		Describe("crashing apps", func() {
@@ -633,11 +730,11 @@ var _ = Describe("LRP", func() {
 				testAppRecovery := func(index int) {
 					It("imediately restarts the app 3 times", func() {
 						// the bbs immediately starts it 3 times
-						Eventually(crashCount(processGuid, index)).Should(BeEquivalentTo(3))
+						helpers.TimedEventually(crashCount(processGuid, index)).Should(BeEquivalentTo(3))
 						// then exponential backoff kicks in
 						Consistently(crashCount(processGuid, index), 15*time.Second).Should(BeEquivalentTo(3))
 						// eventually we cross the first backoff threshold (30 seconds)
-						Eventually(crashCount(processGuid, index), 30*time.Second).Should(BeEquivalentTo(4))
+						helpers.TimedEventually(crashCount(processGuid, index), 30*time.Second).Should(BeEquivalentTo(4))
 					})
 				}
 
@@ -665,7 +762,7 @@ var _ = Describe("LRP", func() {
 					err := bbsClient.DesireLRP(lgr, lrp)
 					Expect(err).NotTo(HaveOccurred())
 
-					Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+					helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 				})
 
 				JustBeforeEach(func() {
@@ -680,16 +777,16 @@ var _ = Describe("LRP", func() {
 				})
 
 				It("crashes the instance and restarts it", func() {
-					Eventually(crashCount(processGuid, 0)).Should(BeEquivalentTo(1))
-					Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+					helpers.TimedEventually(crashCount(processGuid, 0)).Should(BeEquivalentTo(1))
+					helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 				})
 
 				It("contains the instance guid and cell id", func() {
-					Eventually(getEvents).Should(ContainElement(helpers.MatchActualLRPCrashedEvent(
-						processGuid,
-						lrps[0].InstanceGuid,
-						lrps[0].CellId,
-						0,
+					helpers.TimedEventually(getEvents).Should(ContainElement(helpers.MatchActualLRPCrashedEvent(
+						helpers.WithProcessGuid(processGuid),
+						helpers.WithInstanceGuid(lrps[0].InstanceGuid),
+						helpers.WithCellId(lrps[0].CellId),
+						helpers.WithIndex(0),
 					)))
 				})
 			})
@@ -732,7 +829,7 @@ var _ = Describe("LRP", func() {
 						})
 
 						It("eventually crashes", func() {
-							Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
+							helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
 						})
 					})
 
@@ -745,7 +842,7 @@ var _ = Describe("LRP", func() {
 						})
 
 						It("eventually crashes", func() {
-							Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
+							helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
 						})
 					})
 
@@ -758,7 +855,7 @@ var _ = Describe("LRP", func() {
 						})
 
 						It("eventually crashes", func() {
-							Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
+							helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
 						})
 					})
 				})
@@ -784,7 +881,7 @@ var _ = Describe("LRP", func() {
 					})
 
 					It("eventually desires the lrp", func() {
-						Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
+						helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
 					})
 				})
 
@@ -796,7 +893,7 @@ var _ = Describe("LRP", func() {
 					})
 
 					It("eventually desires the lrp", func() {
-						Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
+						helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
 					})
 				})
 
@@ -808,7 +905,7 @@ var _ = Describe("LRP", func() {
 					})
 
 					It("eventually desires the lrp", func() {
-						Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
+						helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateCrashed))
 					})
 				})
 			})
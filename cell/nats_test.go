@@ -0,0 +1,122 @@
+package cell_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io/ioutil"
+	"runtime"
+
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/world"
+	"github.com/nats-io/nats.go"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("NATS", func() {
+	var (
+		user, password string
+		natsProcess    ifrit.Process
+	)
+
+	registration := func(uri string) []byte {
+		message, err := json.Marshal(helpers.RouteMessage{Host: "10.0.0.1", Port: 61000, URIs: []string{uri}})
+		Expect(err).NotTo(HaveOccurred())
+		return message
+	}
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip(" not yet working on windows")
+		}
+
+		user, password = componentMaker.NATSCredentials()
+		natsProcess = nil
+	})
+
+	AfterEach(func() {
+		if natsProcess != nil {
+			helpers.StopProcesses(natsProcess)
+		}
+	})
+
+	It("rejects clients with the wrong password", func() {
+		_, err := nats.Connect("nats://"+componentMaker.Addresses().NATS, nats.UserInfo(user, "not-"+password))
+		Expect(err).To(MatchError(MatchRegexp(`(?i)authorization violation`)))
+	})
+
+	Context("when serving TLS", func() {
+		var (
+			address   string
+			tlsConfig *tls.Config
+		)
+
+		BeforeEach(func() {
+			var runner ifrit.Runner
+			runner, address = componentMaker.NATSN(1, func(cfg *world.NATSConfig) {
+				// a node of its own, rather than one of the suite's cluster
+				cfg.TLS = true
+				cfg.ClusterPort = 0
+				cfg.Routes = nil
+			})
+			natsProcess = ginkgomon.Invoke(runner)
+
+			caCert, err := ioutil.ReadFile(componentMaker.NATSSSLConfig().CACert)
+			Expect(err).NotTo(HaveOccurred())
+			rootCAs := x509.NewCertPool()
+			Expect(rootCAs.AppendCertsFromPEM(caCert)).To(BeTrue())
+
+			// no ServerName: the client checks the certificate against the IP
+			// it dials
+			tlsConfig = &tls.Config{RootCAs: rootCAs}
+		})
+
+		It("delivers route messages to clients that verify its certificate", func() {
+			recorder := helpers.NewRouteMessageRecorder(address, user, password, nats.Secure(tlsConfig))
+			defer recorder.Close()
+
+			conn, err := nats.Connect("nats://"+address, nats.UserInfo(user, password), nats.Secure(tlsConfig))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			Expect(conn.Publish(helpers.RouterRegisterSubject, registration("tls.example.com"))).To(Succeed())
+			helpers.TimedEventually(recorder.RegisteredURIs).Should(ConsistOf("tls.example.com"))
+		})
+
+		It("is not trusted by clients without the CA", func() {
+			_, err := nats.Connect("nats://"+address, nats.UserInfo(user, password), nats.Secure(&tls.Config{}))
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+	})
+
+	Context("with a second cluster node", func() {
+		var address string
+
+		BeforeEach(func() {
+			var runner ifrit.Runner
+			runner, address = componentMaker.NATSN(1)
+			natsProcess = ginkgomon.Invoke(runner)
+		})
+
+		It("delivers route messages published on the first node to subscribers on the second", func() {
+			recorder := helpers.NewRouteMessageRecorder(address, user, password)
+			defer recorder.Close()
+
+			conn, err := nats.Connect("nats://"+componentMaker.Addresses().NATS, nats.UserInfo(user, password))
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			// the nodes drop messages until they have connected and shared
+			// their subscriptions, so keep publishing until one gets across
+			helpers.TimedEventually(func() []string {
+				Expect(conn.Publish(helpers.RouterRegisterSubject, registration("cluster.example.com"))).To(Succeed())
+				Expect(conn.Flush()).To(Succeed())
+				return recorder.RegisteredURIs()
+			}).Should(ConsistOf("cluster.example.com"))
+		})
+	})
+})
@@ -71,7 +71,7 @@ var _ = Describe("Network Environment Variables", func() {
 			err := bbsClient.DesireTask(lgr, taskToDesire.TaskGuid, taskToDesire.Domain, taskToDesire.TaskDefinition)
 			Expect(err).NotTo(HaveOccurred())
 
-			Eventually(func() interface{} {
+			helpers.TimedEventually(func() interface{} {
 				var err error
 				task, err = bbsClient.TaskByGuid(lgr, guid)
 				Expect(err).ShouldNot(HaveOccurred())
@@ -116,8 +116,8 @@ var _ = Describe("Network Environment Variables", func() {
 			err := bbsClient.DesireLRP(lgr, lrp)
 			Expect(err).NotTo(HaveOccurred())
 
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, guid, nil)).Should(Equal(models.ActualLRPStateRunning))
-			Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(Equal(http.StatusOK))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, guid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(Equal(http.StatusOK))
 
 			lrps, err := bbsClient.ActualLRPs(lgr, models.ActualLRPFilter{ProcessGuid: guid})
 			Expect(err).NotTo(HaveOccurred())
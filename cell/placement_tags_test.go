@@ -91,8 +91,8 @@ var _ = Describe("Placement Tags", func() {
 					}
 					return lrps[0].CellId
 				}
-				Eventually(lrpFunc).Should(MatchRegexp("the-cell-id-.*-0"))
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, guid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(lrpFunc).Should(MatchRegexp("the-cell-id-.*-0"))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, guid, nil)).Should(Equal(models.ActualLRPStateRunning))
 			})
 		})
 
@@ -110,8 +110,8 @@ var _ = Describe("Placement Tags", func() {
 					}
 					return lrps[0].CellId
 				}
-				Eventually(lrpFunc).Should(MatchRegexp("the-cell-id-.*-0"))
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, guid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(lrpFunc).Should(MatchRegexp("the-cell-id-.*-0"))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, guid, nil)).Should(Equal(models.ActualLRPStateRunning))
 			})
 		})
 
@@ -132,7 +132,7 @@ var _ = Describe("Placement Tags", func() {
 					return lrps[0].PlacementError
 				}
 
-				Eventually(lrpFunc).Should(ContainSubstring("found no compatible cell with placement tag"))
+				helpers.TimedEventually(lrpFunc).Should(ContainSubstring("found no compatible cell with placement tag"))
 			})
 		})
 	})
@@ -177,7 +177,7 @@ var _ = Describe("Placement Tags", func() {
 		taskShouldRunSuccessfully := func() {
 			It("succeeds", func() {
 				var completedTask *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					completedTask, err = bbsClient.TaskByGuid(lgr, guid)
@@ -218,7 +218,7 @@ var _ = Describe("Placement Tags", func() {
 					return t.FailureReason
 				}
 
-				Eventually(taskFunc).Should(ContainSubstring("found no compatible cell with placement tag"))
+				helpers.TimedEventually(taskFunc).Should(ContainSubstring("found no compatible cell with placement tag"))
 			})
 		})
 	})
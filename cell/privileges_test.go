@@ -1,7 +1,6 @@
 package cell_test
 
 import (
-	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -75,7 +74,7 @@ var _ = Describe("Privileges", func() {
 
 			It("succeeds", func() {
 				var task models.Task
-				Eventually(helpers.TaskStatePoller(lgr, bbsClient, taskToDesire.TaskGuid, &task)).Should(Equal(models.Task_Completed))
+				helpers.TimedEventually(helpers.TaskStatePoller(lgr, bbsClient, taskToDesire.TaskGuid, &task)).Should(Equal(models.Task_Completed))
 				Expect(task.Failed).To(BeFalse())
 			})
 		})
@@ -87,15 +86,32 @@ var _ = Describe("Privileges", func() {
 
 			It("fails", func() {
 				var task models.Task
-				Eventually(helpers.TaskStatePoller(lgr, bbsClient, taskToDesire.TaskGuid, &task)).Should(Equal(models.Task_Completed))
+				helpers.TimedEventually(helpers.TaskStatePoller(lgr, bbsClient, taskToDesire.TaskGuid, &task)).Should(Equal(models.Task_Completed))
 				Expect(task.Failed).To(BeTrue())
 			})
 		})
 	})
 
 	Context("when a LRP that tries to do privileged things is requested", func() {
+		const capSysAdmin = 21
+
 		var lrpRequest *models.DesiredLRP
 
+		// introspect asks the LRP what it can do, retrying until its route
+		// is registered. Both kinds of LRP run as root in their container.
+		introspect := func() helpers.Introspection {
+			client := helpers.NewGoServerClient(componentMaker.Addresses().Router, helpers.DefaultHost)
+
+			var introspection helpers.Introspection
+			helpers.TimedEventually(func() error {
+				var err error
+				introspection, err = client.Introspect("")
+				return err
+			}).Should(Succeed())
+			Expect(introspection.User.UID).To(Equal(0))
+			return introspection
+		}
+
 		BeforeEach(func() {
 			lrpRequest = helpers.DefaultLRPCreateRequest(componentMaker.Addresses(), helpers.GenerateGuid(), "log-guid", 1)
 			lrpRequest.Action = models.WrapAction(&models.RunAction{
@@ -108,7 +124,7 @@ var _ = Describe("Privileges", func() {
 		JustBeforeEach(func() {
 			err := bbsClient.DesireLRP(lgr, lrpRequest)
 			Expect(err).NotTo(HaveOccurred())
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, lrpRequest.ProcessGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, lrpRequest.ProcessGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 		})
 
 		Context("when the LRP is privileged", func() {
@@ -116,8 +132,8 @@ var _ = Describe("Privileges", func() {
 				lrpRequest.Privileged = true
 			})
 
-			It("succeeds", func() {
-				Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost, "privileged")).Should(Equal(http.StatusOK))
+			It("runs with CAP_SYS_ADMIN", func() {
+				Expect(introspect().HasEffectiveCapability(capSysAdmin)).To(BeTrue())
 			})
 		})
 
@@ -126,8 +142,8 @@ var _ = Describe("Privileges", func() {
 				lrpRequest.Privileged = false
 			})
 
-			It("fails", func() {
-				Eventually(helpers.ResponseCodeFromHostPoller(componentMaker.Addresses().Router, helpers.DefaultHost, "privileged")).Should(Equal(http.StatusInternalServerError))
+			It("runs without CAP_SYS_ADMIN", func() {
+				Expect(introspect().HasEffectiveCapability(capSysAdmin)).To(BeFalse())
 			})
 		})
 	})
@@ -0,0 +1,114 @@
+package cell_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/inigo/fixtures"
+	"code.cloudfoundry.org/inigo/helpers"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Protocols", func() {
+	var (
+		processGuid  string
+		ifritRuntime ifrit.Process
+		actualLRP    models.ActualLRP
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip(" not yet working on windows")
+		}
+		processGuid = helpers.GenerateGuid()
+
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+		ifritRuntime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", componentMaker.Router()},
+			{"file-server", fileServer},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"route-emitter", componentMaker.RouteEmitter()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.GoServerApp(),
+		)
+
+		lrp := helpers.ProtocolsLRPCreateRequest(componentMaker.Addresses(), processGuid)
+		err := bbsClient.DesireLRP(lgr, lrp)
+		Expect(err).NotTo(HaveOccurred())
+
+		helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+
+		lrps := helpers.RunningActualLRPs(lgr, bbsClient, processGuid)
+		Expect(lrps).To(HaveLen(1))
+		actualLRP = lrps[0]
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(ifritRuntime)
+	})
+
+	hostPort := func(containerPort uint32) string {
+		for _, mapping := range actualLRP.Ports {
+			if mapping.ContainerPort == containerPort {
+				return net.JoinHostPort(actualLRP.Address, strconv.Itoa(int(mapping.HostPort)))
+			}
+		}
+		Fail(fmt.Sprintf("cannot find a port mapping for %d", containerPort))
+		return ""
+	}
+
+	Context("through the router", func() {
+		var client *helpers.GoServerClient
+
+		BeforeEach(func() {
+			client = helpers.NewGoServerClient(componentMaker.Addresses().Router, helpers.DefaultHost)
+			helpers.TimedEventually(func() (string, error) { return client.Protocol(false) }).Should(Equal("HTTP/1.1"))
+		})
+
+		It("passes WebSocket upgrades through", func() {
+			Expect(client.WebSocketEcho("hello over a websocket")).To(Equal("hello over a websocket"))
+		})
+	})
+
+	Context("on the instance's host port", func() {
+		var client *helpers.GoServerClient
+
+		BeforeEach(func() {
+			client = helpers.NewGoServerClient(hostPort(8080), "")
+		})
+
+		It("speaks h2c", func() {
+			Expect(client.Protocol(true)).To(Equal("HTTP/2.0"))
+		})
+
+		It("serves the gRPC health service", func() {
+			Expect(client.GRPCHealthCheck()).To(Equal("SERVING"))
+		})
+
+		It("echoes raw TCP", func() {
+			Expect(helpers.TCPEcho(hostPort(9000), "hello over tcp")).To(Equal("hello over tcp"))
+		})
+	})
+
+	Context("on the instance address", func() {
+		It("echoes UDP datagrams", func() {
+			address := net.JoinHostPort(actualLRP.InstanceAddress, "9001")
+			helpers.TimedEventually(func() (string, error) { return helpers.UDPEcho(address, "hello over udp") }).Should(Equal("hello over udp"))
+		})
+	})
+})
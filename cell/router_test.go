@@ -0,0 +1,146 @@
+package cell_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	"code.cloudfoundry.org/inigo/fixtures"
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/world"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+var _ = Describe("Router", func() {
+	var (
+		ifritRuntime ifrit.Process
+		routerConfig func(*world.RouterConfig)
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip(" not yet working on windows")
+		}
+
+		routerConfig = func(*world.RouterConfig) {}
+	})
+
+	JustBeforeEach(func() {
+		fileServer, fileServerStaticDir := componentMaker.FileServer()
+		ifritRuntime = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"router", componentMaker.Router(routerConfig)},
+			{"file-server", fileServer},
+			{"rep", componentMaker.Rep()},
+			{"auctioneer", componentMaker.Auctioneer()},
+			{"route-emitter", componentMaker.RouteEmitter()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.GoServerApp(),
+		)
+	})
+
+	AfterEach(func() {
+		helpers.StopProcesses(ifritRuntime)
+	})
+
+	Context("with TLS enabled", func() {
+		var (
+			sslPort   uint16
+			tlsConfig *tls.Config
+		)
+
+		BeforeEach(func() {
+			routerConfig = func(cfg *world.RouterConfig) {
+				cfg.EnableSSL = true
+				sslPort = cfg.SSLPort
+			}
+
+			caCert, err := ioutil.ReadFile(componentMaker.RouterSSLConfig().CACert)
+			Expect(err).NotTo(HaveOccurred())
+			rootCAs := x509.NewCertPool()
+			Expect(rootCAs.AppendCertsFromPEM(caCert)).To(BeTrue())
+
+			// the router's certificate is issued for the router_server SAN
+			tlsConfig = &tls.Config{RootCAs: rootCAs, ServerName: "router_server"}
+		})
+
+		JustBeforeEach(func() {
+			lrp := helpers.DefaultLRPCreateRequest(componentMaker.Addresses(), helpers.GenerateGuid(), "log-guid", 1)
+			Expect(bbsClient.DesireLRP(lgr, lrp)).To(Succeed())
+		})
+
+		get := func(client *http.Client) func() (int, error) {
+			return func() (int, error) {
+				request, err := http.NewRequest("GET", fmt.Sprintf("https://127.0.0.1:%d/", sslPort), nil)
+				Expect(err).NotTo(HaveOccurred())
+				request.Host = helpers.DefaultHost
+
+				response, err := client.Do(request)
+				if err != nil {
+					return 0, err
+				}
+				defer response.Body.Close()
+				return response.StatusCode, nil
+			}
+		}
+
+		It("routes requests on the SSL port with the configured certificate", func() {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			helpers.TimedEventually(get(client)).Should(Equal(http.StatusOK))
+		})
+
+		It("is not trusted by clients without the CA", func() {
+			trusting := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			helpers.TimedEventually(get(trusting)).Should(Equal(http.StatusOK))
+
+			untrusting := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{ServerName: "router_server"}}}
+			_, err := get(untrusting)()
+			Expect(err).To(MatchError(ContainSubstring("certificate")))
+		})
+	})
+
+	Describe("the status port", func() {
+		routes := func(setAuth func(*http.Request)) func() (int, error) {
+			return func() (int, error) {
+				request, err := http.NewRequest("GET", fmt.Sprintf("http://%s/routes", componentMaker.Addresses().RouterStatus), nil)
+				Expect(err).NotTo(HaveOccurred())
+				setAuth(request)
+
+				response, err := http.DefaultClient.Do(request)
+				if err != nil {
+					return 0, err
+				}
+				defer response.Body.Close()
+				return response.StatusCode, nil
+			}
+		}
+
+		It("rejects requests without the status credentials", func() {
+			helpers.TimedEventually(routes(func(*http.Request) {})).Should(Equal(http.StatusUnauthorized))
+
+			user, password := componentMaker.RouterStatusCredentials()
+			Expect(routes(func(request *http.Request) {
+				request.SetBasicAuth(user, "not-"+password)
+			})()).To(Equal(http.StatusUnauthorized))
+		})
+
+		It("accepts requests with the status credentials", func() {
+			user, password := componentMaker.RouterStatusCredentials()
+			helpers.TimedEventually(routes(func(request *http.Request) {
+				request.SetBasicAuth(user, password)
+			})).Should(Equal(http.StatusOK))
+		})
+	})
+})
@@ -3,7 +3,6 @@ package cell_test
 import (
 	"crypto/tls"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
@@ -24,7 +23,6 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/ghttp"
 )
 
 var _ = Describe("Secure Downloading and Uploading", func() {
@@ -43,6 +41,7 @@ var _ = Describe("Secure Downloading and Uploading", func() {
 			Skip(" not yet working on windows")
 		}
 		processGuid = helpers.GenerateGuid()
+		cfgs = nil
 
 		fileServer, fileServerStaticDir = componentMaker.FileServer()
 
@@ -94,7 +93,7 @@ var _ = Describe("Secure Downloading and Uploading", func() {
 		})
 
 		It("eventually runs", func() {
-			Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 		})
 
 		Context("when CaCertForDownload is present", func() {
@@ -114,7 +113,7 @@ var _ = Describe("Secure Downloading and Uploading", func() {
 				})
 
 				It("eventually runs", func() {
-					Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+					helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 				})
 			})
 		})
@@ -128,36 +127,211 @@ var _ = Describe("Secure Downloading and Uploading", func() {
 			})
 
 			It("eventually runs", func() {
-				Eventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+				helpers.TimedEventually(helpers.LRPStatePoller(lgr, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
 			})
 		})
 	})
 
-	Describe("uploading", func() {
+	Describe("downloading from a faulty server", func() {
+		const artifactPath = "/artifact.tgz"
+
 		var (
-			guid       string
-			server     *httptest.Server
-			gotRequest chan struct{}
+			server   *helpers.FaultServer
+			artifact []byte
 		)
 
 		BeforeEach(func() {
-			guid = helpers.GenerateGuid()
+			server = helpers.NewFaultServer(os.Getenv("EXTERNAL_ADDRESS"))
+			artifact = dependencyArchive()
+		})
+
+		AfterEach(func() {
+			server.Close()
+			Expect(server.Errors()).To(BeEmpty())
+		})
+
+		// runTask downloads the artifact from url into a task and checks its
+		// payload is there, and returns the task once it has completed.
+		runTask := func(url string) *models.Task {
+			guid := helpers.GenerateGuid()
+			expectedTask := helpers.TaskCreateRequest(
+				guid,
+				models.Serial(
+					&models.DownloadAction{
+						From:     url,
+						To:       "/home/vcap/artifact",
+						CacheKey: "faulty-artifact",
+						User:     "vcap",
+					},
+					&models.RunAction{
+						User: "vcap",
+						Path: "test",
+						Args: []string{"-f", "/home/vcap/artifact/payload"},
+					},
+				),
+			)
+
+			err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
+			Expect(err).NotTo(HaveOccurred())
+
+			var task models.Task
+			helpers.TimedEventually(helpers.TaskStatePoller(lgr, bbsClient, guid, &task)).Should(Equal(models.Task_Completed))
+			return &task
+		}
+
+		Context("when the first requests fail", func() {
+			BeforeEach(func() {
+				server.Serve(artifactPath, artifact, helpers.FailFirst(2, http.StatusServiceUnavailable))
+			})
+
+			It("retries until the download succeeds", func() {
+				task := runTask(server.URL(artifactPath))
+				Expect(task.Failed).To(BeFalse(), task.FailureReason)
+				Expect(server.RequestCount(artifactPath)).To(Equal(3))
+			})
+		})
+
+		Context("when the artifact is behind a chain of redirects", func() {
+			BeforeEach(func() {
+				server.Serve(artifactPath, artifact, helpers.RedirectChain(3))
+			})
+
+			It("follows them to the artifact", func() {
+				task := runTask(server.URL(artifactPath))
+				Expect(task.Failed).To(BeFalse(), task.FailureReason)
+				Expect(server.RequestCount(artifactPath)).To(Equal(4))
+			})
+		})
+
+		Context("when the server trickles the artifact out", func() {
+			BeforeEach(func() {
+				server.Serve(artifactPath, artifact, helpers.Throttle(len(artifact)/2))
+			})
+
+			It("waits for the whole artifact", func() {
+				task := runTask(server.URL(artifactPath))
+				Expect(task.Failed).To(BeFalse(), task.FailureReason)
+			})
+		})
+
+		Context("when the server sends no cache headers", func() {
+			BeforeEach(func() {
+				server.Serve(artifactPath, artifact, helpers.NoCacheHeaders())
+			})
+
+			It("downloads the artifact in full every time", func() {
+				for i := 0; i < 2; i++ {
+					task := runTask(server.URL(artifactPath))
+					Expect(task.Failed).To(BeFalse(), task.FailureReason)
+				}
 
-			gotRequest = make(chan struct{})
+				requests := server.Requests(artifactPath)
+				Expect(requests).To(HaveLen(2))
+				for _, request := range requests {
+					Expect(request.Conditional()).To(BeFalse())
+				}
+			})
+		})
+
+		Context("when the download is cut short", func() {
+			for _, fault := range []struct {
+				description string
+				fault       helpers.Fault
+			}{
+				{"by closing the connection", helpers.Truncate(1024)},
+				{"by resetting the connection", helpers.ResetAfter(1024)},
+				{"by announcing more bytes than it sends", helpers.WrongContentLength(1024)},
+			} {
+				fault := fault
+
+				Context(fault.description, func() {
+					BeforeEach(func() {
+						server.Serve(artifactPath, artifact, fault.fault)
+					})
+
+					It("fails the task", func() {
+						task := runTask(server.URL(artifactPath))
+						Expect(task.Failed).To(BeTrue())
+						Expect(server.RequestCount(artifactPath)).To(BeNumerically(">=", 1))
+					})
+				})
+			}
+		})
 
-			server, _ = helpers.Callback(os.Getenv("EXTERNAL_ADDRESS"), ghttp.CombineHandlers(
-				ghttp.VerifyRequest("POST", "/thingy"),
-				func(w http.ResponseWriter, r *http.Request) {
-					contents, err := ioutil.ReadAll(r.Body)
-					Expect(err).NotTo(HaveOccurred())
+		Context("over TLS", func() {
+			var tlsServer *helpers.FaultServer
 
-					Expect(string(contents)).To(Equal("tasty thingy\n"))
+			serveWith := func(generate func(string, []string) (string, string, error)) {
+				keyFile, certFile, err := generate("fault-server", []string{os.Getenv("EXTERNAL_ADDRESS")})
+				Expect(err).NotTo(HaveOccurred())
+
+				tlsServer = helpers.NewTLSFaultServer(os.Getenv("EXTERNAL_ADDRESS"), certFile, keyFile)
+				tlsServer.Serve(artifactPath, artifact)
+			}
+
+			BeforeEach(func() {
+				_, caFile := certAuthority.CAAndKey()
+				cfgs = append(cfgs, func(cfg *config.RepConfig) {
+					cfg.PathToCACertsForDownloads = caFile
+				})
+			})
+
+			AfterEach(func() {
+				tlsServer.Close()
+			})
+
+			Context("with a certificate from a trusted authority", func() {
+				BeforeEach(func() {
+					serveWith(func(commonName string, sans []string) (string, string, error) {
+						return certAuthority.GenerateSelfSignedCertAndKey(commonName, sans, false)
+					})
+				})
+
+				It("downloads the artifact", func() {
+					task := runTask(tlsServer.URL(artifactPath))
+					Expect(task.Failed).To(BeFalse(), task.FailureReason)
+				})
+			})
+
+			Context("with an expired certificate", func() {
+				BeforeEach(func() {
+					serveWith(certAuthority.GenerateExpiredCertAndKey)
+				})
+
+				It("refuses to download the artifact", func() {
+					task := runTask(tlsServer.URL(artifactPath))
+					Expect(task.Failed).To(BeTrue())
+					Expect(tlsServer.RequestCount(artifactPath)).To(BeZero())
+				})
+			})
+
+			Context("with a certificate from an untrusted authority", func() {
+				BeforeEach(func() {
+					serveWith(certAuthority.GenerateUntrustedCertAndKey)
+				})
+
+				It("refuses to download the artifact", func() {
+					task := runTask(tlsServer.URL(artifactPath))
+					Expect(task.Failed).To(BeTrue())
+					Expect(tlsServer.RequestCount(artifactPath)).To(BeZero())
+				})
+			})
+		})
+	})
+
+	Describe("uploading", func() {
+		var (
+			guid   string
+			server *helpers.FaultServer
+		)
+
+		BeforeEach(func() {
+			guid = helpers.GenerateGuid()
 
-					close(gotRequest)
-				},
-			))
+			server = helpers.NewFaultServer(os.Getenv("EXTERNAL_ADDRESS"))
+			server.AcceptUploads("/thingy")
 
-			serverURL, err := url.Parse(server.URL)
+			serverURL, err := url.Parse(server.URL(""))
 			Expect(err).NotTo(HaveOccurred())
 			proxy := httputil.NewSingleHostReverseProxy(serverURL)
 			tlsFileServer = httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
@@ -176,6 +350,7 @@ var _ = Describe("Secure Downloading and Uploading", func() {
 
 		AfterEach(func() {
 			server.Close()
+			Expect(server.Errors()).To(BeEmpty())
 		})
 
 		It("uploads the specified files", func() {
@@ -198,7 +373,10 @@ var _ = Describe("Secure Downloading and Uploading", func() {
 			err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 			Expect(err).NotTo(HaveOccurred())
 
-			Eventually(gotRequest).Should(BeClosed())
+			helpers.TimedEventually(server.Uploads).Should(HaveLen(1))
+			upload := server.Uploads()[0]
+			Expect(upload.Method).To(Equal("POST"))
+			Expect(string(upload.Body)).To(Equal("tasty thingy\n"))
 		})
 	})
 })
@@ -167,17 +167,17 @@ var _ = Describe("SSH", func() {
 		err := bbsClient.DesireLRP(logger, &lrp)
 		Expect(err).NotTo(HaveOccurred())
 
-		Eventually(func() []*models.ActualLRP {
+		helpers.TimedEventually(func() []*models.ActualLRP {
 			lrps, err := bbsClient.ActualLRPs(logger, models.ActualLRPFilter{ProcessGuid: processGuid})
 			Expect(err).NotTo(HaveOccurred())
 			return lrps
 		}).Should(HaveLen(2))
 
-		Eventually(
+		helpers.TimedEventually(
 			helpers.LRPInstanceStatePoller(logger, bbsClient, processGuid, 0, nil),
 		).Should(Equal(models.ActualLRPStateRunning))
 
-		Eventually(
+		helpers.TimedEventually(
 			helpers.LRPInstanceStatePoller(logger, bbsClient, processGuid, 1, nil),
 		).Should(Equal(models.ActualLRPStateRunning))
 	})
@@ -230,7 +230,7 @@ var _ = Describe("SSH", func() {
 			})
 
 			It("returns an error", func() {
-				Eventually(
+				helpers.TimedEventually(
 					helpers.LRPInstanceStatePoller(lgr, bbsClient, processGuid, 0, nil),
 				).Should(Equal(models.ActualLRPStateRunning))
 
@@ -33,7 +33,7 @@ var _ = Describe("Tasks as specific user", func() {
 		}
 		cellProcess = ginkgomon.Invoke(grouper.NewParallel(os.Interrupt, cellGroup))
 
-		Eventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(lgr) }).Should(HaveLen(1))
+		helpers.TimedEventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(lgr) }).Should(HaveLen(1))
 	})
 
 	AfterEach(func() {
@@ -62,7 +62,7 @@ var _ = Describe("Tasks as specific user", func() {
 
 			var task *models.Task
 
-			Eventually(func() interface{} {
+			helpers.TimedEventually(func() interface{} {
 				var err error
 
 				task, err = bbsClient.TaskByGuid(lgr, guid)
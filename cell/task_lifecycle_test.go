@@ -1,7 +1,6 @@
 package cell_test
 
 import (
-	"fmt"
 	"os"
 	"runtime"
 	"time"
@@ -9,6 +8,7 @@ import (
 	bbsconfig "code.cloudfoundry.org/bbs/cmd/bbs/config"
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/durationjson"
+	"code.cloudfoundry.org/inigo/fixtures"
 	"code.cloudfoundry.org/inigo/helpers"
 	"code.cloudfoundry.org/inigo/inigo_announcement_server"
 
@@ -50,9 +50,14 @@ var _ = Describe("Task Lifecycle", func() {
 	})
 
 	Context("when a rep, and auctioneer are running", func() {
+		var fileServer *helpers.FileServerHandle
+
 		BeforeEach(func() {
+			fileServerRunner, fileServerStaticDir := componentMaker.FileServer()
+			fileServer = helpers.NewFileServerHandle(fileServerStaticDir, componentMaker.Addresses().FileServer)
 
 			cellProcess = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+				{"file-server", fileServerRunner},
 				{"rep", componentMaker.Rep(func(config *repconfig.RepConfig) {
 					config.MemoryMB = "1024"
 				})},
@@ -63,40 +68,37 @@ var _ = Describe("Task Lifecycle", func() {
 
 		Context("and a standard Task is desired", func() {
 			var taskGuid string
-			var taskSleepSeconds int
 
 			var taskToCreate *models.Task
 
-			BeforeEach(func() {
-				taskSleepSeconds = 5
-				taskGuid = helpers.GenerateGuid()
+			// sleepyTask announces that it is running and then sleeps, so that
+			// we can make assertions around behavior as it's running
+			sleepyTask := func(sleep time.Duration, memoryMB int) *models.Task {
+				announce, announcePath := fixtureApp(fileServer, fixtures.SleepThenAnnounce)
 
-				taskToCreate = helpers.TaskCreateRequestWithMemory(
+				task := helpers.TaskCreateRequestWithMemory(
 					taskGuid,
-					&models.RunAction{
-						User: "vcap",
-						Path: "sh",
-						Args: []string{
-							"-c",
-							// sleep a bit so that we can make assertions around behavior as it's running
-							fmt.Sprintf(`
-								kill_sleep() {
-									kill -15 $child
-									exit
-								}
-
-								trap kill_sleep 15 9
-
-								curl %s
-								sleep %d &
-
-								child=$!
-								wait $child
-							`, inigo_announcement_server.AnnounceURL(taskGuid), taskSleepSeconds),
+					models.Serial(
+						&models.RunAction{
+							User: "vcap",
+							Path: announcePath,
+							Args: []string{"-url", inigo_announcement_server.AnnounceURL(taskGuid)},
 						},
-					},
-					512,
+						&models.RunAction{
+							User: "vcap",
+							Path: announcePath,
+							Args: []string{"-sleep", sleep.String(), "-url", inigo_announcement_server.AnnounceURL(taskGuid + "-slept")},
+						},
+					),
+					memoryMB,
 				)
+				task.CachedDependencies = []*models.CachedDependency{announce}
+				return task
+			}
+
+			BeforeEach(func() {
+				taskGuid = helpers.GenerateGuid()
+				taskToCreate = sleepyTask(5*time.Second, 512)
 			})
 
 			theFailureReason := func() string {
@@ -123,7 +125,7 @@ var _ = Describe("Task Lifecycle", func() {
 
 			Context("when there is a matching rootfs", func() {
 				It("eventually runs the Task", func() {
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+					helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
 				})
 			})
 
@@ -140,39 +142,27 @@ var _ = Describe("Task Lifecycle", func() {
 				})
 
 				It("marks the task as complete, failed and cancelled", func() {
-					Eventually(theFailureReason).Should(ContainSubstring("found no compatible cell"))
+					helpers.TimedEventually(theFailureReason).Should(ContainSubstring("found no compatible cell"))
 				})
 			})
 
 			Context("when there is not enough resources", func() {
 				BeforeEach(func() {
-					taskToCreate = helpers.TaskCreateRequestWithMemory(
-						taskGuid,
-						&models.RunAction{
-							User: "vcap",
-							Path: "sh",
-							Args: []string{
-								"-c",
-								// sleep a bit so that we can make assertions around behavior as it's running
-								fmt.Sprintf("curl %s; sleep %d", inigo_announcement_server.AnnounceURL(taskGuid), taskSleepSeconds),
-							},
-						},
-						2048,
-					)
+					taskToCreate = sleepyTask(5*time.Second, 2048)
 				})
 
 				It("marks the task as complete, failed and cancelled", func() {
-					Eventually(theFailureReason).Should(Equal("insufficient resources: memory"))
+					helpers.TimedEventually(theFailureReason).Should(Equal("insufficient resources: memory"))
 				})
 			})
 
 			Context("and then the task is cancelled", func() {
 				BeforeEach(func() {
-					taskSleepSeconds = 9999 // ensure task never completes on its own
+					taskToCreate = sleepyTask(time.Hour, 512) // ensure task never completes on its own
 				})
 
 				JustBeforeEach(func() {
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+					helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
 
 					err := bbsClient.CancelTask(lgr, taskGuid)
 					Expect(err).NotTo(HaveOccurred())
@@ -192,15 +182,13 @@ var _ = Describe("Task Lifecycle", func() {
 				BeforeEach(func() {
 					By("restarting the bbs with smaller convergeRepeatInterval")
 					ginkgomon.Interrupt(bbsProcess)
-					bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-						overrideConvergenceRepeatInterval,
-						overrideKickTaskDuration,
-					))
+					bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval, overrideKickTaskDuration)
+					bbsProcess = ginkgomon.Invoke(bbsRunner)
 				})
 
 				Context("after the task starts", func() {
 					JustBeforeEach(func() {
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+						helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
 					})
 
 					Context("when the cellProcess disappears", func() {
@@ -211,7 +199,7 @@ var _ = Describe("Task Lifecycle", func() {
 						It("eventually marks the task as failed", func() {
 							// time is primarily influenced by rep's heartbeat interval
 							var completedTask *models.Task
-							Eventually(func() interface{} {
+							helpers.TimedEventually(func() interface{} {
 								var err error
 
 								completedTask, err = bbsClient.TaskByGuid(lgr, taskGuid)
@@ -295,10 +283,8 @@ exit 0
 		BeforeEach(func() {
 			By("restarting the bbs with smaller convergeRepeatInterval")
 			ginkgomon.Interrupt(bbsProcess)
-			bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-				overrideConvergenceRepeatInterval,
-				overrideKickTaskDuration,
-			))
+			bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval, overrideKickTaskDuration)
+			bbsProcess = ginkgomon.Invoke(bbsRunner)
 
 			cellProcess = ginkgomon.Invoke(grouper.NewParallel(os.Interrupt, grouper.Members{
 				{"rep", componentMaker.Rep()},
@@ -333,7 +319,7 @@ exit 0
 				})
 
 				It("eventually runs the Task", func() {
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
+					helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(taskGuid))
 				})
 			})
 		})
@@ -343,10 +329,8 @@ exit 0
 		BeforeEach(func() {
 			By("restarting the bbs with smaller convergeRepeatInterval")
 			ginkgomon.Interrupt(bbsProcess)
-			bbsProcess = ginkgomon.Invoke(componentMaker.BBS(
-				overrideConvergenceRepeatInterval,
-				overrideExpirePendingTaskDuration,
-			))
+			bbsRunner = componentMaker.BBS(overrideConvergenceRepeatInterval, overrideExpirePendingTaskDuration)
+			bbsProcess = ginkgomon.Invoke(bbsRunner)
 		})
 
 		Context("and a task is desired", func() {
@@ -370,7 +354,7 @@ exit 0
 
 			It("should be marked as failed after the expire duration", func() {
 				var completedTask *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					completedTask, err = bbsClient.TaskByGuid(lgr, taskGuid)
@@ -390,7 +374,7 @@ exit 0
 
 func pollTaskStatus(taskGuid string, result string) {
 	var completedTask *models.Task
-	Eventually(func() interface{} {
+	helpers.TimedEventually(func() interface{} {
 		var err error
 
 		completedTask, err = bbsClient.TaskByGuid(lgr, taskGuid)
@@ -401,3 +385,19 @@ func pollTaskStatus(taskGuid string, result string) {
 
 	Expect(completedTask.Result).To(Equal(result))
 }
+
+// fixtureApp publishes the named fixture app (see fixtures.Build) and returns
+// a cached dependency that puts it in a container, along with the path to
+// run it from.
+func fixtureApp(fileServer *helpers.FileServerHandle, name string) (*models.CachedDependency, string) {
+	app := fileServer.PublishApp(name+".zip", name, fixtures.Zip)
+	dir := "/tmp/fixtures/" + name
+
+	return &models.CachedDependency{
+		Name:      name,
+		From:      app.URL,
+		To:        dir,
+		CacheKey:  name + "-" + app.SHA256,
+		LogSource: name,
+	}, dir + "/" + name
+}
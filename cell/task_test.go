@@ -17,6 +17,7 @@ import (
 
 	"code.cloudfoundry.org/bbs/models"
 	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/inigo/fixtures"
 	"code.cloudfoundry.org/inigo/helpers"
 	"code.cloudfoundry.org/inigo/inigo_announcement_server"
 	repconfig "code.cloudfoundry.org/rep/cmd/rep/config"
@@ -31,6 +32,7 @@ var _ = Describe("Tasks", func() {
 	)
 
 	var fileServerStaticDir string
+	var fileServer *helpers.FileServerHandle
 
 	BeforeEach(func() {
 		if runtime.GOOS == "windows" {
@@ -39,6 +41,7 @@ var _ = Describe("Tasks", func() {
 		var fileServerRunner ifrit.Runner
 
 		fileServerRunner, fileServerStaticDir = componentMaker.FileServer()
+		fileServer = helpers.NewFileServerHandle(fileServerStaticDir, componentMaker.Addresses().FileServer)
 
 		cellGroup := grouper.Members{
 			{"file-server", fileServerRunner},
@@ -47,7 +50,7 @@ var _ = Describe("Tasks", func() {
 		}
 		cellProcess = ginkgomon.Invoke(grouper.NewParallel(os.Interrupt, cellGroup))
 
-		Eventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(lgr) }).Should(HaveLen(1))
+		helpers.TimedEventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(lgr) }).Should(HaveLen(1))
 	})
 
 	AfterEach(func() {
@@ -61,7 +64,7 @@ var _ = Describe("Tasks", func() {
 			guid = helpers.GenerateGuid()
 		})
 
-		It("runs the command with the provided environment", func() {
+		It("runs the command with the provided environment [fake-garden]", func() {
 			expectedTask := helpers.TaskCreateRequest(
 				guid,
 				&models.RunAction{
@@ -82,7 +85,7 @@ var _ = Describe("Tasks", func() {
 
 			var task *models.Task
 
-			Eventually(func() interface{} {
+			helpers.TimedEventually(func() interface{} {
 				var err error
 
 				task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -112,7 +115,7 @@ var _ = Describe("Tasks", func() {
 
 			var task *models.Task
 
-			Eventually(func() interface{} {
+			helpers.TimedEventually(func() interface{} {
 				var err error
 
 				task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -172,7 +175,7 @@ var _ = Describe("Tasks", func() {
 
 				var task *models.Task
 
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -210,7 +213,7 @@ var _ = Describe("Tasks", func() {
 
 				var task *models.Task
 
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -271,7 +274,7 @@ var _ = Describe("Tasks", func() {
 
 				var task *models.Task
 
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -309,7 +312,7 @@ var _ = Describe("Tasks", func() {
 
 				var task *models.Task
 
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -351,10 +354,10 @@ var _ = Describe("Tasks", func() {
 
 				Expect(err).NotTo(HaveOccurred())
 
-				Eventually(inigo_announcement_server.Announcements).Should(ContainElement("before-memory-overdose"))
+				helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement("before-memory-overdose"))
 
 				var task *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -370,7 +373,7 @@ var _ = Describe("Tasks", func() {
 			})
 		})
 
-		Context("when the command exceeds its file descriptor limit", func() {
+		Context("when the command exceeds its file descriptor limit [fake-garden]", func() {
 			It("should fail the Task", func() {
 				nofile := uint64(10)
 
@@ -410,7 +413,7 @@ echo should have died by now
 				Expect(err).NotTo(HaveOccurred())
 
 				var task *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -425,7 +428,7 @@ echo should have died by now
 			})
 		})
 
-		Context("when the command times out", func() {
+		Context("when the command times out [fake-garden]", func() {
 			It("should fail the Task", func() {
 				expectedTask := helpers.TaskCreateRequest(
 					guid,
@@ -461,7 +464,7 @@ echo should have died by now
 				Expect(err).NotTo(HaveOccurred())
 
 				var task *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -475,7 +478,7 @@ echo should have died by now
 			})
 		})
 
-		Context("when properties are present on the task definition", func() {
+		Context("when properties are present on the task definition [fake-garden]", func() {
 			It("propagates them to the garden container", func() {
 				expectedTask := helpers.TaskCreateRequest(
 					guid,
@@ -510,7 +513,7 @@ echo should have died by now
 				Expect(err).NotTo(HaveOccurred())
 
 				var properties garden.Properties
-				Eventually(func() error {
+				helpers.TimedEventually(func() error {
 					container, err := gardenClient.Lookup(expectedTask.TaskGuid)
 					if err == nil {
 						properties, err = container.Properties()
@@ -566,7 +569,7 @@ echo should have died by now
 				It("downloads the file", func() {
 					err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 					Expect(err).NotTo(HaveOccurred())
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
+					helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
 				})
 			})
 
@@ -597,21 +600,21 @@ echo should have died by now
 						createChecksum("md5")
 						err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 						Expect(err).NotTo(HaveOccurred())
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
+						helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
 					})
 
 					It("downloads the file for sha1", func() {
 						createChecksum("sha1")
 						err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 						Expect(err).NotTo(HaveOccurred())
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
+						helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
 					})
 
 					It("downloads the file for sha256", func() {
 						createChecksum("sha256")
 						err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 						Expect(err).NotTo(HaveOccurred())
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
+						helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(guid))
 					})
 				})
 
@@ -630,7 +633,7 @@ echo should have died by now
 							downloadAction.ChecksumValue = "incorrect_checksum"
 							err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 							Expect(err).NotTo(HaveOccurred())
-							Eventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
+							helpers.TimedEventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
 						})
 
 						It("for sha1", func() {
@@ -638,7 +641,7 @@ echo should have died by now
 							downloadAction.ChecksumValue = "incorrect_checksum"
 							err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 							Expect(err).NotTo(HaveOccurred())
-							Eventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
+							helpers.TimedEventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
 						})
 
 						It("for sha256", func() {
@@ -646,7 +649,7 @@ echo should have died by now
 							downloadAction.ChecksumValue = "incorrect_checksum"
 							err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 							Expect(err).NotTo(HaveOccurred())
-							Eventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
+							helpers.TimedEventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
 						})
 					})
 				})
@@ -686,7 +689,7 @@ echo should have died by now
 				It("downloads the file", func() {
 					err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 					Expect(err).NotTo(HaveOccurred())
-					Eventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedTask.TaskGuid))
+					helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedTask.TaskGuid))
 				})
 			})
 
@@ -718,7 +721,7 @@ echo should have died by now
 						err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 						Expect(err).NotTo(HaveOccurred())
 						expectedGuid := expectedTask.TaskGuid
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedGuid))
+						helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedGuid))
 					})
 
 					It("downloads the file for sha1", func() {
@@ -726,7 +729,7 @@ echo should have died by now
 						err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 						Expect(err).NotTo(HaveOccurred())
 						expectedGuid := expectedTask.TaskGuid
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedGuid))
+						helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedGuid))
 					})
 
 					It("downloads the file for sha256", func() {
@@ -734,7 +737,7 @@ echo should have died by now
 						err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 						Expect(err).NotTo(HaveOccurred())
 						expectedGuid := expectedTask.TaskGuid
-						Eventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedGuid))
+						helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedGuid))
 					})
 				})
 
@@ -753,7 +756,7 @@ echo should have died by now
 							cachedDependency.ChecksumValue = "incorrect_checksum"
 							err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 							Expect(err).NotTo(HaveOccurred())
-							Eventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
+							helpers.TimedEventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
 						})
 
 						It("for sha1", func() {
@@ -761,7 +764,7 @@ echo should have died by now
 							cachedDependency.ChecksumValue = "incorrect_checksum"
 							err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 							Expect(err).NotTo(HaveOccurred())
-							Eventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
+							helpers.TimedEventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
 						})
 
 						It("for sha256", func() {
@@ -769,7 +772,7 @@ echo should have died by now
 							cachedDependency.ChecksumValue = "incorrect_checksum"
 							err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 							Expect(err).NotTo(HaveOccurred())
-							Eventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
+							helpers.TimedEventually(helpers.TaskFailedPoller(lgr, bbsClient, expectedTask.TaskGuid, nil)).Should(BeTrue())
 						})
 					})
 				})
@@ -808,13 +811,16 @@ echo should have died by now
 		})
 
 		It("uploads the specified files", func() {
+			writeResultFile, writeResultFilePath := fixtureApp(fileServer, fixtures.WriteResultFile)
+			announce, announcePath := fixtureApp(fileServer, fixtures.SleepThenAnnounce)
+
 			expectedTask := helpers.TaskCreateRequest(
 				guid,
 				models.Serial(
 					&models.RunAction{
 						User: "vcap",
-						Path: "sh",
-						Args: []string{"-c", "echo tasty thingy > thingy"},
+						Path: writeResultFilePath,
+						Args: []string{"-path", "/home/vcap/thingy", "-content", "tasty thingy\n"},
 					},
 					&models.UploadAction{
 						From: "thingy",
@@ -823,40 +829,43 @@ echo should have died by now
 					},
 					&models.RunAction{
 						User: "vcap",
-						Path: "curl",
-						Args: []string{inigo_announcement_server.AnnounceURL(guid)},
+						Path: announcePath,
+						Args: []string{"-url", inigo_announcement_server.AnnounceURL(guid)},
 					},
 				),
 			)
+			expectedTask.CachedDependencies = []*models.CachedDependency{writeResultFile, announce}
 
 			err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 			Expect(err).NotTo(HaveOccurred())
 
-			Eventually(gotRequest).Should(BeClosed())
+			helpers.TimedEventually(gotRequest).Should(BeClosed())
 
-			Eventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedTask.TaskGuid))
+			helpers.TimedEventually(inigo_announcement_server.Announcements).Should(ContainElement(expectedTask.TaskGuid))
 		})
 	})
 
 	Describe("Fetching results", func() {
 		It("should fetch the contents of the requested file and provide the content in the completed Task", func() {
 			guid := helpers.GenerateGuid()
+			writeResultFile, writeResultFilePath := fixtureApp(fileServer, fixtures.WriteResultFile)
 
 			expectedTask := helpers.TaskCreateRequest(
 				guid,
 				&models.RunAction{
 					User: "vcap",
-					Path: "sh",
-					Args: []string{"-c", "echo tasty thingy > thingy"},
+					Path: writeResultFilePath,
+					Args: []string{"-path", "/home/vcap/thingy", "-content", "tasty thingy\n"},
 				},
 			)
+			expectedTask.CachedDependencies = []*models.CachedDependency{writeResultFile}
 			expectedTask.ResultFile = "/home/vcap/thingy"
 
 			err := bbsClient.DesireTask(lgr, expectedTask.TaskGuid, expectedTask.Domain, expectedTask.TaskDefinition)
 			Expect(err).NotTo(HaveOccurred())
 
 			var task *models.Task
-			Eventually(func() interface{} {
+			helpers.TimedEventually(func() interface{} {
 				var err error
 
 				task, err = bbsClient.TaskByGuid(lgr, guid)
@@ -2,6 +2,7 @@ package executor_test
 
 import (
 	"archive/tar"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -18,6 +19,8 @@ import (
 	executorinit "code.cloudfoundry.org/executor/initializer"
 	"code.cloudfoundry.org/executor/initializer/configuration"
 	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/helpers/fakegarden"
 	"code.cloudfoundry.org/inigo/world"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
@@ -181,7 +184,7 @@ var _ = Describe("Executor/Garden", func() {
 	findGardenContainer := func(handle string) garden.Container {
 		var container garden.Container
 
-		Eventually(func() error {
+		helpers.TimedEventually(func() error {
 			var err error
 
 			container, err = gardenClient.Lookup(handle)
@@ -213,7 +216,7 @@ var _ = Describe("Executor/Garden", func() {
 
 		Context("when the cache directory doesn't exist", func() {
 			It("creates a new cache directory", func() {
-				Eventually(func() bool {
+				helpers.TimedEventually(func() bool {
 					dirInfo, err := os.Stat(cachePath)
 					if err != nil {
 						return false
@@ -246,12 +249,12 @@ var _ = Describe("Executor/Garden", func() {
 			})
 
 			It("deletes those containers (and only those containers)", func() {
-				Eventually(func() error {
+				helpers.TimedEventually(func() error {
 					_, err := gardenClient.Lookup(container1.Handle())
 					return err
 				}).Should(HaveOccurred())
 
-				Eventually(func() error {
+				helpers.TimedEventually(func() error {
 					_, err := gardenClient.Lookup(container2.Handle())
 					return err
 				}).Should(HaveOccurred())
@@ -299,10 +302,43 @@ var _ = Describe("Executor/Garden", func() {
 					isHealthy := func() bool { return executorClient.Healthy(logger) }
 
 					ginkgomon.Interrupt(gardenProcess)
-					Eventually(isHealthy).Should(BeFalse())
+					helpers.TimedEventually(isHealthy).Should(BeFalse())
 
-					gardenProcess = ginkgomon.Invoke(componentMaker.Garden())
-					Eventually(isHealthy).Should(BeTrue())
+					gardenRunner = componentMaker.Garden()
+					gardenProcess = ginkgomon.Invoke(gardenRunner)
+					helpers.TimedEventually(isHealthy).Should(BeTrue())
+				})
+			})
+
+			Context("garden fails to create containers, then recovers", func() {
+				var (
+					proxy        *fakegarden.Proxy
+					proxyProcess ifrit.Process
+				)
+
+				BeforeEach(func() {
+					config.GardenHealthcheckInterval = durationjson.Duration(5 * time.Millisecond)
+
+					proxy = fakegarden.NewProxy(gardenClient)
+
+					var proxyRunner ifrit.Runner
+					proxyRunner, config.GardenAddr = componentMaker.GardenProxy(proxy)
+					proxyProcess = ginkgomon.Invoke(proxyRunner)
+				})
+
+				AfterEach(func() {
+					ginkgomon.Interrupt(proxyProcess)
+				})
+
+				It("reports correctly", func() {
+					isHealthy := func() bool { return executorClient.Healthy(logger) }
+					Expect(isHealthy()).To(BeTrue())
+
+					proxy.Inject(fakegarden.CallCreate, fakegarden.ReturnError(errors.New("no subnets left")))
+					helpers.TimedEventually(isHealthy).Should(BeFalse())
+
+					proxy.Clear()
+					helpers.TimedEventually(isHealthy).Should(BeTrue())
 				})
 			})
 		})
@@ -327,7 +363,8 @@ var _ = Describe("Executor/Garden", func() {
 				})
 
 				AfterEach(func() {
-					gardenProcess = ginkgomon.Invoke(componentMaker.Garden())
+					gardenRunner = componentMaker.Garden()
+					gardenProcess = ginkgomon.Invoke(gardenRunner)
 				})
 
 				It("should return an error", func() {
@@ -530,7 +567,7 @@ var _ = Describe("Executor/Garden", func() {
 					})
 
 					It("saves the succeeded run result", func() {
-						Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+						helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 
 						container := getContainer(guid)
 						Expect(container.RunResult.Failed).To(BeFalse())
@@ -547,7 +584,7 @@ var _ = Describe("Executor/Garden", func() {
 						})
 
 						It("reports the state as 'running'", func() {
-							Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+							helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 							Consistently(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 						})
 					})
@@ -564,11 +601,11 @@ var _ = Describe("Executor/Garden", func() {
 
 								It("emits a running container event", func() {
 									var event executor.Event
-									Eventually(containerEventPoller(eventSource, &event), 5).Should(Equal(executor.EventTypeContainerRunning))
+									helpers.TimedEventually(containerEventPoller(eventSource, &event), 5).Should(Equal(executor.EventTypeContainerRunning))
 								})
 
 								It("reports the state as 'running'", func() {
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+									helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 									Consistently(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 								})
 
@@ -586,7 +623,7 @@ var _ = Describe("Executor/Garden", func() {
 								})
 
 								It("reports the state as 'created'", func() {
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCreated))
+									helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCreated))
 									Consistently(containerStatePoller(guid)).Should(Equal(executor.StateCreated))
 								})
 							})
@@ -610,8 +647,8 @@ var _ = Describe("Executor/Garden", func() {
 								})
 
 								It("reports the container as 'running' and then as 'completed'", func() {
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+									helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+									helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 								})
 							})
 						}
@@ -656,7 +693,7 @@ var _ = Describe("Executor/Garden", func() {
 								})
 
 								It("stops the container", func() {
-									Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+									helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 								})
 							})
 						})
@@ -667,7 +704,7 @@ var _ = Describe("Executor/Garden", func() {
 							It("works", func(done Done) {
 								defer close(done)
 
-								Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+								helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 
 								err := executorClient.DeleteContainer(logger, guid)
 								Expect(err).NotTo(HaveOccurred())
@@ -684,7 +721,7 @@ var _ = Describe("Executor/Garden", func() {
 						})
 
 						It("saves the failed result and reason", func() {
-							Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+							helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 
 							container := getContainer(guid)
 							Expect(container.RunResult.Failed).To(BeTrue())
@@ -694,7 +731,7 @@ var _ = Describe("Executor/Garden", func() {
 						Context("when listening for events", func() {
 							It("emits a completed container event", func() {
 								var event executor.Event
-								Eventually(containerEventPoller(eventSource, &event), 5).Should(Equal(executor.EventTypeContainerComplete))
+								helpers.TimedEventually(containerEventPoller(eventSource, &event), 5).Should(Equal(executor.EventTypeContainerComplete))
 
 								completeEvent := event.(executor.ContainerCompleteEvent)
 								Expect(completeEvent.Container().State).To(Equal(executor.StateCompleted))
@@ -716,7 +753,7 @@ var _ = Describe("Executor/Garden", func() {
 
 					Context("when listening for events", func() {
 						It("eventually completes with failure", func() {
-							Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+							helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 
 							container := getContainer(guid)
 							Expect(container.RunResult.Failed).To(BeTrue())
@@ -752,7 +789,7 @@ var _ = Describe("Executor/Garden", func() {
 					err := executorClient.DeleteContainer(logger, guid)
 					Expect(err).NotTo(HaveOccurred())
 
-					Eventually(func() (executor.ExecutorResources, error) { return executorClient.RemainingResources(logger) }).Should(Equal(executor.ExecutorResources{
+					helpers.TimedEventually(func() (executor.ExecutorResources, error) { return executorClient.RemainingResources(logger) }).Should(Equal(executor.ExecutorResources{
 						MemoryMB:   int(gardenCapacity.MemoryInBytes / 1024 / 1024),
 						DiskMB:     expectedDiskCapacityMB,
 						Containers: int(gardenCapacity.MaxContainers) - 1,
@@ -795,7 +832,7 @@ var _ = Describe("Executor/Garden", func() {
 				err := executorClient.RunContainer(logger, &runRequest)
 				Expect(err).NotTo(HaveOccurred())
 
-				Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+				helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 			})
 
 			Describe("StopContainer", func() {
@@ -809,7 +846,7 @@ var _ = Describe("Executor/Garden", func() {
 					Expect(err).NotTo(HaveOccurred())
 
 					var container executor.Container
-					Eventually(func() executor.State {
+					helpers.TimedEventually(func() executor.State {
 						container, err = executorClient.GetContainer(logger, guid)
 						Expect(err).NotTo(HaveOccurred())
 						return container.State
@@ -827,7 +864,7 @@ var _ = Describe("Executor/Garden", func() {
 					err := executorClient.DeleteContainer(logger, guid)
 					Expect(err).NotTo(HaveOccurred())
 
-					Eventually(func() error {
+					helpers.TimedEventually(func() error {
 						_, err := gardenClient.Lookup(guid)
 						return err
 					}).Should(HaveOccurred())
@@ -864,7 +901,7 @@ var _ = Describe("Executor/Garden", func() {
 						err := executorClient.DeleteContainer(logger, guid)
 						Expect(err).NotTo(HaveOccurred())
 
-						Eventually(func() (executor.ExecutorResources, error) { return executorClient.RemainingResources(logger) }).Should(
+						helpers.TimedEventually(func() (executor.ExecutorResources, error) { return executorClient.RemainingResources(logger) }).Should(
 							Equal(executor.ExecutorResources{
 								MemoryMB:   int(gardenCapacity.MemoryInBytes / 1024 / 1024),
 								DiskMB:     expectedDiskCapacityMB,
@@ -874,6 +911,55 @@ var _ = Describe("Executor/Garden", func() {
 				})
 			})
 
+			Context("when garden is slow to report metrics", func() {
+				var (
+					proxy        *fakegarden.Proxy
+					proxyProcess ifrit.Process
+				)
+
+				BeforeEach(func() {
+					proxy = fakegarden.NewProxy(gardenClient)
+
+					var proxyRunner ifrit.Runner
+					proxyRunner, config.GardenAddr = componentMaker.GardenProxy(proxy)
+					proxyProcess = ginkgomon.Invoke(proxyRunner)
+				})
+
+				AfterEach(func() {
+					proxy.Clear()
+					ginkgomon.Interrupt(proxyProcess)
+				})
+
+				It("waits for the metrics without holding up other requests", func() {
+					const delay = 3 * time.Second
+					proxy.Inject(fakegarden.CallBulkMetrics, fakegarden.Delay(delay))
+
+					type bulkMetrics struct {
+						metrics map[string]executor.Metrics
+						err     error
+						took    time.Duration
+					}
+					result := make(chan bulkMetrics, 1)
+					go func() {
+						start := time.Now()
+						metrics, err := executorClient.GetBulkMetrics(logger)
+						result <- bulkMetrics{metrics: metrics, err: err, took: time.Since(start)}
+					}()
+
+					helpers.TimedEventually(func() int { return proxy.CallCount(fakegarden.CallBulkMetrics) }).Should(BeNumerically(">", 0))
+
+					start := time.Now()
+					Expect(getContainer(guid).State).To(Equal(executor.StateRunning))
+					Expect(time.Since(start)).To(BeNumerically("<", delay))
+
+					var slow bulkMetrics
+					helpers.TimedEventually(result, 2*delay).Should(Receive(&slow))
+					Expect(slow.err).NotTo(HaveOccurred())
+					Expect(slow.took).To(BeNumerically(">=", delay))
+					Expect(slow.metrics).To(HaveKey(guid))
+				})
+			})
+
 			Describe("removing the container from garden", func() {
 				It("transistions the container into the completed state", func() {
 					findGardenContainer(guid)
@@ -882,7 +968,7 @@ var _ = Describe("Executor/Garden", func() {
 					err := gardenClient.Destroy(guid)
 					Expect(err).NotTo(HaveOccurred())
 
-					Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+					helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 					container := getContainer(guid)
 					Expect(container.RunResult.Failed).To(BeTrue())
 				})
@@ -978,7 +1064,7 @@ var _ = Describe("Executor/Garden", func() {
 				containers = removeHealthcheckContainers(containers)
 				Expect(containers).To(HaveLen(1))
 
-				Eventually(func() executor.State {
+				helpers.TimedEventually(func() executor.State {
 					container, err := executorClient.GetContainer(logger, "some-handle")
 					Expect(err).NotTo(HaveOccurred())
 
@@ -1041,14 +1127,14 @@ var _ = Describe("Executor/Garden", func() {
 					err := executorClient.RunContainer(logger, &runRequest)
 					Expect(err).NotTo(HaveOccurred())
 
-					Eventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
+					helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateRunning))
 
 					container := getContainer(guid)
 
 					externalAddr = fmt.Sprintf("%s:%d", container.ExternalIP, container.Ports[0].HostPort)
 
 					var conn net.Conn
-					Eventually(func() error {
+					helpers.TimedEventually(func() error {
 						var err error
 						dialer := net.Dialer{Timeout: time.Second * 5}
 						conn, err = dialer.Dial("tcp", externalAddr)
@@ -11,12 +11,12 @@ import (
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gexec"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/helpers/buildcache"
 	"code.cloudfoundry.org/inigo/helpers/certauthority"
 	"code.cloudfoundry.org/inigo/helpers/portauthority"
 	"code.cloudfoundry.org/inigo/world"
@@ -25,6 +25,7 @@ import (
 var (
 	componentMaker world.ComponentMaker
 
+	gardenRunner  ifrit.Runner
 	gardenProcess ifrit.Process
 	gardenClient  garden.Client
 	suiteTempDir  string
@@ -67,6 +68,10 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 		SQL:                 fmt.Sprintf("%sdiego_%d", dbBaseConnectionString, config.GinkgoConfig.ParallelNode),
 	}
 
+	if world.LocketOnly() {
+		addresses.Consul = ""
+	}
+
 	node := GinkgoParallelProcess()
 	startPort := 1000 * node
 	portRange := 950
@@ -85,21 +90,23 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 })
 
 var _ = AfterSuite(func() {
+	Expect(helpers.WriteEventuallyTimings()).To(Succeed())
 	componentMaker.Teardown()
 
 	deleteSuiteTempDir := func() error { return os.RemoveAll(suiteTempDir) }
-	Eventually(deleteSuiteTempDir).Should(Succeed())
+	helpers.TimedEventually(deleteSuiteTempDir).Should(Succeed())
 })
 
 var _ = BeforeEach(func() {
-	gardenProcess = ginkgomon.Invoke(componentMaker.Garden())
+	gardenRunner = componentMaker.Garden()
+	gardenProcess = ginkgomon.Invoke(gardenRunner)
 	gardenClient = componentMaker.GardenClient()
 })
 
 var _ = AfterEach(func() {
 	destroyContainerErrors := helpers.CleanupGarden(gardenClient)
 
-	helpers.StopProcesses(gardenProcess)
+	helpers.StopNamed(helpers.NewNamedProcess("garden", gardenRunner, gardenProcess))
 
 	Expect(destroyContainerErrors).To(
 		BeEmpty(),
@@ -109,7 +116,10 @@ var _ = AfterEach(func() {
 })
 
 func TestExecutor(t *testing.T) {
-	helpers.RegisterDefaultTimeouts()
+	err := helpers.RegisterDefaultTimeouts()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	RegisterFailHandler(Fail)
 
@@ -124,7 +134,7 @@ func CompileTestedExecutables() world.BuiltExecutables {
 	cwd, err := os.Getwd()
 	Expect(err).NotTo(HaveOccurred())
 	Expect(os.Chdir(os.Getenv("GARDEN_GOPATH"))).To(Succeed())
-	builtExecutables["garden"], err = gexec.Build("./cmd/gdn", "-race", "-a", "-tags", "daemon")
+	builtExecutables["garden"], err = buildcache.Build("./cmd/gdn", "-race", "-a", "-tags", "daemon")
 	Expect(err).NotTo(HaveOccurred())
 	Expect(os.Chdir(cwd)).To(Succeed())
 
@@ -1,42 +1,90 @@
 package fixtures
 
 import (
+	"fmt"
 	"io/ioutil"
-	"os"
 	"runtime"
+	"sort"
 
 	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	"code.cloudfoundry.org/inigo/helpers/buildcache"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gexec"
 )
 
-func GoServerApp() []archive_helper.ArchiveFile {
-	originalCGOValue := os.Getenv("CGO_ENABLED")
-	os.Setenv("CGO_ENABLED", "0")
+// The fixture apps in the catalogue. Each is a small static Go program; run
+// it with -help (or read its source under fixtures/apps) for its flags.
+const (
+	GoServer = "go-server"
+
+	// ExitWithCode exits with -code after printing -message to stderr.
+	ExitWithCode = "exit-with-code"
+	// ProduceOutput writes -bytes bytes of a recognisable pattern to -stream.
+	ProduceOutput = "produce-output"
+	// WriteResultFile writes -content (or -bytes of padding) to -path, for
+	// tasks with a ResultFile.
+	WriteResultFile = "write-result-file"
+	// SleepThenAnnounce sleeps for -sleep and then GETs -url, or -term-url if
+	// it is sent SIGTERM first.
+	SleepThenAnnounce = "sleep-then-announce"
+	// ForkBombGuard starts up to -max processes, prints a JSON report of how
+	// many the pid limit allowed, and cleans them all up.
+	ForkBombGuard = "fork-bomb-guard"
+	// LogSpammer emits -lines numbered lines at -rate lines per second.
+	LogSpammer = "log-spammer"
+)
 
-	serverPath, err := gexec.Build("code.cloudfoundry.org/inigo/fixtures/go-server")
+var catalogue = map[string]string{
+	GoServer:          "code.cloudfoundry.org/inigo/fixtures/go-server",
+	ExitWithCode:      "code.cloudfoundry.org/inigo/fixtures/apps/exit-with-code",
+	ProduceOutput:     "code.cloudfoundry.org/inigo/fixtures/apps/produce-output",
+	WriteResultFile:   "code.cloudfoundry.org/inigo/fixtures/apps/write-result-file",
+	SleepThenAnnounce: "code.cloudfoundry.org/inigo/fixtures/apps/sleep-then-announce",
+	ForkBombGuard:     "code.cloudfoundry.org/inigo/fixtures/apps/fork-bomb-guard",
+	LogSpammer:        "code.cloudfoundry.org/inigo/fixtures/apps/log-spammer",
+}
 
-	os.Setenv("CGO_ENABLED", originalCGOValue)
+// Names returns the names of every app in the catalogue.
+func Names() []string {
+	names := make([]string, 0, len(catalogue))
+	for name := range catalogue {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
+// Build compiles the named app (statically, through the build cache) and
+// returns its binary along with a staging_info.yml that starts it, ready to be
+// packaged with Package or archive_helper.
+func Build(name string) []archive_helper.ArchiveFile {
+	packagePath, ok := catalogue[name]
+	Expect(ok).To(BeTrue(), fmt.Sprintf("unknown fixture app %q, must be one of %v", name, Names()))
+
+	binaryPath, err := buildcache.BuildWithEnvironment(packagePath, []string{"CGO_ENABLED=0"})
 	Expect(err).NotTo(HaveOccurred())
 
-	contents, err := ioutil.ReadFile(serverPath)
+	contents, err := ioutil.ReadFile(binaryPath)
 	Expect(err).NotTo(HaveOccurred())
+
 	return []archive_helper.ArchiveFile{
 		{
-			Name: getGoServerBinaryName(),
+			Name: binaryName(name),
 			Body: string(contents),
 		}, {
 			Name: "staging_info.yml",
-			Body: `detected_buildpack: Doesn't Matter
-start_command: go-server`,
+			Body: fmt.Sprintf(`detected_buildpack: Doesn't Matter
+start_command: %s`, name),
 		},
 	}
 }
 
-func getGoServerBinaryName() string {
+func GoServerApp() []archive_helper.ArchiveFile {
+	return Build(GoServer)
+}
+
+func binaryName(name string) string {
 	if runtime.GOOS == "windows" {
-		return "go-server.exe"
+		return name + ".exe"
 	}
-	return "go-server"
+	return name
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+var (
+	code    = flag.Int("code", 0, "exit with this code")
+	message = flag.String("message", "", "print this to stderr before exiting")
+)
+
+func main() {
+	flag.Parse()
+
+	if *message != "" {
+		fmt.Fprintln(os.Stderr, *message)
+	}
+
+	os.Exit(*code)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+)
+
+var (
+	maxProcesses = flag.Int("max", 1024, "stop after starting this many processes")
+	child        = flag.Bool("child", false, "run as a child, blocking until stdin closes")
+)
+
+type report struct {
+	Started int    `json:"started"`
+	Limited bool   `json:"limited"`
+	Error   string `json:"error,omitempty"`
+}
+
+// fork-bomb-guard starts processes until the container's pid limit stops it
+// (or -max is reached), reports how many it got, and then cleans them all up
+// again. It never lets the processes outlive it, so it is safe to run on a
+// shared host.
+func main() {
+	flag.Parse()
+
+	if *child {
+		io.Copy(ioutil.Discard, os.Stdin)
+		return
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		self = os.Args[0]
+	}
+
+	children := []*exec.Cmd{}
+	result := report{}
+
+	for len(children) < *maxProcesses {
+		cmd := exec.Command(self, "-child")
+		_, err := cmd.StdinPipe()
+		if err == nil {
+			err = cmd.Start()
+		}
+		if err != nil {
+			result.Limited = true
+			result.Error = err.Error()
+			break
+		}
+		children = append(children, cmd)
+	}
+	result.Started = len(children)
+
+	for _, cmd := range children {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}
+
+	json.NewEncoder(os.Stdout).Encode(result)
+}
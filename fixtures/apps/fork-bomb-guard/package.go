@@ -0,0 +1 @@
+package main // import "code.cloudfoundry.org/inigo/fixtures/apps/fork-bomb-guard"
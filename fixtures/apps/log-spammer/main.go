@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	lines     = flag.Int("lines", 100, "emit this many lines; 0 emits forever")
+	rate      = flag.Int("rate", 0, "emit this many lines per second; 0 emits as fast as possible")
+	lineBytes = flag.Int("line-bytes", 64, "pad every line to this many bytes")
+	stream    = flag.String("stream", "stdout", "emit to stdout, stderr or both (alternating)")
+)
+
+// every line carries its sequence number so that specs can count what was
+// dropped on the way through the logging pipeline
+func main() {
+	flag.Parse()
+
+	if *stream != "stdout" && *stream != "stderr" && *stream != "both" {
+		fmt.Fprintf(os.Stderr, "unknown stream %q, must be stdout, stderr or both\n", *stream)
+		os.Exit(2)
+	}
+
+	var tick <-chan time.Time
+	if *rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for i := 1; *lines == 0 || i <= *lines; i++ {
+		if tick != nil {
+			<-tick
+		}
+
+		line := fmt.Sprintf("log-spammer %d ", i)
+		if padding := *lineBytes - len(line); padding > 0 {
+			line += strings.Repeat("x", padding)
+		}
+
+		out := os.Stdout
+		if *stream == "stderr" || (*stream == "both" && i%2 == 0) {
+			out = os.Stderr
+		}
+		fmt.Fprintln(out, line)
+	}
+}
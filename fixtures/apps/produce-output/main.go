@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	bytes  = flag.Int("bytes", 1024, "write this many bytes")
+	stream = flag.String("stream", "stdout", "write to stdout or stderr")
+)
+
+// the output is a repeating, recognisable pattern so that truncation shows
+const pattern = "0123456789abcdefghijklmnopqrstuvwxyz\n"
+
+func main() {
+	flag.Parse()
+
+	var out io.Writer
+	switch *stream {
+	case "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		fmt.Fprintf(os.Stderr, "unknown stream %q, must be stdout or stderr\n", *stream)
+		os.Exit(2)
+	}
+
+	writer := bufio.NewWriter(out)
+	for remaining := *bytes; remaining > 0; remaining -= len(pattern) {
+		chunk := pattern
+		if remaining < len(chunk) {
+			chunk = chunk[:remaining]
+		}
+		writer.WriteString(chunk)
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		os.Exit(1)
+	}
+}
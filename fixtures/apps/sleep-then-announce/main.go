@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var (
+	sleep   = flag.Duration("sleep", 0, "sleep this long before announcing")
+	url     = flag.String("url", "", "announce by GETting this URL, e.g. inigo_announcement_server.AnnounceURL")
+	termURL = flag.String("term-url", "", "GET this URL instead if SIGTERM arrives while sleeping")
+)
+
+func main() {
+	flag.Parse()
+
+	if *url == "" {
+		fmt.Fprintln(os.Stderr, "-url is required")
+		os.Exit(2)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+
+	select {
+	case <-time.After(*sleep):
+		announce(*url)
+	case <-signals:
+		if *termURL != "" {
+			announce(*termURL)
+		}
+		os.Exit(143)
+	}
+}
+
+func announce(url string) {
+	response, err := http.Get(url)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to announce: %s\n", err.Error())
+		os.Exit(1)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		fmt.Fprintf(os.Stderr, "failed to announce: status %d\n", response.StatusCode)
+		os.Exit(1)
+	}
+
+	fmt.Printf("announced %s\n", url)
+}
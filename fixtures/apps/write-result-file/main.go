@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var (
+	path    = flag.String("path", "/home/vcap/result", "write the result to this file")
+	content = flag.String("content", "", "the result to write")
+	bytes   = flag.Int("bytes", 0, "write this many bytes of padding instead of -content, e.g. to exceed the result size limit")
+)
+
+func main() {
+	flag.Parse()
+
+	result := *content
+	if *bytes > 0 {
+		result = strings.Repeat("r", *bytes)
+	}
+
+	err := ioutil.WriteFile(*path, []byte(result), 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write result: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Printf("wrote %d bytes to %s\n", len(result), *path)
+}
@@ -0,0 +1,191 @@
+package fixtures
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sort"
+
+	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	. "github.com/onsi/gomega"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// StagingInfo is the staging_info.yml at the root of a droplet. The launcher
+// only reads StartCommand; the rest mirrors what the buildpack lifecycle
+// reports after staging, so that specs can check it is passed along.
+type StagingInfo struct {
+	DetectedBuildpack string            `yaml:"detected_buildpack" json:"detected_buildpack"`
+	StartCommand      string            `yaml:"start_command" json:"start_command"`
+	LifecycleType     string            `yaml:"lifecycle_type,omitempty" json:"lifecycle_type,omitempty"`
+	LifecycleMetadata LifecycleMetadata `yaml:"lifecycle_metadata" json:"lifecycle_metadata"`
+	ProcessTypes      map[string]string `yaml:"process_types,omitempty" json:"process_types,omitempty"`
+	Sidecars          []Sidecar         `yaml:"sidecars,omitempty" json:"sidecars,omitempty"`
+}
+
+type LifecycleMetadata struct {
+	BuildpackKey      string      `yaml:"buildpack_key,omitempty" json:"buildpack_key,omitempty"`
+	DetectedBuildpack string      `yaml:"detected_buildpack,omitempty" json:"detected_buildpack,omitempty"`
+	Buildpacks        []Buildpack `yaml:"buildpacks,omitempty" json:"buildpacks,omitempty"`
+}
+
+type Buildpack struct {
+	Key     string `yaml:"key" json:"key"`
+	Name    string `yaml:"name" json:"name"`
+	Version string `yaml:"version,omitempty" json:"version,omitempty"`
+}
+
+type Sidecar struct {
+	Name         string   `yaml:"name" json:"name"`
+	ProcessTypes []string `yaml:"process_types" json:"process_types"`
+	Command      string   `yaml:"command" json:"command"`
+	MemoryMb     int      `yaml:"memory,omitempty" json:"memory,omitempty"`
+}
+
+// DropletBuilder lays out a droplet the way the buildpack lifecycle leaves
+// it: the app under app/, buildpack provided profile.d scripts under
+// profile.d/, app provided ones under app/.profile.d/, and staging_info.yml at
+// the root.
+type DropletBuilder struct {
+	appFiles     []archive_helper.ArchiveFile
+	profileD     []archive_helper.ArchiveFile
+	appProfileD  []archive_helper.ArchiveFile
+	stagingInfo  StagingInfo
+	processTypes map[string]string
+}
+
+func NewDroplet() *DropletBuilder {
+	return &DropletBuilder{
+		stagingInfo: StagingInfo{
+			DetectedBuildpack: "Doesn't Matter",
+			LifecycleType:     "buildpack",
+		},
+		processTypes: map[string]string{},
+	}
+}
+
+// WithApp adds the named fixture app (see Build) to app/ and makes it the
+// web process, unless a web process has already been set.
+func (d *DropletBuilder) WithApp(name string) *DropletBuilder {
+	for _, file := range Build(name) {
+		if file.Name == "staging_info.yml" {
+			continue
+		}
+		d.appFiles = append(d.appFiles, file)
+	}
+
+	if _, ok := d.processTypes["web"]; !ok {
+		d.processTypes["web"] = "./" + binaryName(name)
+	}
+	return d
+}
+
+// WithFile adds a file, relative to app/.
+func (d *DropletBuilder) WithFile(name, body string, mode int64) *DropletBuilder {
+	d.appFiles = append(d.appFiles, archive_helper.ArchiveFile{Name: name, Body: body, Mode: mode})
+	return d
+}
+
+// WithProfileScript adds a script to the droplet's profile.d/, where
+// buildpacks put theirs. The launcher sources it before starting a process.
+func (d *DropletBuilder) WithProfileScript(name, body string) *DropletBuilder {
+	d.profileD = append(d.profileD, archive_helper.ArchiveFile{Name: name, Body: body, Mode: 0755})
+	return d
+}
+
+// WithAppProfileScript adds a script to app/.profile.d/, where apps put
+// theirs. The launcher sources these after the buildpack ones.
+func (d *DropletBuilder) WithAppProfileScript(name, body string) *DropletBuilder {
+	d.appProfileD = append(d.appProfileD, archive_helper.ArchiveFile{Name: name, Body: body, Mode: 0755})
+	return d
+}
+
+// WithProcessType sets the command for a process type. The web process is
+// the droplet's start_command.
+func (d *DropletBuilder) WithProcessType(name, command string) *DropletBuilder {
+	d.processTypes[name] = command
+	return d
+}
+
+func (d *DropletBuilder) WithSidecar(sidecar Sidecar) *DropletBuilder {
+	d.stagingInfo.Sidecars = append(d.stagingInfo.Sidecars, sidecar)
+	return d
+}
+
+// WithBuildpack records a buildpack as having taken part in staging; the
+// last one added is reported as the detected buildpack.
+func (d *DropletBuilder) WithBuildpack(buildpack Buildpack) *DropletBuilder {
+	metadata := &d.stagingInfo.LifecycleMetadata
+	metadata.Buildpacks = append(metadata.Buildpacks, buildpack)
+	metadata.BuildpackKey = buildpack.Key
+	metadata.DetectedBuildpack = buildpack.Name
+	d.stagingInfo.DetectedBuildpack = buildpack.Name
+	return d
+}
+
+// StagingInfo returns what will be written to staging_info.yml.
+func (d *DropletBuilder) StagingInfo() StagingInfo {
+	info := d.stagingInfo
+	info.StartCommand = d.processTypes["web"]
+	info.ProcessTypes = map[string]string{}
+	for name, command := range d.processTypes {
+		info.ProcessTypes[name] = command
+	}
+	return info
+}
+
+// StartCommand returns the command for processType, e.g. to use as the
+// argument to the launcher in a DesiredLRP.
+func (d *DropletBuilder) StartCommand(processType string) string {
+	command, ok := d.processTypes[processType]
+	Expect(ok).To(BeTrue(), fmt.Sprintf("droplet has no %q process type", processType))
+	return command
+}
+
+// Files returns the droplet's contents, laid out as described on
+// DropletBuilder.
+func (d *DropletBuilder) Files() []archive_helper.ArchiveFile {
+	stagingInfo, err := yaml.Marshal(d.StagingInfo())
+	Expect(err).NotTo(HaveOccurred())
+
+	files := []archive_helper.ArchiveFile{
+		{Name: "staging_info.yml", Body: string(stagingInfo)},
+	}
+	files = append(files, underDir("app", d.appFiles)...)
+	files = append(files, underDir("profile.d", d.profileD)...)
+	files = append(files, underDir("app/.profile.d", d.appProfileD)...)
+
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
+
+// WriteTo writes the droplet as a tgz to archivePath.
+func (d *DropletBuilder) WriteTo(archivePath string) {
+	archive_helper.CreateTarGZArchive(archivePath, d.Files())
+}
+
+// Publish writes the droplet as filename into the file server's static
+// directory (as returned by ComponentMaker.FileServer) and returns the URL it
+// is served from.
+func (d *DropletBuilder) Publish(staticDir, fileServerAddress, filename string) string {
+	d.WriteTo(filepath.Join(staticDir, filename))
+
+	publishedURL := url.URL{
+		Scheme: "http",
+		Host:   fileServerAddress,
+		Path:   path.Join("/v1/static", filename),
+	}
+	return publishedURL.String()
+}
+
+func underDir(dir string, files []archive_helper.ArchiveFile) []archive_helper.ArchiveFile {
+	moved := make([]archive_helper.ArchiveFile, 0, len(files))
+	for _, file := range files {
+		file.Name = path.Join(dir, file.Name)
+		moved = append(moved, file)
+	}
+	return moved
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	controlLock    sync.Mutex
+	healthListener net.Listener
+	hung           chan struct{}
+	allocations    [][]uint8
+)
+
+func registerControlHandlers() {
+	http.HandleFunc("/control/exit", controlExit)
+	http.HandleFunc("/control/health", controlHealth)
+	http.HandleFunc("/control/ignore-sigterm", controlIgnoreSigterm)
+	http.HandleFunc("/control/hang", controlHang)
+	http.HandleFunc("/control/allocate", controlAllocate)
+}
+
+// startHealthListener listens on HEALTH_PORT, if set, so that a port based
+// monitor can be pointed at it and flipped with /control/health.
+func startHealthListener() error {
+	controlLock.Lock()
+	defer controlLock.Unlock()
+
+	healthPort := os.Getenv("HEALTH_PORT")
+	if healthPort == "" || healthListener != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", ":"+healthPort)
+	if err != nil {
+		return err
+	}
+	healthListener = listener
+
+	go http.Serve(listener, http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(res, "healthy")
+	}))
+	return nil
+}
+
+func stopHealthListener() error {
+	controlLock.Lock()
+	defer controlLock.Unlock()
+
+	if healthListener == nil {
+		return nil
+	}
+
+	err := healthListener.Close()
+	healthListener = nil
+	return err
+}
+
+// hangable blocks requests while the server has been told to hang. Control
+// requests are never blocked so that the hang can be lifted again.
+func hangable(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !strings.HasPrefix(req.URL.Path, "/control/") {
+			controlLock.Lock()
+			wait := hung
+			controlLock.Unlock()
+
+			if wait != nil {
+				<-wait
+			}
+		}
+
+		handler.ServeHTTP(res, req)
+	})
+}
+
+func controlExit(res http.ResponseWriter, req *http.Request) {
+	code, err := strconv.Atoi(req.URL.Query().Get("code"))
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(res, "invalid exit code: %s\n", err.Error())
+		return
+	}
+
+	fmt.Fprintf(res, "exiting with %d\n", code)
+	if flusher, ok := res.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	go func() {
+		// give the response a chance to make it out
+		time.Sleep(100 * time.Millisecond)
+		os.Exit(code)
+	}()
+}
+
+func controlHealth(res http.ResponseWriter, req *http.Request) {
+	var err error
+	switch status := req.URL.Query().Get("status"); status {
+	case "fail":
+		err = stopHealthListener()
+	case "pass", "ok":
+		err = startHealthListener()
+	default:
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(res, "unknown health status %q, must be pass or fail\n", status)
+		return
+	}
+
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(res, "failed to change health: %s\n", err.Error())
+		return
+	}
+
+	fmt.Fprint(res, "ok\n")
+}
+
+func controlIgnoreSigterm(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Query().Get("enabled") == "false" {
+		signal.Reset(syscall.SIGTERM)
+		fmt.Fprint(res, "handling SIGTERM\n")
+		return
+	}
+
+	signal.Ignore(syscall.SIGTERM)
+	fmt.Fprint(res, "ignoring SIGTERM\n")
+}
+
+func controlHang(res http.ResponseWriter, req *http.Request) {
+	controlLock.Lock()
+	defer controlLock.Unlock()
+
+	if req.URL.Query().Get("enabled") == "false" {
+		if hung != nil {
+			close(hung)
+			hung = nil
+		}
+		fmt.Fprint(res, "serving\n")
+		return
+	}
+
+	if hung == nil {
+		hung = make(chan struct{})
+	}
+	fmt.Fprint(res, "hanging\n")
+}
+
+func controlAllocate(res http.ResponseWriter, req *http.Request) {
+	mb, err := strconv.Atoi(req.URL.Query().Get("mb"))
+	if err != nil || mb < 0 {
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(res, "invalid number of megabytes: %q\n", req.URL.Query().Get("mb"))
+		return
+	}
+
+	allocation := make([]uint8, mb*1024*1024)
+	// touch every page so that the memory is actually committed
+	for i := 0; i < len(allocation); i += 4096 {
+		allocation[i] = 1
+	}
+
+	controlLock.Lock()
+	allocations = append(allocations, allocation)
+	total := 0
+	for _, a := range allocations {
+		total += len(a)
+	}
+	controlLock.Unlock()
+
+	fmt.Fprintf(res, "%d\n", total/(1024*1024))
+}
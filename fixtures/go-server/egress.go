@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const egressTimeout = 5 * time.Second
+
+type egressResult struct {
+	Target      string   `json:"target"`
+	Proto       string   `json:"proto"`
+	ResolvedIPs []string `json:"resolved_ips"`
+	Connected   bool     `json:"connected"`
+	ErrorClass  string   `json:"error_class,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	LatencyMs   float64  `json:"latency_ms"`
+}
+
+// egress probes target from inside the container without shelling out, and
+// reports where the attempt failed: resolving, connecting or getting an
+// answer back.
+func egress(res http.ResponseWriter, req *http.Request) {
+	target := req.URL.Query().Get("target")
+	proto := req.URL.Query().Get("proto")
+	if proto == "" {
+		proto = "tcp"
+	}
+
+	if target == "" {
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(res, "missing target\n")
+		return
+	}
+
+	result := egressResult{Target: target, Proto: proto, ResolvedIPs: []string{}}
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		if proto != "icmp" {
+			res.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(res, "invalid target: %s\n", err.Error())
+			return
+		}
+		host = target
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), egressTimeout)
+	defer cancel()
+
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		result.setError("dns", err)
+		result.LatencyMs = millisecondsSince(start)
+		writeEgressResult(res, result)
+		return
+	}
+	for _, ip := range ips {
+		result.ResolvedIPs = append(result.ResolvedIPs, ip.IP.String())
+	}
+
+	// security groups only describe IPv4 destinations, so probe the same
+	// address whatever the protocol
+	ip := firstIPv4(ips)
+	if ip == nil {
+		result.setError("dns", errors.New("no IPv4 address"))
+		result.LatencyMs = millisecondsSince(start)
+		writeEgressResult(res, result)
+		return
+	}
+
+	switch proto {
+	case "tcp":
+		err = probeTCP(net.JoinHostPort(ip.String(), port))
+	case "udp":
+		err = probeUDP(net.JoinHostPort(ip.String(), port))
+	case "icmp":
+		err = probeICMP(ip)
+	default:
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(res, "unknown proto %q, must be tcp, udp or icmp\n", proto)
+		return
+	}
+
+	result.LatencyMs = millisecondsSince(start)
+	if err != nil {
+		result.setError(classifyEgressError(err), err)
+	} else {
+		result.Connected = true
+	}
+
+	writeEgressResult(res, result)
+}
+
+func probeTCP(address string) error {
+	conn, err := net.DialTimeout("tcp", address, egressTimeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeUDP sends a datagram and waits briefly for an answer. A closed port
+// normally surfaces as "connection refused" on the read; silence is treated
+// as success since most UDP services do not answer arbitrary payloads.
+func probeUDP(address string) error {
+	conn, err := net.DialTimeout("udp", address, egressTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("egress-probe"))
+	if err != nil {
+		return err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 512))
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return nil
+	}
+	return err
+}
+
+func firstIPv4(ips []net.IPAddr) net.IP {
+	for _, candidate := range ips {
+		if candidate.IP.To4() != nil {
+			return candidate.IP
+		}
+	}
+	return nil
+}
+
+// probeICMP sends a single echo request to ip. It needs a raw socket, so it
+// fails with a permission error in unprivileged containers.
+func probeICMP(ip net.IP) error {
+	conn, err := net.DialTimeout("ip4:icmp", ip.String(), egressTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	id := os.Getpid() & 0xffff
+	request := []byte{8, 0, 0, 0, byte(id >> 8), byte(id), 0, 1}
+	request = append(request, []byte("egress-probe")...)
+	checksum := icmpChecksum(request)
+	request[2] = byte(checksum >> 8)
+	request[3] = byte(checksum)
+
+	_, err = conn.Write(request)
+	if err != nil {
+		return err
+	}
+
+	conn.SetReadDeadline(time.Now().Add(egressTimeout))
+	reply := make([]byte, 1500)
+	for {
+		n, err := conn.Read(reply)
+		if err != nil {
+			return err
+		}
+
+		message := stripIPv4Header(reply[:n])
+		if len(message) < 8 {
+			continue
+		}
+
+		// the raw socket sees every ICMP message for the host, so skip the
+		// ones that are not about this probe's echo request
+		switch message[0] {
+		case 0:
+			if icmpID(message) == id {
+				return nil
+			}
+		case 3:
+			// the payload is the start of the datagram that was undeliverable
+			original := stripIPv4Header(message[8:])
+			if len(original) >= 8 && original[0] == 8 && icmpID(original) == id {
+				return errors.New("destination unreachable")
+			}
+		}
+	}
+}
+
+// stripIPv4Header drops the IP header that raw ip4 sockets may include.
+func stripIPv4Header(packet []byte) []byte {
+	if len(packet) > 20 && packet[0]>>4 == 4 {
+		headerLength := int(packet[0]&0x0f) * 4
+		if headerLength <= len(packet) {
+			return packet[headerLength:]
+		}
+	}
+	return packet
+}
+
+func icmpID(message []byte) int {
+	return int(message[4])<<8 | int(message[5])
+}
+
+func icmpChecksum(data []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
+
+func classifyEgressError(err error) string {
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return "timeout"
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH):
+		return "unreachable"
+	case errors.Is(err, syscall.EPERM), errors.Is(err, syscall.EACCES):
+		return "permission"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "reset"
+	case strings.Contains(err.Error(), "unreachable"):
+		return "unreachable"
+	}
+	return "other"
+}
+
+func (r *egressResult) setError(class string, err error) {
+	r.ErrorClass = class
+	r.Error = err.Error()
+}
+
+func millisecondsSince(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
+
+func writeEgressResult(res http.ResponseWriter, result egressResult) {
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(result)
+}
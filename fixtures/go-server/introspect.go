@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type introspection struct {
+	Memory        memoryInfo        `json:"memory"`
+	CPU           cpuInfo           `json:"cpu"`
+	Disk          diskInfo          `json:"disk"`
+	User          userInfo          `json:"user"`
+	Mounts        []mountInfo       `json:"mounts"`
+	Interfaces    []interfaceInfo   `json:"interfaces"`
+	InstanceEnv   map[string]string `json:"instance_env"`
+	CgroupVersion int               `json:"cgroup_version"`
+}
+
+type memoryInfo struct {
+	// LimitBytes is -1 when the cgroup has no limit
+	LimitBytes int64 `json:"limit_bytes"`
+	UsageBytes int64 `json:"usage_bytes"`
+}
+
+type cpuInfo struct {
+	// Weight is cpu.weight on cgroup v2 hosts and cpu.shares on cgroup v1
+	Weight int64 `json:"weight"`
+}
+
+type diskInfo struct {
+	Path        string `json:"path"`
+	TotalBytes  uint64 `json:"total_bytes"`
+	FreeBytes   uint64 `json:"free_bytes"`
+	AvailBytes  uint64 `json:"avail_bytes"`
+	TotalInodes uint64 `json:"total_inodes"`
+	FreeInodes  uint64 `json:"free_inodes"`
+}
+
+type userInfo struct {
+	UID          int               `json:"uid"`
+	GID          int               `json:"gid"`
+	Groups       []int             `json:"groups"`
+	Capabilities map[string]string `json:"capabilities"`
+}
+
+type mountInfo struct {
+	Device     string   `json:"device"`
+	MountPoint string   `json:"mount_point"`
+	FSType     string   `json:"fs_type"`
+	Options    []string `json:"options"`
+}
+
+type interfaceInfo struct {
+	Name      string   `json:"name"`
+	MTU       int      `json:"mtu"`
+	Flags     string   `json:"flags"`
+	Addresses []string `json:"addresses"`
+}
+
+// introspect reports the limits and identity of the container as seen from
+// inside it. Values that cannot be read are left at their zero value.
+func introspect(res http.ResponseWriter, req *http.Request) {
+	diskPath := req.URL.Query().Get("path")
+	if diskPath == "" {
+		diskPath = "/"
+	}
+
+	result := introspection{
+		Disk:        readDisk(diskPath),
+		User:        readUser(),
+		Mounts:      readMounts(),
+		Interfaces:  readInterfaces(),
+		InstanceEnv: map[string]string{},
+	}
+	result.CgroupVersion, result.Memory, result.CPU = readCgroup()
+
+	for _, e := range os.Environ() {
+		if strings.HasPrefix(e, "CF_INSTANCE_") {
+			parts := strings.SplitN(e, "=", 2)
+			result.InstanceEnv[parts[0]] = parts[1]
+		}
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(result)
+}
+
+func readCgroup() (int, memoryInfo, cpuInfo) {
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err == nil {
+		memory := memoryInfo{
+			LimitBytes: readCgroupInt("/sys/fs/cgroup/memory.max"),
+			UsageBytes: readCgroupInt("/sys/fs/cgroup/memory.current"),
+		}
+		cpu := cpuInfo{Weight: readCgroupInt("/sys/fs/cgroup/cpu.weight")}
+		return 2, memory, cpu
+	}
+
+	memory := memoryInfo{
+		LimitBytes: readCgroupInt("/sys/fs/cgroup/memory/memory.limit_in_bytes"),
+		UsageBytes: readCgroupInt("/sys/fs/cgroup/memory/memory.usage_in_bytes"),
+	}
+	cpu := cpuInfo{Weight: readCgroupInt("/sys/fs/cgroup/cpu/cpu.shares")}
+	return 1, memory, cpu
+}
+
+func readCgroupInt(path string) int64 {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return -1
+	}
+
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return i
+}
+
+func readUser() userInfo {
+	info := userInfo{
+		UID:          os.Getuid(),
+		GID:          os.Getgid(),
+		Capabilities: map[string]string{},
+	}
+	info.Groups, _ = os.Getgroups()
+
+	file, err := os.Open("/proc/self/status")
+	if err != nil {
+		return info
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.HasPrefix(fields[0], "Cap") {
+			info.Capabilities[strings.TrimSuffix(fields[0], ":")] = fields[1]
+		}
+	}
+	return info
+}
+
+func readMounts() []mountInfo {
+	mounts := []mountInfo{}
+
+	file, err := os.Open("/proc/self/mounts")
+	if err != nil {
+		return mounts
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		mounts = append(mounts, mountInfo{
+			Device:     fields[0],
+			MountPoint: fields[1],
+			FSType:     fields[2],
+			Options:    strings.Split(fields[3], ","),
+		})
+	}
+	return mounts
+}
+
+func readInterfaces() []interfaceInfo {
+	interfaces := []interfaceInfo{}
+
+	netInterfaces, err := net.Interfaces()
+	if err != nil {
+		return interfaces
+	}
+
+	for _, netInterface := range netInterfaces {
+		info := interfaceInfo{
+			Name:      netInterface.Name,
+			MTU:       netInterface.MTU,
+			Flags:     netInterface.Flags.String(),
+			Addresses: []string{},
+		}
+
+		addrs, err := netInterface.Addrs()
+		if err == nil {
+			for _, addr := range addrs {
+				info.Addresses = append(info.Addresses, addr.String())
+			}
+		}
+
+		interfaces = append(interfaces, info)
+	}
+	return interfaces
+}
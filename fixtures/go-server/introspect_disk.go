@@ -0,0 +1,21 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import "syscall"
+
+func readDisk(path string) diskInfo {
+	var stat syscall.Statfs_t
+	info := diskInfo{Path: path}
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return info
+	}
+
+	info.TotalBytes = stat.Blocks * uint64(stat.Bsize)
+	info.FreeBytes = stat.Bfree * uint64(stat.Bsize)
+	info.AvailBytes = stat.Bavail * uint64(stat.Bsize)
+	info.TotalInodes = stat.Files
+	info.FreeInodes = stat.Ffree
+	return info
+}
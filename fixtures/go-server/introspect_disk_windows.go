@@ -0,0 +1,6 @@
+package main
+
+// readDisk is not supported on windows; only the path is reported.
+func readDisk(path string) diskInfo {
+	return diskInfo{Path: path}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	grpcHealthCheckPath = "/grpc.health.v1.Health/Check"
+
+	// grpc.health.v1.HealthCheckResponse.ServingStatus
+	grpcServing    = 1
+	grpcNotServing = 2
+)
+
+func registerProtocolHandlers() {
+	http.HandleFunc("/protocol", protocol)
+	http.Handle("/ws/echo", websocket.Handler(websocketEcho))
+	http.HandleFunc(grpcHealthCheckPath, grpcHealthCheck)
+}
+
+// withH2C lets plaintext listeners speak HTTP/2 with prior knowledge when
+// H2C is set. TLS listeners negotiate h2 over ALPN without it.
+func withH2C(handler http.Handler) http.Handler {
+	if os.Getenv("H2C") == "" {
+		return handler
+	}
+	return h2c.NewHandler(handler, &http2.Server{})
+}
+
+// startEchoListeners echoes raw bytes back on every port in TCP_ECHO_PORT and
+// every datagram on every port in UDP_ECHO_PORT. Both take a space separated
+// list, like PORT.
+func startEchoListeners(errCh chan<- error) error {
+	for _, port := range strings.Fields(os.Getenv("TCP_ECHO_PORT")) {
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					errCh <- err
+					return
+				}
+
+				go func() {
+					defer conn.Close()
+					io.Copy(conn, conn)
+				}()
+			}
+		}()
+	}
+
+	for _, port := range strings.Fields(os.Getenv("UDP_ECHO_PORT")) {
+		conn, err := net.ListenPacket("udp", ":"+port)
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			buf := make([]byte, 65535)
+			for {
+				n, addr, err := conn.ReadFrom(buf)
+				if err != nil {
+					errCh <- err
+					return
+				}
+				conn.WriteTo(buf[:n], addr)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// protocol reports the protocol the request arrived over, so that specs can
+// tell whether a proxy in between downgraded it.
+func protocol(res http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(res, req.Proto)
+}
+
+func websocketEcho(ws *websocket.Conn) {
+	io.Copy(ws, ws)
+}
+
+// grpcHealthCheck implements grpc.health.v1.Health/Check without pulling in
+// grpc. The request is ignored, and the reported status follows
+// /control/health whenever a HEALTH_PORT is configured.
+func grpcHealthCheck(res http.ResponseWriter, req *http.Request) {
+	if req.ProtoMajor != 2 || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/grpc") {
+		res.WriteHeader(http.StatusUnsupportedMediaType)
+		fmt.Fprint(res, "grpc requires HTTP/2 and content-type application/grpc\n")
+		return
+	}
+
+	ioutil.ReadAll(req.Body)
+
+	status := grpcServing
+	controlLock.Lock()
+	if os.Getenv("HEALTH_PORT") != "" && healthListener == nil {
+		status = grpcNotServing
+	}
+	controlLock.Unlock()
+
+	// a length-prefixed message holding field 1 (status) as a varint
+	message := []byte{0x08, byte(status)}
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	frame = append(frame, message...)
+
+	res.Header().Set("Content-Type", "application/grpc")
+	res.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	res.WriteHeader(http.StatusOK)
+	res.Write(frame)
+
+	res.Header().Set("Grpc-Status", "0")
+	res.Header().Set("Grpc-Message", "")
+}
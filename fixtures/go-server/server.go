@@ -21,16 +21,26 @@ func main() {
 	http.HandleFunc("/env", env)
 	http.HandleFunc("/write", write)
 	http.HandleFunc("/curl", curl)
+	http.HandleFunc("/egress", egress)
 	http.HandleFunc("/yo", yo)
 	http.HandleFunc("/privileged", privileged)
 	http.HandleFunc("/cf-instance-cert", cfInstanceCert)
 	http.HandleFunc("/cf-instance-key", cfInstanceKey)
 	http.HandleFunc("/cat", catFile)
+	http.HandleFunc("/introspect", introspect)
+	registerControlHandlers()
+	registerProtocolHandlers()
+	registerVolumeHandlers()
 
 	if memoryAllocated != nil {
 		someGarbage = make([]uint8, *memoryAllocated*1024*1024)
 	}
 
+	err := startHealthListener()
+	if err != nil {
+		panic(err)
+	}
+
 	fmt.Println("listening...")
 
 	ports := os.Getenv("PORT")
@@ -40,6 +50,11 @@ func main() {
 
 	errCh := make(chan error)
 
+	err = startEchoListeners(errCh)
+	if err != nil {
+		panic(err)
+	}
+
 	for _, port := range portArray {
 		addr := ""
 		if os.Getenv("SKIP_LOCALHOST_LISTEN") != "" {
@@ -48,7 +63,7 @@ func main() {
 		addr += ":" + port
 		go func(addr string) {
 			println(addr)
-			errCh <- http.ListenAndServe(addr, nil)
+			errCh <- http.ListenAndServe(addr, withH2C(hangable(http.DefaultServeMux)))
 		}(addr)
 	}
 
@@ -56,11 +71,11 @@ func main() {
 		go func() {
 			instanceCertPath := os.Getenv("CF_INSTANCE_CERT")
 			instanceKeyPath := os.Getenv("CF_INSTANCE_KEY")
-			errCh <- http.ListenAndServeTLS(":"+httpsPort, instanceCertPath, instanceKeyPath, nil)
+			errCh <- http.ListenAndServeTLS(":"+httpsPort, instanceCertPath, instanceKeyPath, hangable(http.DefaultServeMux))
 		}()
 	}
 
-	err := <-errCh
+	err = <-errCh
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type volumeFile struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"is_dir"`
+}
+
+// registerVolumeHandlers serves files on the volume mounted at
+// MOUNT_POINT_DIR. Every endpoint takes a path relative to the mount point,
+// which cannot be used to step outside of it.
+func registerVolumeHandlers() {
+	http.HandleFunc("/volume/read", volumeRead)
+	http.HandleFunc("/volume/write", volumeWrite)
+	http.HandleFunc("/volume/lock", volumeLock)
+	http.HandleFunc("/volume/list", volumeList)
+}
+
+func volumePath(req *http.Request) string {
+	return filepath.Join(os.Getenv("MOUNT_POINT_DIR"), filepath.Clean("/"+req.URL.Query().Get("path")))
+}
+
+func volumeError(res http.ResponseWriter, err error) {
+	switch {
+	case os.IsNotExist(err):
+		res.WriteHeader(http.StatusNotFound)
+	case os.IsPermission(err):
+		res.WriteHeader(http.StatusForbidden)
+	default:
+		res.WriteHeader(http.StatusInternalServerError)
+	}
+	fmt.Fprintf(res, "%s\n", err.Error())
+}
+
+func volumeRead(res http.ResponseWriter, req *http.Request) {
+	data, err := ioutil.ReadFile(volumePath(req))
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+
+	res.Write(data)
+}
+
+// volumeWrite writes the request body to path, creating its parent
+// directories, or appends to it when append=true.
+func volumeWrite(res http.ResponseWriter, req *http.Request) {
+	path := volumePath(req)
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(res, "failed to read body: %s\n", err.Error())
+		return
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if req.URL.Query().Get("append") == "true" {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+
+	file, err := os.OpenFile(path, flags, 0666)
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+	defer file.Close()
+
+	_, err = file.Write(data)
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+
+	fmt.Fprintf(res, "%d\n", len(data))
+}
+
+// volumeLock appends the request body to path under an exclusive lock on
+// the file. It reads the file, waits for hold and writes it back with the
+// body appended, so writers that are not kept apart by the lock lose each
+// other's lines.
+func volumeLock(res http.ResponseWriter, req *http.Request) {
+	path := volumePath(req)
+
+	// hold defaults to 0 when missing or invalid
+	hold, _ := time.ParseDuration(req.URL.Query().Get("hold"))
+
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(res, "failed to read body: %s\n", err.Error())
+		return
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+	defer file.Close()
+
+	err = lockFile(file)
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+	defer unlockFile(file)
+
+	existing, err := ioutil.ReadAll(file)
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+
+	time.Sleep(hold)
+
+	_, err = file.WriteAt(append(existing, data...), 0)
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+
+	fmt.Fprintf(res, "%d\n", len(existing)+len(data))
+}
+
+func volumeList(res http.ResponseWriter, req *http.Request) {
+	infos, err := ioutil.ReadDir(volumePath(req))
+	if err != nil {
+		volumeError(res, err)
+		return
+	}
+
+	files := []volumeFile{}
+	for _, info := range infos {
+		files = append(files, volumeFile{Name: info.Name(), Size: info.Size(), IsDir: info.IsDir()})
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(files)
+}
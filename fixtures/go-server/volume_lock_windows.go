@@ -0,0 +1,15 @@
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// locking is not supported on windows; /volume/lock always fails.
+func lockFile(file *os.File) error {
+	return errors.New("file locking is not supported on windows")
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,30 @@
+package fixtures
+
+import (
+	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	. "github.com/onsi/gomega"
+)
+
+type Format string
+
+const (
+	Zip Format = "zip"
+	TGZ Format = "tgz"
+	// Droplet is a tgz laid out like a staged droplet; see DropletBuilder.
+	Droplet Format = "droplet"
+)
+
+// Package builds the named app (see Build) and writes it to archivePath in
+// format.
+func Package(name string, format Format, archivePath string) {
+	switch format {
+	case Zip:
+		archive_helper.CreateZipArchive(archivePath, Build(name))
+	case TGZ:
+		archive_helper.CreateTarGZArchive(archivePath, Build(name))
+	case Droplet:
+		NewDroplet().WithApp(name).WriteTo(archivePath)
+	default:
+		Expect(format).To(BeElementOf(Zip, TGZ, Droplet), "unknown fixture archive format")
+	}
+}
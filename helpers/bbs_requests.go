@@ -128,6 +128,50 @@ func CrashingLRPCreateRequest(addresses world.ComponentAddresses, processGuid st
 	return lrpCreateRequest(addresses, processGuid, defaultLogGuid, defaultPreloadedRootFS, 1, nil, action, defaultMonitor)
 }
 
+// ControllableLRPCreateRequest runs go-server with a separate HEALTH_PORT
+// that the monitor checks, so that the instance's health can be flipped with
+// GoServerClient.SetHealthy.
+func ControllableLRPCreateRequest(addresses world.ComponentAddresses, processGuid string) *models.DesiredLRP {
+	action := models.WrapAction(&models.RunAction{
+		User: "vcap",
+		Path: "/tmp/diego/go-server",
+		Env: []*models.EnvironmentVariable{
+			{"PORT", "8080"},
+			{"HEALTH_PORT", "8081"},
+		},
+	})
+
+	monitor := models.WrapAction(&models.RunAction{
+		User: "vcap",
+		Path: "nc",
+		Args: []string{"-z", "localhost", "8081"},
+	})
+
+	return lrpCreateRequest(addresses, processGuid, defaultLogGuid, defaultPreloadedRootFS, 1, nil, action, monitor)
+}
+
+// ProtocolsLRPCreateRequest runs go-server speaking h2c and gRPC on 8080,
+// echoing raw TCP on 9000 and UDP datagrams on 9001, so that specs can check
+// that routers and proxies pass each protocol through. See
+// GoServerClient.Protocol, TCPEcho and UDPEcho. Port mappings are TCP only,
+// so 9001 is reached on the instance address rather than a host port.
+func ProtocolsLRPCreateRequest(addresses world.ComponentAddresses, processGuid string) *models.DesiredLRP {
+	action := models.WrapAction(&models.RunAction{
+		User: "vcap",
+		Path: "/tmp/diego/go-server",
+		Env: []*models.EnvironmentVariable{
+			{"PORT", "8080"},
+			{"H2C", "true"},
+			{"TCP_ECHO_PORT", "9000"},
+			{"UDP_ECHO_PORT", "9001"},
+		},
+	})
+
+	lrp := lrpCreateRequest(addresses, processGuid, defaultLogGuid, defaultPreloadedRootFS, 1, nil, action, defaultMonitor)
+	lrp.Ports = []uint32{8080, 9000}
+	return lrp
+}
+
 func LightweightLRPCreateRequest(addresses world.ComponentAddresses, processGuid string) *models.DesiredLRP {
 	action := models.WrapAction(&models.RunAction{
 		User: "vcap",
@@ -0,0 +1,383 @@
+package buildcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DirEnv names the variable holding the cache directory. Setting it to "off"
+// disables caching, so every Build compiles into a fresh directory.
+const DirEnv = "INIGO_BUILD_CACHE_DIR"
+
+// Cache keeps built binaries and anything derived from them (e.g. lifecycle
+// tarballs) in a directory keyed by the hash of everything that went into
+// them: the source of the package and all its non-standard dependencies, the
+// build flags, and the toolchain and environment (GOOS, GOARCH,
+// CGO_ENABLED, ...). Unchanged inputs are never rebuilt, across suites and
+// across runs.
+//
+// Paths returned by a Cache are shared and must not be modified or moved;
+// use Link to place them somewhere else.
+type Cache struct {
+	dir      string
+	disabled bool
+}
+
+// New returns a cache in dir, creating it if needed.
+func New(dir string) (*Cache, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Default returns the cache in $INIGO_BUILD_CACHE_DIR, or in an inigo
+// directory under the user's cache directory when it is not set.
+func Default() (*Cache, error) {
+	dir := os.Getenv(DirEnv)
+	if dir == "off" {
+		tmpDir, err := ioutil.TempDir("", "inigo-builds")
+		if err != nil {
+			return nil, err
+		}
+		return &Cache{dir: tmpDir, disabled: true}, nil
+	}
+
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("no build cache directory: set $%s (%s)", DirEnv, err.Error())
+		}
+		dir = filepath.Join(userCacheDir, "inigo", "builds")
+	}
+
+	return New(dir)
+}
+
+var (
+	sharedCache     *Cache
+	sharedCacheErr  error
+	sharedCacheOnce sync.Once
+)
+
+// Shared returns the Default cache, set up once per process.
+func Shared() (*Cache, error) {
+	sharedCacheOnce.Do(func() {
+		sharedCache, sharedCacheErr = Default()
+	})
+	return sharedCache, sharedCacheErr
+}
+
+// Build builds packagePath with the Shared cache.
+func Build(packagePath string, args ...string) (string, error) {
+	return BuildWithEnvironment(packagePath, nil, args...)
+}
+
+// BuildWithEnvironment builds packagePath with the Shared cache.
+func BuildWithEnvironment(packagePath string, env []string, args ...string) (string, error) {
+	cache, err := Shared()
+	if err != nil {
+		return "", err
+	}
+	return cache.BuildWithEnvironment(packagePath, env, args...)
+}
+
+// Dir returns the directory the cache stores its entries in.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Build behaves like gexec.Build, but returns the cached binary when the
+// package, its dependencies and the build configuration are unchanged.
+func (c *Cache) Build(packagePath string, args ...string) (string, error) {
+	return c.BuildWithEnvironment(packagePath, nil, args...)
+}
+
+// BuildWithEnvironment behaves like gexec.BuildWithEnvironment. The extra
+// environment is part of the cache key.
+func (c *Cache) BuildWithEnvironment(packagePath string, env []string, args ...string) (string, error) {
+	env = append(os.Environ(), env...)
+
+	key, err := buildKey(packagePath, env, args)
+	if err != nil {
+		return "", err
+	}
+
+	goos, err := goCommand(env, "env", "GOOS")
+	if err != nil {
+		return "", err
+	}
+
+	return c.Derive(key, binaryName(packagePath, strings.TrimSpace(goos)), func(outputPath string) error {
+		cmdArgs := append([]string{"build"}, args...)
+		cmdArgs = append(cmdArgs, "-o", outputPath, packagePath)
+
+		cmd := exec.Command("go", cmdArgs...)
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to build %s: %s\n%s", packagePath, err.Error(), output)
+		}
+		return nil
+	})
+}
+
+// Derive returns the cached file called name for key, calling create to
+// produce it at the given path when it is not cached yet. Hashing the paths
+// of other cache entries gives a key that changes whenever they do.
+//
+// Entries appear atomically, so concurrent processes (e.g. parallel ginkgo
+// nodes) never see a partially written file.
+func (c *Cache) Derive(key, name string, create func(path string) error) (string, error) {
+	entryPath := filepath.Join(c.dir, key, name)
+	if !c.disabled {
+		if _, err := os.Stat(entryPath); err == nil {
+			return entryPath, nil
+		}
+	}
+
+	err := os.MkdirAll(filepath.Dir(entryPath), 0755)
+	if err != nil {
+		return "", err
+	}
+
+	stagingDir, err := ioutil.TempDir(c.dir, "staging")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(stagingDir)
+
+	stagingPath := filepath.Join(stagingDir, name)
+	err = create(stagingPath)
+	if err != nil {
+		return "", err
+	}
+
+	err = os.Rename(stagingPath, entryPath)
+	if err != nil {
+		return "", err
+	}
+
+	return entryPath, nil
+}
+
+// Key hashes parts into a cache key for Derive.
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(hash, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Link places the cached file at cachedPath at destinationPath, hard linking
+// when possible and copying otherwise.
+func Link(cachedPath, destinationPath string) error {
+	err := os.Link(cachedPath, destinationPath)
+	if err == nil {
+		return nil
+	}
+
+	source, err := os.Open(cachedPath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	destination, err := os.OpenFile(destinationPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(destination, source)
+	if err != nil {
+		destination.Close()
+		return err
+	}
+	return destination.Close()
+}
+
+// keyedEnv are the variables that change what go build produces.
+var keyedEnv = []string{
+	"GOOS", "GOARCH", "GOARM", "GO386", "GOAMD64", "GOFLAGS", "GOEXPERIMENT",
+	"CGO_ENABLED", "CGO_CFLAGS", "CGO_LDFLAGS", "CC", "CXX",
+	"GO111MODULE", "GOPATH", "GOROOT",
+}
+
+func buildKey(packagePath string, env, args []string) (string, error) {
+	args = normalizeFlags(args)
+
+	goVersion, err := goCommand(env, "version")
+	if err != nil {
+		return "", err
+	}
+
+	goEnv, err := goCommand(env, append([]string{"env"}, keyedEnv...)...)
+	if err != nil {
+		return "", err
+	}
+
+	sourceHash, err := hashSources(packagePath, env, args)
+	if err != nil {
+		return "", err
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	return Key(
+		packagePath,
+		cwd,
+		strings.Join(args, "\x00"),
+		goVersion,
+		goEnv,
+		sourceHash,
+	), nil
+}
+
+// hashSources hashes the contents of every file that goes into packagePath
+// and its dependencies, leaving out the standard library (which the go
+// version covers).
+func hashSources(packagePath string, env, args []string) (string, error) {
+	const format = `{{if not .Standard}}{{.ImportPath}}|{{.Dir}}|` +
+		`{{join .GoFiles ","}},{{join .CgoFiles ","}},{{join .CFiles ","}},{{join .CXXFiles ","}},` +
+		`{{join .HFiles ","}},{{join .SFiles ","}},{{join .SysoFiles ","}},{{join .EmbedFiles ","}}{{end}}`
+
+	listArgs := append([]string{"list", "-deps", "-f", format}, listFlags(args)...)
+	listArgs = append(listArgs, packagePath)
+
+	output, err := goCommand(env, listArgs...)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(output, "\n")
+	sort.Strings(lines)
+
+	hash := sha256.New()
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, "|", 3)
+		if len(fields) != 3 {
+			return "", fmt.Errorf("unexpected go list output: %q", line)
+		}
+		fmt.Fprintf(hash, "%s\n", fields[0])
+
+		for _, file := range strings.Split(fields[2], ",") {
+			if file == "" {
+				continue
+			}
+
+			err := hashFile(hash, fields[0], fields[1], file)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashFile hashes name, relative to dir, which is an embedded file's path
+// or a source file's base name.
+func hashFile(hash io.Writer, importPath, dir, name string) error {
+	file, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fmt.Fprintf(hash, "%s/%s\n", importPath, filepath.ToSlash(name))
+	_, err = io.Copy(hash, file)
+	return err
+}
+
+// valueFlags are the go build flags that take a value, and so can be given
+// either as -flag value or as -flag=value.
+var valueFlags = map[string]bool{
+	"asmflags": true, "buildmode": true, "compiler": true, "gccgoflags": true,
+	"gcflags": true, "installsuffix": true, "ldflags": true, "mod": true,
+	"modfile": true, "overlay": true, "p": true, "pkgdir": true, "tags": true,
+	"toolexec": true,
+}
+
+// normalizeFlags rewrites every flag in args as -flag or -flag=value, so
+// that the ways of spelling the same build give the same key.
+func normalizeFlags(args []string) []string {
+	normalized := []string{}
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			normalized = append(normalized, args[i])
+			continue
+		}
+
+		name := strings.TrimLeft(args[i], "-")
+		if valueFlags[name] && i+1 < len(args) {
+			normalized = append(normalized, "-"+name+"="+args[i+1])
+			i++
+			continue
+		}
+		normalized = append(normalized, "-"+name)
+	}
+	return normalized
+}
+
+// listFlags keeps the build flags that change which files go list selects.
+// args must be normalized.
+func listFlags(args []string) []string {
+	flags := []string{}
+	for _, arg := range args {
+		switch {
+		case arg == "-race", arg == "-msan", arg == "-asan":
+			flags = append(flags, arg)
+		case strings.HasPrefix(arg, "-tags="), strings.HasPrefix(arg, "-mod="):
+			flags = append(flags, arg)
+		}
+	}
+	return flags
+}
+
+func goCommand(env []string, args ...string) (string, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Env = env
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("go %s failed: %s\n%s", args[0], err.Error(), exitErr.Stderr)
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// binaryName names the binary for packagePath as go build would when
+// targeting goos.
+func binaryName(packagePath, goos string) string {
+	name := path.Base(filepath.ToSlash(packagePath))
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
@@ -0,0 +1,13 @@
+package buildcache_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBuildcache(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Buildcache Suite")
+}
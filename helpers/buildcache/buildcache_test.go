@@ -0,0 +1,210 @@
+package buildcache_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"code.cloudfoundry.org/inigo/helpers/buildcache"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cache", func() {
+	var (
+		cacheDir, moduleDir string
+		cache               *buildcache.Cache
+		originalCwd         string
+	)
+
+	writeMain := func(greeting string) {
+		err := ioutil.WriteFile(filepath.Join(moduleDir, "main.go"), []byte(`package main
+
+func main() { println("`+greeting+`") }
+`), 0644)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "build-cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		moduleDir, err = ioutil.TempDir("", "build-cache-module")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module example.com/greeter\n"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		writeMain("hello")
+
+		originalCwd, err = os.Getwd()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Chdir(moduleDir)).To(Succeed())
+
+		cache, err = buildcache.New(cacheDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(originalCwd)).To(Succeed())
+		Expect(os.RemoveAll(cacheDir)).To(Succeed())
+		Expect(os.RemoveAll(moduleDir)).To(Succeed())
+	})
+
+	Describe("Build", func() {
+		It("builds a runnable binary into the cache", func() {
+			path, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(HavePrefix(cacheDir))
+
+			output, err := exec.Command(path).CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(output)).To(Equal("hello\n"))
+		})
+
+		It("reuses the binary when nothing changed", func() {
+			path, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			rebuiltPath, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rebuiltPath).To(Equal(path))
+
+			rebuiltInfo, err := os.Stat(rebuiltPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rebuiltInfo.ModTime()).To(Equal(info.ModTime()))
+		})
+
+		It("rebuilds when the source changes", func() {
+			path, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+
+			writeMain("goodbye")
+
+			rebuiltPath, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rebuiltPath).NotTo(Equal(path))
+
+			output, err := exec.Command(rebuiltPath).CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(output)).To(Equal("goodbye\n"))
+		})
+
+		It("keys on the build flags and environment", func() {
+			path, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+
+			trimmedPath, err := cache.Build("example.com/greeter", "-trimpath")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trimmedPath).NotTo(Equal(path))
+
+			noCgoPath, err := cache.BuildWithEnvironment("example.com/greeter", []string{"CGO_ENABLED=0"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(noCgoPath).NotTo(Equal(path))
+		})
+
+		It("rebuilds when an embedded file changes", func() {
+			err := ioutil.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module example.com/greeter\n\ngo 1.16\n"), 0644)
+			Expect(err).NotTo(HaveOccurred())
+			err = ioutil.WriteFile(filepath.Join(moduleDir, "main.go"), []byte(`package main
+
+import _ "embed"
+
+//go:embed assets/greeting.txt
+var greeting string
+
+func main() { print(greeting) }
+`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Mkdir(filepath.Join(moduleDir, "assets"), 0755)).To(Succeed())
+			writeGreeting := func(greeting string) {
+				err := ioutil.WriteFile(filepath.Join(moduleDir, "assets", "greeting.txt"), []byte(greeting), 0644)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			writeGreeting("hello\n")
+
+			path, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+
+			writeGreeting("goodbye\n")
+
+			rebuiltPath, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rebuiltPath).NotTo(Equal(path))
+
+			output, err := exec.Command(rebuiltPath).CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(output)).To(Equal("goodbye\n"))
+		})
+
+		It("names the binary for the target GOOS", func() {
+			windowsPath, err := cache.BuildWithEnvironment("example.com/greeter", []string{"GOOS=windows", "GOARCH=amd64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(windowsPath).To(HaveSuffix("greeter.exe"))
+
+			linuxPath, err := cache.BuildWithEnvironment("example.com/greeter", []string{"GOOS=linux", "GOARCH=amd64"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Base(linuxPath)).To(Equal("greeter"))
+		})
+
+		It("accepts -mod in either form, as the same build", func() {
+			path, err := cache.Build("example.com/greeter", "-mod", "mod")
+			Expect(err).NotTo(HaveOccurred())
+
+			otherPath, err := cache.Build("example.com/greeter", "-mod=mod")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(otherPath).To(Equal(path))
+		})
+
+		Context("when the build fails", func() {
+			It("returns the compiler output", func() {
+				err := ioutil.WriteFile(filepath.Join(moduleDir, "main.go"), []byte("package main\n\nfunc main() { undefined() }\n"), 0644)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = cache.Build("example.com/greeter")
+				Expect(err).To(MatchError(ContainSubstring("undefined")))
+			})
+		})
+	})
+
+	Describe("Derive", func() {
+		It("only creates an entry once per key", func() {
+			calls := 0
+			create := func(path string) error {
+				calls++
+				return ioutil.WriteFile(path, []byte("derived"), 0644)
+			}
+
+			path, err := cache.Derive(buildcache.Key("a", "b"), "thing", create)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.ReadFile(path)).To(Equal([]byte("derived")))
+
+			_, err = cache.Derive(buildcache.Key("a", "b"), "thing", create)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(Equal(1))
+
+			_, err = cache.Derive(buildcache.Key("ab"), "thing", create)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(Equal(2))
+		})
+	})
+
+	Describe("Link", func() {
+		It("places the cached file at the destination", func() {
+			path, err := cache.Build("example.com/greeter")
+			Expect(err).NotTo(HaveOccurred())
+
+			destination := filepath.Join(moduleDir, "greeter-copy")
+			Expect(buildcache.Link(path, destination)).To(Succeed())
+
+			output, err := exec.Command(destination).CombinedOutput()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(output)).To(Equal("hello\n"))
+		})
+	})
+})
@@ -0,0 +1 @@
+package buildcache // import "code.cloudfoundry.org/inigo/helpers/buildcache"
@@ -1,7 +1,14 @@
 package certauthority
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	pkixname "crypto/x509/pkix"
+	"encoding/pem"
 	"io/ioutil"
+	"math"
+	"math/big"
 	"net"
 	"path/filepath"
 	"sync"
@@ -21,6 +28,12 @@ var (
 type CertAuthority interface {
 	CAAndKey() (key string, cert string)
 	GenerateSelfSignedCertAndKey(string, []string, bool) (key string, cert string, err error)
+	// GenerateExpiredCertAndKey signs a host certificate that has already
+	// expired.
+	GenerateExpiredCertAndKey(string, []string) (key string, cert string, err error)
+	// GenerateUntrustedCertAndKey creates a host certificate that is signed by
+	// itself instead of by the authority.
+	GenerateUntrustedCertAndKey(string, []string) (key string, cert string, err error)
 }
 
 type certAuthority struct {
@@ -48,14 +61,57 @@ func (c certAuthority) CAAndKey() (string, string) {
 }
 
 func (c certAuthority) GenerateSelfSignedCertAndKey(commonName string, sans []string, intermediateCA bool) (string, string, error) {
+	return c.generateCertAndKey(commonName, sans, intermediateCA, time.Now().AddDate(1, 0, 0))
+}
+
+func (c certAuthority) GenerateExpiredCertAndKey(commonName string, sans []string) (string, string, error) {
+	return c.generateCertAndKey(commonName, sans, false, time.Now().Add(-time.Hour))
+}
+
+func (c certAuthority) GenerateUntrustedCertAndKey(commonName string, sans []string) (string, string, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return handleError(err)
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	if err != nil {
+		return handleError(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkixname.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(1, 0, 0),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     sans,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	crtDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return handleError(err)
+	}
+
+	crtBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: crtDER})
+	keyBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return c.writeCertAndKey(commonName, keyBytes, crtBytes)
+}
+
+func (c certAuthority) generateCertAndKey(commonName string, sans []string, intermediateCA bool, expiry time.Time) (string, string, error) {
 	key, err := pkix.CreateRSAKey(4096)
 	keyBytes, err := key.ExportPrivate()
 	if err != nil {
 		return handleError(err)
 	}
 
+	ips, dnsNames := splitSANs(sans)
+
 	csrLock.Lock()
-	csr, err := pkix.CreateCertificateSigningRequest(key, "", []net.IP{net.ParseIP("127.0.0.1")}, sans, nil, "", "", "", "", commonName)
+	csr, err := pkix.CreateCertificateSigningRequest(key, "", ips, dnsNames, nil, "", "", "", "", commonName)
 	if err != nil {
 		csrLock.Unlock()
 		return handleError(err)
@@ -85,9 +141,9 @@ func (c certAuthority) GenerateSelfSignedCertAndKey(commonName string, sans []st
 	caLock.Lock()
 	var crt *pkix.Certificate
 	if intermediateCA {
-		crt, err = pkix.CreateIntermediateCertificateAuthority(ca, caKey, csr, time.Now().AddDate(1, 0, 0))
+		crt, err = pkix.CreateIntermediateCertificateAuthority(ca, caKey, csr, expiry)
 	} else {
-		crt, err = pkix.CreateCertificateHost(ca, caKey, csr, time.Now().AddDate(1, 0, 0))
+		crt, err = pkix.CreateCertificateHost(ca, caKey, csr, expiry)
 	}
 	if err != nil {
 		caLock.Unlock()
@@ -100,6 +156,10 @@ func (c certAuthority) GenerateSelfSignedCertAndKey(commonName string, sans []st
 		return handleError(err)
 	}
 
+	return c.writeCertAndKey(commonName, keyBytes, crtBytes)
+}
+
+func (c certAuthority) writeCertAndKey(commonName string, keyBytes, crtBytes []byte) (string, string, error) {
 	keyFile, err := ioutil.TempFile(c.depotDir, commonName)
 	if err != nil {
 		return handleError(err)
@@ -181,3 +241,19 @@ func generateCAAndKey(depotDir, commonName string) (string, string, error) {
 func handleError(err error) (string, string, error) {
 	return "", "", err
 }
+
+// splitSANs returns the sans that are IP addresses, always including
+// 127.0.0.1, and the ones that are DNS names.
+func splitSANs(sans []string) ([]net.IP, []string) {
+	ips := []net.IP{net.ParseIP("127.0.0.1")}
+	dnsNames := []string{}
+	for _, san := range sans {
+		ip := net.ParseIP(san)
+		if ip == nil {
+			dnsNames = append(dnsNames, san)
+		} else if !ip.Equal(ips[0]) {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, dnsNames
+}
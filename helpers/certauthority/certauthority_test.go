@@ -5,6 +5,7 @@ import (
 	"encoding/pem"
 	"io/ioutil"
 	"os"
+	"time"
 
 	"code.cloudfoundry.org/inigo/helpers/certauthority"
 	. "github.com/onsi/ginkgo"
@@ -76,6 +77,45 @@ var _ = Describe("Cert Allocator", func() {
 			parsedCert, _ := parseCert(cert)
 			Expect(parsedCert.Subject.CommonName).To(Equal("some-component"))
 		})
+
+		It("puts SANs that are IP addresses in the certificate's IP SANs", func() {
+			authority, err = certauthority.NewCertAuthority(depotDir, "some-name")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, cert, err := authority.GenerateSelfSignedCertAndKey("some-component", []string{"some-component", "127.0.0.1", "10.0.0.1"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			parsedCert, _ := parseCert(cert)
+			Expect(parsedCert.DNSNames).To(ConsistOf("some-component"))
+			Expect(parsedCert.IPAddresses).To(HaveLen(2))
+			Expect(parsedCert.VerifyHostname("127.0.0.1")).To(Succeed())
+			Expect(parsedCert.VerifyHostname("10.0.0.1")).To(Succeed())
+		})
+
+		It("generates certificates that have already expired", func() {
+			authority, err = certauthority.NewCertAuthority(depotDir, "some-name")
+			Expect(err).NotTo(HaveOccurred())
+
+			key, cert, err := authority.GenerateExpiredCertAndKey("some-component", []string{"some-component"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(BeAnExistingFile())
+
+			parsedCert, _ := parseCert(cert)
+			Expect(parsedCert.NotAfter).To(BeTemporally("<", time.Now()))
+		})
+
+		It("generates certificates that the authority did not sign", func() {
+			authority, err = certauthority.NewCertAuthority(depotDir, "some-name")
+			Expect(err).NotTo(HaveOccurred())
+
+			key, cert, err := authority.GenerateUntrustedCertAndKey("some-component", []string{"some-component"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(BeAnExistingFile())
+
+			parsedCert, _ := parseCert(cert)
+			Expect(parsedCert.Subject.CommonName).To(Equal("some-component"))
+			Expect(parsedCert.Issuer.CommonName).To(Equal("some-component"))
+			Expect(parsedCert.DNSNames).To(ConsistOf("some-component"))
+		})
 	})
 
 	Context("when depotDir is invalid", func() {
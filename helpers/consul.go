@@ -11,7 +11,13 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// ConsulWaitUntilReady waits for Consul to elect a leader. It returns
+// straight away when the world runs without Consul.
 func ConsulWaitUntilReady(addresses world.ComponentAddresses) {
+	if addresses.Consul == "" {
+		return
+	}
+
 	_, port, err := net.SplitHostPort(addresses.Consul)
 	Expect(err).NotTo(HaveOccurred())
 	httpPort, err := strconv.Atoi(port)
@@ -30,7 +36,7 @@ func ConsulWaitUntilReady(addresses world.ComponentAddresses) {
 	client := cr.NewClient()
 	catalog := client.Catalog()
 
-	Eventually(func() error {
+	TimedEventuallyWithOffset(1, func() error {
 		_, qm, err := catalog.Nodes(nil)
 		if err != nil {
 			return err
@@ -0,0 +1,105 @@
+package helpers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+
+	repconfig "code.cloudfoundry.org/rep/cmd/rep/config"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+// DownloadCacheStats summarises how the executor's download cache fetched a
+// CachedDependency served by a FaultServer.
+type DownloadCacheStats struct {
+	// Downloads counts GETs answered with the full body.
+	Downloads int
+	// ETagRevalidations counts GETs that sent If-None-Match.
+	ETagRevalidations int
+	// NotModified counts requests answered with 304 Not Modified.
+	NotModified int
+	// Refetches counts unconditional full downloads after the first one,
+	// i.e. times the cache no longer had its copy (e.g. it was evicted).
+	Refetches int
+}
+
+// CacheStats returns the DownloadCacheStats for path. Use it with
+// BeDownloadedOnce, BeRevalidatedWithETag and BeRefetched:
+//
+//	Eventually(func() helpers.DownloadCacheStats {
+//		return server.CacheStats("/dependency.tgz")
+//	}).Should(helpers.BeRevalidatedWithETag())
+func (s *FaultServer) CacheStats(path string) DownloadCacheStats {
+	stats := DownloadCacheStats{}
+	for _, request := range s.Requests(path) {
+		if request.Method != "GET" {
+			continue
+		}
+
+		if request.Header.Get("If-None-Match") != "" {
+			stats.ETagRevalidations++
+		}
+
+		switch request.Status {
+		case http.StatusOK:
+			if stats.Downloads > 0 && !request.Conditional() {
+				stats.Refetches++
+			}
+			stats.Downloads++
+		case http.StatusNotModified:
+			stats.NotModified++
+		}
+	}
+	return stats
+}
+
+// BeDownloadedOnce succeeds when the body was sent exactly once, however
+// many containers asked for it.
+func BeDownloadedOnce() types.GomegaMatcher {
+	return WithTransform(func(stats DownloadCacheStats) int { return stats.Downloads }, Equal(1))
+}
+
+// BeRevalidatedWithETag succeeds once the cache has asked whether its copy is
+// still current with If-None-Match.
+func BeRevalidatedWithETag() types.GomegaMatcher {
+	return WithTransform(func(stats DownloadCacheStats) int { return stats.ETagRevalidations }, BeNumerically(">", 0))
+}
+
+// BeRefetched succeeds once the cache has downloaded the body again without
+// revalidating, which it only does after losing its copy.
+func BeRefetched() types.GomegaMatcher {
+	return WithTransform(func(stats DownloadCacheStats) int { return stats.Refetches }, BeNumerically(">", 0))
+}
+
+// WithDownloadCacheSize limits the rep's download cache to maxBytes, e.g.
+// componentMaker.Rep(helpers.WithDownloadCacheSize(1024)).
+func WithDownloadCacheSize(maxBytes uint64) func(*repconfig.RepConfig) {
+	return func(cfg *repconfig.RepConfig) {
+		cfg.MaxCacheSizeInBytes = maxBytes
+	}
+}
+
+// CaptureDownloadCachePath stores the rep's download cache directory in
+// cachePath, so that specs can look inside it (see DirSize).
+func CaptureDownloadCachePath(cachePath *string) func(*repconfig.RepConfig) {
+	return func(cfg *repconfig.RepConfig) {
+		*cachePath = cfg.CachePath
+	}
+}
+
+// DirSize returns the total size of the files under dir.
+func DirSize(dir string) int64 {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return size
+}
@@ -0,0 +1,178 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	routeemitterconfig "code.cloudfoundry.org/route-emitter/cmd/route-emitter/config"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const FakeRouterGroupGuid = "inigo-tcp-router-group"
+
+// TCPRouteMapping is a TCP route as the routing API stores it.
+type TCPRouteMapping struct {
+	RouterGroupGuid  string `json:"router_group_guid"`
+	Port             uint16 `json:"port"`
+	BackendIP        string `json:"backend_ip"`
+	BackendPort      uint16 `json:"backend_port"`
+	TTL              *int   `json:"ttl,omitempty"`
+	IsolationSegment string `json:"isolation_segment,omitempty"`
+}
+
+func (m TCPRouteMapping) key() string {
+	return fmt.Sprintf("%s:%d->%s:%d", m.RouterGroupGuid, m.Port, m.BackendIP, m.BackendPort)
+}
+
+// TCPRouteEvent is one mapping in a create (upsert) or delete request.
+type TCPRouteEvent struct {
+	Action     string
+	Mapping    TCPRouteMapping
+	ReceivedAt time.Time
+}
+
+// FakeRoutingAPI stands in for the routing API, with auth disabled, as far as
+// the route-emitter's TCP emitter uses it: it has a single TCP router group,
+// and records every TCP route upsert and delete.
+//
+//	routingAPI := helpers.NewFakeRoutingAPI()
+//	defer routingAPI.Close()
+//	componentMaker.RouteEmitter(routingAPI.RouteEmitterConfig())
+type FakeRoutingAPI struct {
+	server *httptest.Server
+
+	lock   sync.Mutex
+	events []TCPRouteEvent
+	routes map[string]TCPRouteMapping
+}
+
+func NewFakeRoutingAPI() *FakeRoutingAPI {
+	api := &FakeRoutingAPI{routes: map[string]TCPRouteMapping{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/routing/v1/router_groups", api.routerGroups)
+	mux.HandleFunc("/routing/v1/tcp_routes", api.tcpRoutes)
+	mux.HandleFunc("/routing/v1/tcp_routes/create", api.update("upsert"))
+	mux.HandleFunc("/routing/v1/tcp_routes/delete", api.update("delete"))
+
+	api.server = httptest.NewServer(mux)
+	return api
+}
+
+func (api *FakeRoutingAPI) URL() string {
+	return api.server.URL
+}
+
+func (api *FakeRoutingAPI) Port() int {
+	serverURL, err := url.Parse(api.server.URL)
+	Expect(err).NotTo(HaveOccurred())
+
+	port, err := strconv.Atoi(serverURL.Port())
+	Expect(err).NotTo(HaveOccurred())
+	return port
+}
+
+// RouteEmitterConfig points the route-emitter's TCP emitter at the fake.
+func (api *FakeRoutingAPI) RouteEmitterConfig() func(*routeemitterconfig.RouteEmitterConfig) {
+	return func(cfg *routeemitterconfig.RouteEmitterConfig) {
+		cfg.EnableTCPEmitter = true
+		cfg.RoutingAPI = routeemitterconfig.RoutingAPIConfig{
+			URL:         "http://127.0.0.1",
+			Port:        api.Port(),
+			AuthEnabled: false,
+		}
+	}
+}
+
+// Events returns every upsert and delete received so far, in order.
+func (api *FakeRoutingAPI) Events() []TCPRouteEvent {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	events := make([]TCPRouteEvent, len(api.events))
+	copy(events, api.events)
+	return events
+}
+
+// TCPRoutes returns the current routing table.
+func (api *FakeRoutingAPI) TCPRoutes() []TCPRouteMapping {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	routes := make([]TCPRouteMapping, 0, len(api.routes))
+	for _, route := range api.routes {
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+func (api *FakeRoutingAPI) Close() {
+	api.server.Close()
+}
+
+func (api *FakeRoutingAPI) routerGroups(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, []map[string]string{{
+		"guid":             FakeRouterGroupGuid,
+		"name":             "default-tcp",
+		"type":             "tcp",
+		"reservable_ports": "1024-65535",
+	}})
+}
+
+func (api *FakeRoutingAPI) tcpRoutes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, api.TCPRoutes())
+}
+
+func (api *FakeRoutingAPI) update(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer GinkgoRecover()
+
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		var mappings []TCPRouteMapping
+		err := json.NewDecoder(r.Body).Decode(&mappings)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		receivedAt := time.Now()
+
+		api.lock.Lock()
+		for _, mapping := range mappings {
+			api.events = append(api.events, TCPRouteEvent{Action: action, Mapping: mapping, ReceivedAt: receivedAt})
+			if action == "upsert" {
+				api.routes[mapping.key()] = mapping
+			} else {
+				delete(api.routes, mapping.key())
+			}
+		}
+		api.lock.Unlock()
+
+		w.WriteHeader(http.StatusCreated)
+	}
+}
+
+// writeJSON runs on the server's goroutines, where a failed Expect would
+// panic outside of any spec, so it reports failures to the client instead.
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
@@ -0,0 +1,383 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+// VolumeDriverCall is a call the fake volume driver received.
+type VolumeDriverCall struct {
+	Method     string
+	Volume     string
+	Opts       map[string]interface{}
+	Err        string
+	ReceivedAt time.Time
+}
+
+// VolumeMount is a volume that is currently mounted through a fake volume
+// driver.
+type VolumeMount struct {
+	CellID     string
+	Volume     string
+	Opts       map[string]interface{}
+	Mountpoint string
+	MountCount int
+}
+
+type fakeVolume struct {
+	opts       map[string]interface{}
+	mountpoint string
+	mountCount int
+}
+
+// FakeVolumeDriver is an in-process volume driver served over driverhttp,
+// for volman specs that need more than local-driver's happy path. Nothing is
+// really mounted: a mount is a directory under the driver's mount dir, so
+// drivers made with the same mount dir share their volumes' data, as cells
+// mounting the same NFS export would.
+//
+// Drivers cannot tell which rep is calling them, so register one per cell
+// (each with its own VolmanDriverPaths) to check where a volume was mounted:
+//
+//	driver := helpers.NewFakeVolumeDriver(logger, "fakedriver", "cell-a", world.TempDir("fake-driver"))
+//	defer driver.Close()
+//	driver.Register(componentMaker.VolmanDriverPath())
+//	driver.FailMount("mount.nfs: access denied by server")
+//	...
+//	Eventually(driver).Should(helpers.HaveMountedVolume("some-volume", map[string]interface{}{"uid": "1000"}))
+type FakeVolumeDriver struct {
+	Name   string
+	CellID string
+
+	mountDir string
+	server   *httptest.Server
+
+	lock         sync.Mutex
+	calls        []VolumeDriverCall
+	volumes      map[string]*fakeVolume
+	mountErr     string
+	mountDelay   time.Duration
+	unmountErr   string
+	scope        string
+	registeredIn []string
+}
+
+func NewFakeVolumeDriver(logger lager.Logger, name, cellID, mountDir string) *FakeVolumeDriver {
+	driver := &FakeVolumeDriver{
+		Name:     name,
+		CellID:   cellID,
+		mountDir: mountDir,
+		volumes:  map[string]*fakeVolume{},
+		scope:    "local",
+	}
+
+	handler, err := driverhttp.NewHandler(logger.Session(name), driver)
+	Expect(err).NotTo(HaveOccurred())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	driver.server = httptest.NewUnstartedServer(handler)
+	driver.server.Listener = listener
+	driver.server.Start()
+
+	return driver
+}
+
+// Register writes the driver's spec into driversPath. Volman only picks it up
+// on its next sync, so register before starting VolmanClient or the rep.
+func (d *FakeVolumeDriver) Register(driversPath string) {
+	spec, err := json.Marshal(map[string]string{"Name": d.Name, "Addr": d.server.URL})
+	Expect(err).NotTo(HaveOccurred())
+
+	err = os.MkdirAll(driversPath, 0755)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = dockerdriver.WriteDriverSpec(lager.NewLogger("fake-volume-driver"), driversPath, d.Name, "json", spec)
+	Expect(err).NotTo(HaveOccurred())
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.registeredIn = append(d.registeredIn, driversPath)
+}
+
+// Close stops serving and removes the specs written by Register.
+func (d *FakeVolumeDriver) Close() {
+	d.server.Close()
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	for _, driversPath := range d.registeredIn {
+		os.Remove(filepath.Join(driversPath, d.Name+".json"))
+	}
+	d.registeredIn = nil
+}
+
+// URL is where the driver is served, as written to its spec by Register.
+func (d *FakeVolumeDriver) URL() string {
+	return d.server.URL
+}
+
+// FailMount makes every Mount fail with message.
+func (d *FakeVolumeDriver) FailMount(message string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.mountErr = message
+}
+
+// DelayMount makes every Mount take at least delay.
+func (d *FakeVolumeDriver) DelayMount(delay time.Duration) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.mountDelay = delay
+}
+
+// FailUnmount makes every Unmount fail with message.
+func (d *FakeVolumeDriver) FailUnmount(message string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.unmountErr = message
+}
+
+// SetScope sets the scope the driver advertises, "local" or "global".
+func (d *FakeVolumeDriver) SetScope(scope string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.scope = scope
+}
+
+// Heal undoes FailMount, DelayMount and FailUnmount.
+func (d *FakeVolumeDriver) Heal() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.mountErr = ""
+	d.mountDelay = 0
+	d.unmountErr = ""
+}
+
+// Calls returns every call received so far, in order.
+func (d *FakeVolumeDriver) Calls() []VolumeDriverCall {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	calls := make([]VolumeDriverCall, len(d.calls))
+	copy(calls, d.calls)
+	return calls
+}
+
+// CallCount returns how many times method (e.g. "Mount") has been called.
+func (d *FakeVolumeDriver) CallCount(method string) int {
+	count := 0
+	for _, call := range d.Calls() {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// Mounts returns the volumes that are currently mounted.
+func (d *FakeVolumeDriver) Mounts() []VolumeMount {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	mounts := []VolumeMount{}
+	for name, volume := range d.volumes {
+		if volume.mountCount == 0 {
+			continue
+		}
+		mounts = append(mounts, VolumeMount{
+			CellID:     d.CellID,
+			Volume:     name,
+			Opts:       volume.opts,
+			Mountpoint: volume.mountpoint,
+			MountCount: volume.mountCount,
+		})
+	}
+	return mounts
+}
+
+// HaveMountedVolume succeeds when a fake volume driver has volume mounted
+// with opts. Volume names with the container appended, as volman makes them
+// for drivers that want unique volume ids, match too.
+func HaveMountedVolume(volume string, opts map[string]interface{}) types.GomegaMatcher {
+	return WithTransform(func(d *FakeVolumeDriver) []VolumeMount {
+		matching := []VolumeMount{}
+		for _, mount := range d.Mounts() {
+			if mount.Volume == volume || strings.HasPrefix(mount.Volume, volume+"_") {
+				matching = append(matching, mount)
+			}
+		}
+		return matching
+	}, ContainElement(WithTransform(func(mount VolumeMount) map[string]interface{} { return mount.Opts }, Equal(opts))))
+}
+
+func (d *FakeVolumeDriver) Activate(env dockerdriver.Env) dockerdriver.ActivateResponse {
+	d.record("Activate", "", nil, "")
+	return dockerdriver.ActivateResponse{Implements: []string{"VolumeDriver"}}
+}
+
+func (d *FakeVolumeDriver) Create(env dockerdriver.Env, request dockerdriver.CreateRequest) dockerdriver.ErrorResponse {
+	d.record("Create", request.Name, request.Opts, "")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, exists := d.volumes[request.Name]; !exists {
+		d.volumes[request.Name] = &fakeVolume{opts: request.Opts}
+	}
+	return dockerdriver.ErrorResponse{}
+}
+
+func (d *FakeVolumeDriver) Get(env dockerdriver.Env, request dockerdriver.GetRequest) dockerdriver.GetResponse {
+	d.record("Get", request.Name, nil, "")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	volume, ok := d.volumes[request.Name]
+	if !ok {
+		return dockerdriver.GetResponse{Err: "volume not found"}
+	}
+	return dockerdriver.GetResponse{Volume: dockerdriver.VolumeInfo{
+		Name:       request.Name,
+		Mountpoint: volume.mountpoint,
+		MountCount: volume.mountCount,
+	}}
+}
+
+func (d *FakeVolumeDriver) List(env dockerdriver.Env) dockerdriver.ListResponse {
+	d.record("List", "", nil, "")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	volumes := []dockerdriver.VolumeInfo{}
+	for name, volume := range d.volumes {
+		volumes = append(volumes, dockerdriver.VolumeInfo{
+			Name:       name,
+			Mountpoint: volume.mountpoint,
+			MountCount: volume.mountCount,
+		})
+	}
+	return dockerdriver.ListResponse{Volumes: volumes}
+}
+
+func (d *FakeVolumeDriver) Mount(env dockerdriver.Env, request dockerdriver.MountRequest) dockerdriver.MountResponse {
+	d.lock.Lock()
+	mountErr, mountDelay := d.mountErr, d.mountDelay
+	d.lock.Unlock()
+
+	time.Sleep(mountDelay)
+
+	d.lock.Lock()
+	volume, ok := d.volumes[request.Name]
+	d.lock.Unlock()
+
+	if mountErr != "" {
+		// record the opts the mount would have used, so that specs can tell
+		// which mount failed
+		var opts map[string]interface{}
+		if ok {
+			opts = volume.opts
+		}
+		d.record("Mount", request.Name, opts, mountErr)
+		return dockerdriver.MountResponse{Err: mountErr}
+	}
+
+	if !ok {
+		d.record("Mount", request.Name, nil, "volume not found")
+		return dockerdriver.MountResponse{Err: "volume not found"}
+	}
+
+	mountpoint := filepath.Join(d.mountDir, request.Name)
+	err := os.MkdirAll(mountpoint, 0777)
+	if err == nil {
+		// apps run as vcap, so make sure they can write despite the umask
+		err = os.Chmod(mountpoint, 0777)
+	}
+	if err != nil {
+		d.record("Mount", request.Name, volume.opts, err.Error())
+		return dockerdriver.MountResponse{Err: err.Error()}
+	}
+
+	d.lock.Lock()
+	volume.mountpoint = mountpoint
+	volume.mountCount++
+	d.lock.Unlock()
+
+	d.record("Mount", request.Name, volume.opts, "")
+	return dockerdriver.MountResponse{Mountpoint: mountpoint}
+}
+
+func (d *FakeVolumeDriver) Path(env dockerdriver.Env, request dockerdriver.PathRequest) dockerdriver.PathResponse {
+	d.record("Path", request.Name, nil, "")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	volume, ok := d.volumes[request.Name]
+	if !ok {
+		return dockerdriver.PathResponse{Err: "volume not found"}
+	}
+	return dockerdriver.PathResponse{Mountpoint: volume.mountpoint}
+}
+
+func (d *FakeVolumeDriver) Unmount(env dockerdriver.Env, request dockerdriver.UnmountRequest) dockerdriver.ErrorResponse {
+	d.lock.Lock()
+	unmountErr := d.unmountErr
+	volume, ok := d.volumes[request.Name]
+	switch {
+	case unmountErr != "":
+	case !ok || volume.mountCount == 0:
+		unmountErr = "volume not mounted"
+	default:
+		volume.mountCount--
+	}
+	d.lock.Unlock()
+
+	d.record("Unmount", request.Name, nil, unmountErr)
+	return dockerdriver.ErrorResponse{Err: unmountErr}
+}
+
+func (d *FakeVolumeDriver) Remove(env dockerdriver.Env, request dockerdriver.RemoveRequest) dockerdriver.ErrorResponse {
+	d.record("Remove", request.Name, nil, "")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.volumes, request.Name)
+	return dockerdriver.ErrorResponse{}
+}
+
+func (d *FakeVolumeDriver) Capabilities(env dockerdriver.Env) dockerdriver.CapabilitiesResponse {
+	d.record("Capabilities", "", nil, "")
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return dockerdriver.CapabilitiesResponse{Capabilities: dockerdriver.CapabilityInfo{Scope: d.scope}}
+}
+
+func (d *FakeVolumeDriver) record(method, volume string, opts map[string]interface{}, err string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.calls = append(d.calls, VolumeDriverCall{
+		Method:     method,
+		Volume:     volume,
+		Opts:       opts,
+		Err:        err,
+		ReceivedAt: time.Now(),
+	})
+}
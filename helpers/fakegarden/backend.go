@@ -0,0 +1,217 @@
+package fakegarden
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// DefaultCapacity is what the backend reports unless told otherwise. Nothing
+// is reserved against it; it only has to be large enough for the rep to
+// advertise the resources specs ask for.
+var DefaultCapacity = garden.Capacity{
+	MemoryInBytes: 16 * 1024 * 1024 * 1024,
+	DiskInBytes:   64 * 1024 * 1024 * 1024,
+	MaxContainers: 250,
+}
+
+// Backend is a garden.Backend that needs neither root nor a container
+// runtime. A container is a directory under the backend's base directory and
+// its processes are plain host subprocesses, so it isolates nothing:
+//
+//   - paths given to StreamIn, StreamOut and bind mounts are resolved inside
+//     the container directory (bind mounts become symlinks); process paths,
+//     arguments and working directories are resolved there too when they
+//     exist, and on the host otherwise
+//   - processes run as the current user, whatever User they ask for
+//   - containers share the host network: NetIn maps a port to itself unless
+//     a host port is given, and NetOut rules are recorded but not enforced
+//   - limits are recorded and reported back; only the nofile limit of a
+//     process is enforced (with ulimit)
+//
+// This is enough for the rep, the executor and the harness helpers to be
+// exercised on a machine without root.
+type Backend struct {
+	baseDir  string
+	capacity garden.Capacity
+
+	lock       sync.Mutex
+	containers map[string]*container
+	nextHandle int
+}
+
+func NewBackend(baseDir string, capacity garden.Capacity) *Backend {
+	return &Backend{
+		baseDir:    baseDir,
+		capacity:   capacity,
+		containers: map[string]*container{},
+	}
+}
+
+func (b *Backend) Start() error {
+	return os.MkdirAll(b.baseDir, 0755)
+}
+
+// Stop kills every process that is still running. Containers are left in
+// place; destroy them first to remove their directories.
+func (b *Backend) Stop() error {
+	for _, c := range b.allContainers() {
+		c.Stop(true)
+	}
+	return nil
+}
+
+func (b *Backend) GraceTime(gardenContainer garden.Container) time.Duration {
+	c, ok := gardenContainer.(*container)
+	if !ok {
+		return 0
+	}
+	return c.currentGraceTime()
+}
+
+func (b *Backend) Ping() error {
+	return nil
+}
+
+func (b *Backend) Capacity() (garden.Capacity, error) {
+	return b.capacity, nil
+}
+
+func (b *Backend) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	handle := spec.Handle
+	if handle == "" {
+		b.nextHandle++
+		handle = fmt.Sprintf("fake-garden-container-%d", b.nextHandle)
+	}
+
+	if _, exists := b.containers[handle]; exists {
+		return nil, fmt.Errorf("handle already exists: %s", handle)
+	}
+
+	err := os.MkdirAll(b.baseDir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	rootDir, err := ioutil.TempDir(b.baseDir, "container-")
+	if err != nil {
+		return nil, err
+	}
+
+	c := newContainer(handle, rootDir, spec)
+
+	for _, mount := range spec.BindMounts {
+		err := c.bindMount(mount)
+		if err != nil {
+			os.RemoveAll(rootDir)
+			return nil, err
+		}
+	}
+
+	for _, netIn := range spec.NetIn {
+		c.NetIn(netIn.HostPort, netIn.ContainerPort)
+	}
+
+	err = c.BulkNetOut(spec.NetOut)
+	if err != nil {
+		os.RemoveAll(rootDir)
+		return nil, err
+	}
+
+	b.containers[handle] = c
+	return c, nil
+}
+
+func (b *Backend) Destroy(handle string) error {
+	b.lock.Lock()
+	c, ok := b.containers[handle]
+	delete(b.containers, handle)
+	b.lock.Unlock()
+
+	if !ok {
+		return garden.ContainerNotFoundError{Handle: handle}
+	}
+
+	c.Stop(true)
+	return os.RemoveAll(c.rootDir)
+}
+
+func (b *Backend) Containers(filter garden.Properties) ([]garden.Container, error) {
+	containers := []garden.Container{}
+	for _, c := range b.allContainers() {
+		if c.hasProperties(filter) {
+			containers = append(containers, c)
+		}
+	}
+	return containers, nil
+}
+
+func (b *Backend) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	infos := map[string]garden.ContainerInfoEntry{}
+	for _, handle := range handles {
+		c, err := b.lookup(handle)
+		if err != nil {
+			infos[handle] = garden.ContainerInfoEntry{Err: garden.NewError(err.Error())}
+			continue
+		}
+
+		info, _ := c.Info()
+		infos[handle] = garden.ContainerInfoEntry{Info: info}
+	}
+	return infos, nil
+}
+
+func (b *Backend) BulkMetrics(handles []string) (map[string]garden.ContainerMetricsEntry, error) {
+	metrics := map[string]garden.ContainerMetricsEntry{}
+	for _, handle := range handles {
+		c, err := b.lookup(handle)
+		if err != nil {
+			metrics[handle] = garden.ContainerMetricsEntry{Err: garden.NewError(err.Error())}
+			continue
+		}
+
+		m, _ := c.Metrics()
+		metrics[handle] = garden.ContainerMetricsEntry{Metrics: m}
+	}
+	return metrics, nil
+}
+
+func (b *Backend) Lookup(handle string) (garden.Container, error) {
+	return b.lookup(handle)
+}
+
+func (b *Backend) lookup(handle string) (*container, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	c, ok := b.containers[handle]
+	if !ok {
+		return nil, garden.ContainerNotFoundError{Handle: handle}
+	}
+	return c, nil
+}
+
+func (b *Backend) allContainers() []*container {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	containers := make([]*container, 0, len(b.containers))
+	for _, c := range b.containers {
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+// containerPath resolves path, as seen from inside a container, to where it
+// lives on the host.
+func containerPath(rootDir, path string) string {
+	return filepath.Join(rootDir, filepath.FromSlash(path))
+}
@@ -0,0 +1,410 @@
+package fakegarden
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+)
+
+type container struct {
+	handle  string
+	rootDir string
+	env     []string
+	limits  garden.Limits
+
+	lock        sync.Mutex
+	graceTime   time.Duration
+	properties  garden.Properties
+	mappedPorts []garden.PortMapping
+	netOutRules []garden.NetOutRule
+	processes   map[string]*process
+	nextProcess int
+}
+
+func newContainer(handle, rootDir string, spec garden.ContainerSpec) *container {
+	properties := garden.Properties{}
+	for k, v := range spec.Properties {
+		properties[k] = v
+	}
+
+	return &container{
+		handle:     handle,
+		rootDir:    rootDir,
+		env:        spec.Env,
+		limits:     spec.Limits,
+		graceTime:  spec.GraceTime,
+		properties: properties,
+		processes:  map[string]*process{},
+	}
+}
+
+func (c *container) Handle() string {
+	return c.handle
+}
+
+func (c *container) Stop(kill bool) error {
+	signal := garden.SignalTerminate
+	if kill {
+		signal = garden.SignalKill
+	}
+
+	for _, p := range c.allProcesses() {
+		p.Signal(signal)
+	}
+	return nil
+}
+
+func (c *container) Info() (garden.ContainerInfo, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	processIDs := []string{}
+	for id := range c.processes {
+		processIDs = append(processIDs, id)
+	}
+
+	properties := garden.Properties{}
+	for k, v := range c.properties {
+		properties[k] = v
+	}
+
+	mappedPorts := make([]garden.PortMapping, len(c.mappedPorts))
+	copy(mappedPorts, c.mappedPorts)
+
+	return garden.ContainerInfo{
+		State:         "active",
+		HostIP:        "127.0.0.1",
+		ContainerIP:   "127.0.0.1",
+		ExternalIP:    "127.0.0.1",
+		ContainerPath: c.rootDir,
+		ProcessIDs:    processIDs,
+		Properties:    properties,
+		MappedPorts:   mappedPorts,
+	}, nil
+}
+
+func (c *container) StreamIn(spec garden.StreamInSpec) error {
+	destination := containerPath(c.rootDir, spec.Path)
+
+	err := os.MkdirAll(destination, 0755)
+	if err != nil {
+		return err
+	}
+
+	reader := tar.NewReader(spec.TarStream)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(destination, filepath.FromSlash(header.Name))
+		if !withinDir(c.rootDir, target) {
+			return fmt.Errorf("tar entry %q escapes the container", header.Name)
+		}
+
+		err = extractEntry(reader, header, target)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// withinDir reports whether path is dir or lies below it; a sibling that
+// merely starts with the same name (dir + "-other") does not.
+func withinDir(dir, path string) bool {
+	dir = filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator))
+}
+
+func extractEntry(reader io.Reader, header *tar.Header, target string) error {
+	switch header.Typeflag {
+	case tar.TypeDir:
+		return os.MkdirAll(target, os.FileMode(header.Mode)|0700)
+	case tar.TypeSymlink:
+		err := os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+		os.Remove(target)
+		return os.Symlink(header.Linkname, target)
+	case tar.TypeReg, tar.TypeRegA:
+		err := os.MkdirAll(filepath.Dir(target), 0755)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, os.FileMode(header.Mode)|0600)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(file, reader)
+		return err
+	default:
+		// devices, fifos and hard links are not needed by anything that runs
+		// against the fake
+		return nil
+	}
+}
+
+// StreamOut tars up path; a trailing slash streams the contents of a
+// directory rather than the directory itself, as Garden does.
+func (c *container) StreamOut(spec garden.StreamOutSpec) (io.ReadCloser, error) {
+	source := containerPath(c.rootDir, spec.Path)
+
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(source)
+	if info.IsDir() && strings.HasSuffix(spec.Path, "/") {
+		base = "."
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeTar(writer, source, base))
+	}()
+
+	return reader, nil
+}
+
+func writeTar(w io.Writer, source, base string) error {
+	tarWriter := tar.NewWriter(w)
+
+	err := filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relative, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(filepath.Join(base, relative))
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tarWriter.Close()
+}
+
+func (c *container) CurrentBandwidthLimits() (garden.BandwidthLimits, error) {
+	return c.limits.Bandwidth, nil
+}
+
+func (c *container) CurrentCPULimits() (garden.CPULimits, error) {
+	return c.limits.CPU, nil
+}
+
+func (c *container) CurrentDiskLimits() (garden.DiskLimits, error) {
+	return c.limits.Disk, nil
+}
+
+func (c *container) CurrentMemoryLimits() (garden.MemoryLimits, error) {
+	return c.limits.Memory, nil
+}
+
+// NetIn maps containerPort to itself when hostPort is 0, since processes
+// listen on the host network.
+func (c *container) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
+	if hostPort == 0 {
+		hostPort = containerPort
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.mappedPorts = append(c.mappedPorts, garden.PortMapping{HostPort: hostPort, ContainerPort: containerPort})
+
+	return hostPort, containerPort, nil
+}
+
+func (c *container) NetOut(netOutRule garden.NetOutRule) error {
+	return c.BulkNetOut([]garden.NetOutRule{netOutRule})
+}
+
+func (c *container) BulkNetOut(netOutRules []garden.NetOutRule) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.netOutRules = append(c.netOutRules, netOutRules...)
+	return nil
+}
+
+func (c *container) Run(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+	c.lock.Lock()
+	id := spec.ID
+	if id == "" {
+		c.nextProcess++
+		id = fmt.Sprintf("%s-process-%d", c.handle, c.nextProcess)
+	}
+	if _, exists := c.processes[id]; exists {
+		c.lock.Unlock()
+		return nil, fmt.Errorf("process ID already in use: %s", id)
+	}
+	c.lock.Unlock()
+
+	p, err := startProcess(id, c.rootDir, c.env, spec, processIO)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.processes[id] = p
+	c.lock.Unlock()
+
+	return p, nil
+}
+
+// Attach returns the process with the given ID. Its output keeps going to the
+// ProcessIO it was started with; processIO is ignored.
+func (c *container) Attach(processID string, processIO garden.ProcessIO) (garden.Process, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	p, ok := c.processes[processID]
+	if !ok {
+		return nil, garden.ProcessNotFoundError{ProcessID: processID}
+	}
+	return p, nil
+}
+
+func (c *container) Metrics() (garden.Metrics, error) {
+	return garden.Metrics{}, nil
+}
+
+func (c *container) SetGraceTime(graceTime time.Duration) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.graceTime = graceTime
+	return nil
+}
+
+func (c *container) currentGraceTime() time.Duration {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.graceTime
+}
+
+func (c *container) Properties() (garden.Properties, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	properties := garden.Properties{}
+	for k, v := range c.properties {
+		properties[k] = v
+	}
+	return properties, nil
+}
+
+func (c *container) Property(name string) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	value, ok := c.properties[name]
+	if !ok {
+		return "", fmt.Errorf("property does not exist: %s", name)
+	}
+	return value, nil
+}
+
+func (c *container) SetProperty(name string, value string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.properties[name] = value
+	return nil
+}
+
+func (c *container) RemoveProperty(name string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.properties[name]; !ok {
+		return fmt.Errorf("property does not exist: %s", name)
+	}
+	delete(c.properties, name)
+	return nil
+}
+
+func (c *container) hasProperties(filter garden.Properties) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for k, v := range filter {
+		if c.properties[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// bindMount links mount.DstPath inside the container to mount.SrcPath on the
+// host. The mount mode is not enforced.
+func (c *container) bindMount(mount garden.BindMount) error {
+	destination := containerPath(c.rootDir, mount.DstPath)
+
+	err := os.MkdirAll(filepath.Dir(destination), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(mount.SrcPath, destination)
+}
+
+func (c *container) allProcesses() []*process {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	processes := make([]*process, 0, len(c.processes))
+	for _, p := range c.processes {
+		processes = append(processes, p)
+	}
+	return processes
+}
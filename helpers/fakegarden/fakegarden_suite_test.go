@@ -0,0 +1,13 @@
+package fakegarden_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestFakegarden(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Fakegarden Suite")
+}
@@ -0,0 +1,193 @@
+package fakegarden_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/inigo/helpers/fakegarden"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Backend", func() {
+	var (
+		baseDir string
+		backend *fakegarden.Backend
+	)
+
+	BeforeEach(func() {
+		var err error
+		baseDir, err = ioutil.TempDir("", "fakegarden")
+		Expect(err).NotTo(HaveOccurred())
+
+		backend = fakegarden.NewBackend(baseDir, fakegarden.DefaultCapacity)
+		Expect(backend.Start()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(backend.Stop()).To(Succeed())
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	run := func(container garden.Container, spec garden.ProcessSpec) (int, *gbytes.Buffer) {
+		stdout := gbytes.NewBuffer()
+		process, err := container.Run(spec, garden.ProcessIO{Stdout: stdout, Stderr: GinkgoWriter})
+		Expect(err).NotTo(HaveOccurred())
+
+		exitCode, err := process.Wait()
+		Expect(err).NotTo(HaveOccurred())
+		return exitCode, stdout
+	}
+
+	It("filters containers by their properties", func() {
+		_, err := backend.Create(garden.ContainerSpec{Handle: "a", Properties: garden.Properties{"owner": "cell-a"}})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = backend.Create(garden.ContainerSpec{Handle: "b", Properties: garden.Properties{"owner": "cell-b"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		containers, err := backend.Containers(garden.Properties{"owner": "cell-b"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0].Handle()).To(Equal("b"))
+
+		containers, err = backend.Containers(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers).To(HaveLen(2))
+	})
+
+	It("fails to destroy an unknown handle the way Garden does", func() {
+		err := backend.Destroy("nope")
+		Expect(err).To(MatchError(ContainSubstring("unknown handle")))
+	})
+
+	It("runs processes with the container and process environment, in the container directory", func() {
+		container, err := backend.Create(garden.ContainerSpec{Env: []string{"FOO=container", "BAR=container"}})
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := container.Info()
+		Expect(err).NotTo(HaveOccurred())
+
+		exitCode, stdout := run(container, garden.ProcessSpec{
+			Path: "sh",
+			Args: []string{"-c", `echo $FOO $BAR $PWD; exit 3`},
+			Env:  []string{"FOO=process"},
+		})
+		Expect(exitCode).To(Equal(3))
+
+		realPath, err := filepath.EvalSymlinks(info.ContainerPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stdout).To(gbytes.Say("process container " + realPath))
+	})
+
+	It("enforces the nofile limit of a process", func() {
+		container, err := backend.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		nofile := uint64(10)
+		exitCode, stdout := run(container, garden.ProcessSpec{
+			Path:   "sh",
+			Args:   []string{"-c", "ulimit -n"},
+			Limits: garden.ResourceLimits{Nofile: &nofile},
+		})
+		Expect(exitCode).To(Equal(0))
+		Expect(stdout).To(gbytes.Say("10"))
+	})
+
+	It("reports a process killed by a signal the way Garden does", func() {
+		container, err := backend.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		process, err := container.Run(garden.ProcessSpec{Path: "sleep", Args: []string{"10"}}, garden.ProcessIO{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(process.Signal(garden.SignalKill)).To(Succeed())
+		Expect(process.Wait()).To(Equal(137))
+	})
+
+	It("streams files in and out of the container", func() {
+		container, err := backend.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		in := new(bytes.Buffer)
+		tarWriter := tar.NewWriter(in)
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: "script", Mode: 0755, Size: 17, Typeflag: tar.TypeReg})).To(Succeed())
+		_, err = tarWriter.Write([]byte("#!/bin/sh\necho hi"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tarWriter.Close()).To(Succeed())
+
+		Expect(container.StreamIn(garden.StreamInSpec{Path: "/tmp/lifecycle", TarStream: in})).To(Succeed())
+
+		exitCode, stdout := run(container, garden.ProcessSpec{Path: "/tmp/lifecycle/script"})
+		Expect(exitCode).To(Equal(0))
+		Expect(stdout).To(gbytes.Say("hi"))
+
+		out, err := container.StreamOut(garden.StreamOutSpec{Path: "/tmp/lifecycle/script"})
+		Expect(err).NotTo(HaveOccurred())
+		defer out.Close()
+
+		tarReader := tar.NewReader(out)
+		header, err := tarReader.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(header.Name).To(Equal("script"))
+		Expect(ioutil.ReadAll(tarReader)).To(Equal([]byte("#!/bin/sh\necho hi")))
+	})
+
+	It("refuses tar entries that escape the container, even into a sibling with the same prefix", func() {
+		container, err := backend.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := container.Info()
+		Expect(err).NotTo(HaveOccurred())
+		sibling := filepath.Base(info.ContainerPath) + "-sibling"
+
+		in := new(bytes.Buffer)
+		tarWriter := tar.NewWriter(in)
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: "../" + sibling + "/escaped", Mode: 0644, Size: 2, Typeflag: tar.TypeReg})).To(Succeed())
+		_, err = tarWriter.Write([]byte("hi"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tarWriter.Close()).To(Succeed())
+
+		err = container.StreamIn(garden.StreamInSpec{Path: "/", TarStream: in})
+		Expect(err).To(MatchError(ContainSubstring("escapes the container")))
+		Expect(filepath.Join(baseDir, sibling)).NotTo(BeADirectory())
+	})
+
+	It("links bind mounts into the container", func() {
+		srcDir, err := ioutil.TempDir("", "bind-mount")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(srcDir)
+		Expect(ioutil.WriteFile(filepath.Join(srcDir, "file"), []byte("mounted"), 0644)).To(Succeed())
+
+		container, err := backend.Create(garden.ContainerSpec{
+			BindMounts: []garden.BindMount{{SrcPath: srcDir, DstPath: "/etc/cf-assets"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		exitCode, stdout := run(container, garden.ProcessSpec{Path: "cat", Args: []string{"/etc/cf-assets/file"}})
+		Expect(exitCode).To(Equal(0))
+		Expect(stdout).To(gbytes.Say("mounted"))
+	})
+
+	It("kills the processes of a destroyed container and removes its directory", func() {
+		container, err := backend.Create(garden.ContainerSpec{Handle: "doomed"})
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := container.Info()
+		Expect(err).NotTo(HaveOccurred())
+
+		process, err := container.Run(garden.ProcessSpec{Path: "sleep", Args: []string{"10"}}, garden.ProcessIO{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(backend.Destroy("doomed")).To(Succeed())
+		Expect(process.Wait()).To(Equal(137))
+		Expect(info.ContainerPath).NotTo(BeADirectory())
+
+		_, err = backend.Lookup("doomed")
+		Expect(err).To(MatchError(garden.ContainerNotFoundError{Handle: "doomed"}))
+	})
+})
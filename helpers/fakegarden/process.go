@@ -0,0 +1,164 @@
+package fakegarden
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"code.cloudfoundry.org/garden"
+)
+
+type process struct {
+	id  string
+	cmd *exec.Cmd
+
+	exited   chan struct{}
+	exitCode int
+	exitErr  error
+
+	signalLock sync.Mutex
+}
+
+func startProcess(id, rootDir string, containerEnv []string, spec garden.ProcessSpec, processIO garden.ProcessIO) (*process, error) {
+	dir, err := workingDir(rootDir, spec.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, len(spec.Args))
+	for i, arg := range spec.Args {
+		args[i] = resolve(rootDir, arg)
+	}
+
+	path := resolve(rootDir, spec.Path)
+	if spec.Limits.Nofile != nil {
+		// the only limit that can be applied to a single process without root
+		args = append([]string{"-c", `ulimit -n ` + strconv.FormatUint(*spec.Limits.Nofile, 10) + ` && exec "$0" "$@"`, path}, args...)
+		path = "/bin/sh"
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + rootDir}, containerEnv...)
+	cmd.Env = append(cmd.Env, spec.Env...)
+	cmd.Stdout = processIO.Stdout
+	cmd.Stderr = processIO.Stderr
+
+	// copy stdin ourselves; exec.Cmd.Wait would otherwise block until the
+	// client closes it, even after the process has exited
+	var stdin io.WriteCloser
+	if processIO.Stdin != nil {
+		stdin, err = cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = startInProcessGroup(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if stdin != nil {
+		go func() {
+			io.Copy(stdin, processIO.Stdin)
+			stdin.Close()
+		}()
+	}
+
+	p := &process{
+		id:     id,
+		cmd:    cmd,
+		exited: make(chan struct{}),
+	}
+
+	go p.wait()
+
+	return p, nil
+}
+
+func (p *process) wait() {
+	err := p.cmd.Wait()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		status := exitErr.Sys().(syscall.WaitStatus)
+		if status.Signaled() {
+			p.exitCode = 128 + int(status.Signal())
+		} else {
+			p.exitCode = status.ExitStatus()
+		}
+	default:
+		p.exitErr = err
+	}
+
+	close(p.exited)
+}
+
+func (p *process) ID() string {
+	return p.id
+}
+
+func (p *process) Wait() (int, error) {
+	<-p.exited
+	return p.exitCode, p.exitErr
+}
+
+func (p *process) SetTTY(garden.TTYSpec) error {
+	return nil
+}
+
+// Signal signals the process's whole process group, so that children of a
+// shell are stopped along with it.
+func (p *process) Signal(signal garden.Signal) error {
+	p.signalLock.Lock()
+	defer p.signalLock.Unlock()
+
+	select {
+	case <-p.exited:
+		return nil
+	default:
+	}
+
+	sig := syscall.SIGTERM
+	if signal == garden.SignalKill {
+		sig = syscall.SIGKILL
+	}
+
+	return signalProcessGroup(p.cmd.Process.Pid, sig)
+}
+
+// resolve maps a path inside the container to the host when it exists in the
+// container directory, and leaves it alone otherwise.
+func resolve(rootDir, path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+
+	inContainer := containerPath(rootDir, path)
+	if _, err := os.Lstat(inContainer); err == nil {
+		return inContainer
+	}
+	return path
+}
+
+func workingDir(rootDir, dir string) (string, error) {
+	if dir == "" {
+		return rootDir, nil
+	}
+
+	resolved := resolve(rootDir, dir)
+	if _, err := os.Stat(resolved); err == nil {
+		return resolved, nil
+	}
+
+	inContainer := containerPath(rootDir, dir)
+	return inContainer, os.MkdirAll(inContainer, 0755)
+}
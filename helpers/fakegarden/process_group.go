@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package fakegarden
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInProcessGroup starts cmd as the leader of a new process group.
+func startInProcessGroup(cmd *exec.Cmd) error {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd.Start()
+}
+
+func signalProcessGroup(pid int, sig syscall.Signal) error {
+	return syscall.Kill(-pid, sig)
+}
@@ -0,0 +1,18 @@
+package fakegarden
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+var errProcessesUnsupported = errors.New("fakegarden: running processes is not supported on windows")
+
+// the fake garden cannot run processes on windows; everything else works.
+func startInProcessGroup(cmd *exec.Cmd) error {
+	return errProcessesUnsupported
+}
+
+func signalProcessGroup(pid int, sig syscall.Signal) error {
+	return errProcessesUnsupported
+}
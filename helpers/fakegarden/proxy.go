@@ -0,0 +1,560 @@
+package fakegarden
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+)
+
+// Call names the Garden calls that faults can be injected into. Container
+// calls that come in several flavours share one Call, e.g. CallLimits covers
+// every Current*Limits.
+type Call string
+
+const (
+	CallPing        Call = "Ping"
+	CallCapacity    Call = "Capacity"
+	CallCreate      Call = "Create"
+	CallDestroy     Call = "Destroy"
+	CallContainers  Call = "Containers"
+	CallBulkInfo    Call = "BulkInfo"
+	CallBulkMetrics Call = "BulkMetrics"
+	CallLookup      Call = "Lookup"
+
+	CallStop          Call = "Stop"
+	CallInfo          Call = "Info"
+	CallStreamIn      Call = "StreamIn"
+	CallStreamOut     Call = "StreamOut"
+	CallLimits        Call = "Limits"
+	CallNetIn         Call = "NetIn"
+	CallNetOut        Call = "NetOut"
+	CallRun           Call = "Run"
+	CallAttach        Call = "Attach"
+	CallMetrics       Call = "Metrics"
+	CallSetGraceTime  Call = "SetGraceTime"
+	CallProperties    Call = "Properties"
+	CallSetProperties Call = "SetProperties"
+)
+
+// ErrStreamDropped is what a call fails with when its stream is dropped.
+var ErrStreamDropped = errors.New("fake garden: stream dropped")
+
+// Fault changes how an intercepted call behaves.
+type Fault func(*faultRule)
+
+type faultRule struct {
+	handle string
+	times  int
+	used   int
+
+	delay     time.Duration
+	err       error
+	hang      bool
+	drop      bool
+	dropAfter int
+}
+
+// ForHandle limits the fault to calls about one container. For BulkInfo and
+// BulkMetrics the fault then applies to that container's entry only.
+func ForHandle(handle string) Fault {
+	return func(r *faultRule) { r.handle = handle }
+}
+
+// Times limits the fault to the first n matching calls; by default it
+// applies to every one until the proxy is cleared.
+func Times(n int) Fault {
+	return func(r *faultRule) { r.times = n }
+}
+
+// Delay waits before the call is passed on.
+func Delay(d time.Duration) Fault {
+	return func(r *faultRule) { r.delay = d }
+}
+
+// ReturnError fails the call with err instead of passing it on.
+func ReturnError(err error) Fault {
+	return func(r *faultRule) { r.err = err }
+}
+
+// Hang blocks the call until Release or Clear is called on the proxy, after
+// which it goes ahead (or fails, with ReturnError).
+func Hang() Fault {
+	return func(r *faultRule) { r.hang = true }
+}
+
+// DropStreamAfter cuts StreamIn and StreamOut off with ErrStreamDropped
+// after n bytes. For Run and Attach the process starts, but waiting for it
+// fails with ErrStreamDropped, as when the connection to Garden is lost.
+func DropStreamAfter(n int) Fault {
+	return func(r *faultRule) {
+		r.drop = true
+		r.dropAfter = n
+	}
+}
+
+// Proxy is a garden.Backend that passes every call on to another Garden,
+// except where a fault has been injected. Serve it with NewProxyRunner and
+// point the rep or executor at it:
+//
+//	proxy := fakegarden.NewProxy(componentMaker.GardenClient())
+//	proxyRunner, proxyAddress := componentMaker.GardenProxy(proxy)
+//	proxy.Inject(fakegarden.CallDestroy, fakegarden.Hang())
+//	proxy.Inject(fakegarden.CallCreate, fakegarden.ReturnError(errors.New("boom")), fakegarden.Times(2))
+//	proxy.Inject(fakegarden.CallBulkMetrics, fakegarden.Delay(5*time.Second))
+//
+// When several faults match a call, the one injected first wins.
+type Proxy struct {
+	upstream garden.Client
+
+	lock    sync.Mutex
+	rules   map[Call][]*faultRule
+	calls   map[Call]int
+	release chan struct{}
+}
+
+func NewProxy(upstream garden.Client) *Proxy {
+	return &Proxy{
+		upstream: upstream,
+		rules:    map[Call][]*faultRule{},
+		calls:    map[Call]int{},
+		release:  make(chan struct{}),
+	}
+}
+
+// Inject adds a fault to call.
+func (p *Proxy) Inject(call Call, faults ...Fault) {
+	rule := &faultRule{}
+	for _, fault := range faults {
+		fault(rule)
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.rules[call] = append(p.rules[call], rule)
+}
+
+// Release lets every hung call go ahead.
+func (p *Proxy) Release() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	close(p.release)
+	p.release = make(chan struct{})
+}
+
+// Clear removes every fault and releases hung calls.
+func (p *Proxy) Clear() {
+	p.lock.Lock()
+	p.rules = map[Call][]*faultRule{}
+	p.lock.Unlock()
+
+	p.Release()
+}
+
+// CallCount returns how many times call has been made through the proxy.
+func (p *Proxy) CallCount(call Call) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.calls[call]
+}
+
+func (p *Proxy) Start() error {
+	return nil
+}
+
+func (p *Proxy) Stop() error {
+	p.Release()
+	return nil
+}
+
+// GraceTime leaves reaping idle containers to the upstream Garden.
+func (p *Proxy) GraceTime(garden.Container) time.Duration {
+	return 0
+}
+
+func (p *Proxy) Ping() error {
+	_, err := p.intercept(CallPing, "")
+	if err != nil {
+		return err
+	}
+	return p.upstream.Ping()
+}
+
+func (p *Proxy) Capacity() (garden.Capacity, error) {
+	_, err := p.intercept(CallCapacity, "")
+	if err != nil {
+		return garden.Capacity{}, err
+	}
+	return p.upstream.Capacity()
+}
+
+func (p *Proxy) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	_, err := p.intercept(CallCreate, spec.Handle)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := p.upstream.Create(spec)
+	if err != nil {
+		return nil, err
+	}
+	return &proxyContainer{Container: container, proxy: p}, nil
+}
+
+func (p *Proxy) Destroy(handle string) error {
+	_, err := p.intercept(CallDestroy, handle)
+	if err != nil {
+		return err
+	}
+	return p.upstream.Destroy(handle)
+}
+
+func (p *Proxy) Containers(properties garden.Properties) ([]garden.Container, error) {
+	_, err := p.intercept(CallContainers, "")
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := p.upstream.Containers(properties)
+	if err != nil {
+		return nil, err
+	}
+
+	proxied := make([]garden.Container, len(containers))
+	for i, container := range containers {
+		proxied[i] = &proxyContainer{Container: container, proxy: p}
+	}
+	return proxied, nil
+}
+
+func (p *Proxy) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	_, err := p.intercept(CallBulkInfo, "")
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := p.upstream.BulkInfo(handles)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, handle := range handles {
+		_, err := p.interceptEntry(CallBulkInfo, handle)
+		if err != nil {
+			infos[handle] = garden.ContainerInfoEntry{Err: garden.NewError(err.Error())}
+		}
+	}
+	return infos, nil
+}
+
+func (p *Proxy) BulkMetrics(handles []string) (map[string]garden.ContainerMetricsEntry, error) {
+	_, err := p.intercept(CallBulkMetrics, "")
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, err := p.upstream.BulkMetrics(handles)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, handle := range handles {
+		_, err := p.interceptEntry(CallBulkMetrics, handle)
+		if err != nil {
+			metrics[handle] = garden.ContainerMetricsEntry{Err: garden.NewError(err.Error())}
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Proxy) Lookup(handle string) (garden.Container, error) {
+	_, err := p.intercept(CallLookup, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := p.upstream.Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+	return &proxyContainer{Container: container, proxy: p}, nil
+}
+
+// intercept applies the first fault on call that is for handle or for no
+// handle in particular.
+func (p *Proxy) intercept(call Call, handle string) (*faultRule, error) {
+	p.lock.Lock()
+	p.calls[call]++
+	p.lock.Unlock()
+
+	return p.apply(p.match(call, func(rule *faultRule) bool {
+		return rule.handle == "" || rule.handle == handle
+	}))
+}
+
+// interceptEntry applies the first fault on call that is for handle itself,
+// for the entries of bulk calls.
+func (p *Proxy) interceptEntry(call Call, handle string) (*faultRule, error) {
+	return p.apply(p.match(call, func(rule *faultRule) bool {
+		return rule.handle == handle
+	}))
+}
+
+func (p *Proxy) match(call Call, matches func(*faultRule) bool) *faultRule {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, rule := range p.rules[call] {
+		if !matches(rule) || (rule.times > 0 && rule.used >= rule.times) {
+			continue
+		}
+		rule.used++
+		return rule
+	}
+	return nil
+}
+
+func (p *Proxy) apply(rule *faultRule) (*faultRule, error) {
+	if rule == nil {
+		return nil, nil
+	}
+
+	if rule.delay > 0 {
+		time.Sleep(rule.delay)
+	}
+
+	if rule.hang {
+		p.lock.Lock()
+		release := p.release
+		p.lock.Unlock()
+		<-release
+	}
+
+	return rule, rule.err
+}
+
+type proxyContainer struct {
+	garden.Container
+	proxy *Proxy
+}
+
+func (c *proxyContainer) Stop(kill bool) error {
+	_, err := c.proxy.intercept(CallStop, c.Handle())
+	if err != nil {
+		return err
+	}
+	return c.Container.Stop(kill)
+}
+
+func (c *proxyContainer) Info() (garden.ContainerInfo, error) {
+	_, err := c.proxy.intercept(CallInfo, c.Handle())
+	if err != nil {
+		return garden.ContainerInfo{}, err
+	}
+	return c.Container.Info()
+}
+
+func (c *proxyContainer) StreamIn(spec garden.StreamInSpec) error {
+	rule, err := c.proxy.intercept(CallStreamIn, c.Handle())
+	if err != nil {
+		return err
+	}
+
+	if rule != nil && rule.drop {
+		spec.TarStream = &droppingReader{reader: spec.TarStream, remaining: rule.dropAfter}
+	}
+	return c.Container.StreamIn(spec)
+}
+
+func (c *proxyContainer) StreamOut(spec garden.StreamOutSpec) (io.ReadCloser, error) {
+	rule, err := c.proxy.intercept(CallStreamOut, c.Handle())
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := c.Container.StreamOut(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule != nil && rule.drop {
+		return &droppingReadCloser{droppingReader: droppingReader{reader: stream, remaining: rule.dropAfter}, closer: stream}, nil
+	}
+	return stream, nil
+}
+
+func (c *proxyContainer) CurrentBandwidthLimits() (garden.BandwidthLimits, error) {
+	_, err := c.proxy.intercept(CallLimits, c.Handle())
+	if err != nil {
+		return garden.BandwidthLimits{}, err
+	}
+	return c.Container.CurrentBandwidthLimits()
+}
+
+func (c *proxyContainer) CurrentCPULimits() (garden.CPULimits, error) {
+	_, err := c.proxy.intercept(CallLimits, c.Handle())
+	if err != nil {
+		return garden.CPULimits{}, err
+	}
+	return c.Container.CurrentCPULimits()
+}
+
+func (c *proxyContainer) CurrentDiskLimits() (garden.DiskLimits, error) {
+	_, err := c.proxy.intercept(CallLimits, c.Handle())
+	if err != nil {
+		return garden.DiskLimits{}, err
+	}
+	return c.Container.CurrentDiskLimits()
+}
+
+func (c *proxyContainer) CurrentMemoryLimits() (garden.MemoryLimits, error) {
+	_, err := c.proxy.intercept(CallLimits, c.Handle())
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+	return c.Container.CurrentMemoryLimits()
+}
+
+func (c *proxyContainer) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
+	_, err := c.proxy.intercept(CallNetIn, c.Handle())
+	if err != nil {
+		return 0, 0, err
+	}
+	return c.Container.NetIn(hostPort, containerPort)
+}
+
+func (c *proxyContainer) NetOut(netOutRule garden.NetOutRule) error {
+	_, err := c.proxy.intercept(CallNetOut, c.Handle())
+	if err != nil {
+		return err
+	}
+	return c.Container.NetOut(netOutRule)
+}
+
+func (c *proxyContainer) BulkNetOut(netOutRules []garden.NetOutRule) error {
+	_, err := c.proxy.intercept(CallNetOut, c.Handle())
+	if err != nil {
+		return err
+	}
+	return c.Container.BulkNetOut(netOutRules)
+}
+
+func (c *proxyContainer) Run(spec garden.ProcessSpec, processIO garden.ProcessIO) (garden.Process, error) {
+	rule, err := c.proxy.intercept(CallRun, c.Handle())
+	if err != nil {
+		return nil, err
+	}
+
+	process, err := c.Container.Run(spec, processIO)
+	if err != nil {
+		return nil, err
+	}
+	return dropProcess(rule, process), nil
+}
+
+func (c *proxyContainer) Attach(processID string, processIO garden.ProcessIO) (garden.Process, error) {
+	rule, err := c.proxy.intercept(CallAttach, c.Handle())
+	if err != nil {
+		return nil, err
+	}
+
+	process, err := c.Container.Attach(processID, processIO)
+	if err != nil {
+		return nil, err
+	}
+	return dropProcess(rule, process), nil
+}
+
+func (c *proxyContainer) Metrics() (garden.Metrics, error) {
+	_, err := c.proxy.intercept(CallMetrics, c.Handle())
+	if err != nil {
+		return garden.Metrics{}, err
+	}
+	return c.Container.Metrics()
+}
+
+func (c *proxyContainer) SetGraceTime(graceTime time.Duration) error {
+	_, err := c.proxy.intercept(CallSetGraceTime, c.Handle())
+	if err != nil {
+		return err
+	}
+	return c.Container.SetGraceTime(graceTime)
+}
+
+func (c *proxyContainer) Properties() (garden.Properties, error) {
+	_, err := c.proxy.intercept(CallProperties, c.Handle())
+	if err != nil {
+		return nil, err
+	}
+	return c.Container.Properties()
+}
+
+func (c *proxyContainer) Property(name string) (string, error) {
+	_, err := c.proxy.intercept(CallProperties, c.Handle())
+	if err != nil {
+		return "", err
+	}
+	return c.Container.Property(name)
+}
+
+func (c *proxyContainer) SetProperty(name string, value string) error {
+	_, err := c.proxy.intercept(CallSetProperties, c.Handle())
+	if err != nil {
+		return err
+	}
+	return c.Container.SetProperty(name, value)
+}
+
+func (c *proxyContainer) RemoveProperty(name string) error {
+	_, err := c.proxy.intercept(CallSetProperties, c.Handle())
+	if err != nil {
+		return err
+	}
+	return c.Container.RemoveProperty(name)
+}
+
+func dropProcess(rule *faultRule, process garden.Process) garden.Process {
+	if rule == nil || !rule.drop {
+		return process
+	}
+	return droppedProcess{Process: process}
+}
+
+type droppedProcess struct {
+	garden.Process
+}
+
+func (droppedProcess) Wait() (int, error) {
+	return 0, ErrStreamDropped
+}
+
+type droppingReader struct {
+	reader    io.Reader
+	remaining int
+}
+
+func (r *droppingReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, ErrStreamDropped
+	}
+
+	if len(p) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.reader.Read(p)
+	r.remaining -= n
+	return n, err
+}
+
+type droppingReadCloser struct {
+	droppingReader
+	closer io.Closer
+}
+
+func (r *droppingReadCloser) Close() error {
+	return r.closer.Close()
+}
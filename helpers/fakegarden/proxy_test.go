@@ -0,0 +1,127 @@
+package fakegarden_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/inigo/helpers/fakegarden"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Proxy", func() {
+	var (
+		baseDir  string
+		upstream *fakegarden.Backend
+		proxy    *fakegarden.Proxy
+	)
+
+	BeforeEach(func() {
+		var err error
+		baseDir, err = ioutil.TempDir("", "fakegarden-proxy")
+		Expect(err).NotTo(HaveOccurred())
+
+		upstream = fakegarden.NewBackend(baseDir, fakegarden.DefaultCapacity)
+		Expect(upstream.Start()).To(Succeed())
+
+		proxy = fakegarden.NewProxy(upstream)
+	})
+
+	AfterEach(func() {
+		Expect(proxy.Stop()).To(Succeed())
+		Expect(upstream.Stop()).To(Succeed())
+		Expect(os.RemoveAll(baseDir)).To(Succeed())
+	})
+
+	It("passes calls through when no fault is injected", func() {
+		_, err := proxy.Create(garden.ContainerSpec{Handle: "passed-through"})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = upstream.Lookup("passed-through")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proxy.CallCount(fakegarden.CallCreate)).To(Equal(1))
+	})
+
+	It("returns injected errors for the first n calls only", func() {
+		proxy.Inject(fakegarden.CallCreate, fakegarden.ReturnError(errors.New("no subnets left")), fakegarden.Times(1))
+
+		_, err := proxy.Create(garden.ContainerSpec{Handle: "first"})
+		Expect(err).To(MatchError("no subnets left"))
+
+		_, err = proxy.Create(garden.ContainerSpec{Handle: "second"})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("only faults the calls about the given handle", func() {
+		for _, handle := range []string{"healthy", "sick"} {
+			_, err := proxy.Create(garden.ContainerSpec{Handle: handle})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		proxy.Inject(fakegarden.CallBulkMetrics, fakegarden.ForHandle("sick"), fakegarden.ReturnError(errors.New("cgroup gone")))
+
+		metrics, err := proxy.BulkMetrics([]string{"healthy", "sick"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(metrics["healthy"].Err).To(BeNil())
+		Expect(metrics["sick"].Err).To(MatchError("cgroup gone"))
+	})
+
+	It("delays calls", func() {
+		proxy.Inject(fakegarden.CallPing, fakegarden.Delay(100*time.Millisecond))
+
+		start := time.Now()
+		Expect(proxy.Ping()).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+
+	It("hangs calls until released", func() {
+		_, err := proxy.Create(garden.ContainerSpec{Handle: "stuck"})
+		Expect(err).NotTo(HaveOccurred())
+
+		proxy.Inject(fakegarden.CallDestroy, fakegarden.Hang())
+
+		destroyed := make(chan error, 1)
+		go func() { destroyed <- proxy.Destroy("stuck") }()
+
+		Consistently(destroyed, 200*time.Millisecond).ShouldNot(Receive())
+
+		proxy.Release()
+		Eventually(destroyed).Should(Receive(BeNil()))
+	})
+
+	It("drops streams part way through", func() {
+		container, err := proxy.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		in := new(bytes.Buffer)
+		tarWriter := tar.NewWriter(in)
+		content := bytes.Repeat([]byte("x"), 4096)
+		Expect(tarWriter.WriteHeader(&tar.Header{Name: "file", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})).To(Succeed())
+		_, err = tarWriter.Write(content)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tarWriter.Close()).To(Succeed())
+
+		proxy.Inject(fakegarden.CallStreamIn, fakegarden.DropStreamAfter(1024))
+
+		err = container.StreamIn(garden.StreamInSpec{Path: "/tmp", TarStream: in})
+		Expect(err).To(MatchError(fakegarden.ErrStreamDropped))
+	})
+
+	It("fails waiting for processes whose stream was dropped", func() {
+		container, err := proxy.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		proxy.Inject(fakegarden.CallRun, fakegarden.DropStreamAfter(0))
+
+		process, err := container.Run(garden.ProcessSpec{Path: "true"}, garden.ProcessIO{})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = process.Wait()
+		Expect(err).To(MatchError(fakegarden.ErrStreamDropped))
+	})
+})
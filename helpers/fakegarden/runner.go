@@ -0,0 +1,95 @@
+package fakegarden
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	gardenclient "code.cloudfoundry.org/garden/client"
+	gardenconnection "code.cloudfoundry.org/garden/client/connection"
+	"code.cloudfoundry.org/garden/server"
+	"code.cloudfoundry.org/lager"
+)
+
+// Runner serves a garden.Backend over the Garden protocol on a TCP address,
+// so that the rep and garden clients talk to it as they would to a real
+// Garden.
+type Runner struct {
+	Address string
+	Backend garden.Backend
+	Logger  lager.Logger
+
+	// StartTimeout bounds how long the server may take to answer a ping
+	StartTimeout time.Duration
+}
+
+// NewRunner serves a fresh Backend keeping its containers under baseDir.
+func NewRunner(logger lager.Logger, address, baseDir string) *Runner {
+	return &Runner{
+		Address:      address,
+		Backend:      NewBackend(baseDir, DefaultCapacity),
+		Logger:       logger,
+		StartTimeout: 10 * time.Second,
+	}
+}
+
+// NewProxyRunner serves proxy. The runner pings through the proxy to know it
+// is up, so inject Ping faults once it is ready. Stopping the runner releases
+// calls that the proxy has hung.
+func NewProxyRunner(logger lager.Logger, address string, proxy *Proxy) *Runner {
+	return &Runner{
+		Address:      address,
+		Backend:      proxy,
+		Logger:       logger,
+		StartTimeout: 10 * time.Second,
+	}
+}
+
+func (r *Runner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	err := r.Backend.Start()
+	if err != nil {
+		return err
+	}
+
+	gardenServer := server.New("tcp", r.Address, 0, r.Backend, r.Logger)
+
+	serveErrs := make(chan error, 1)
+	go func() {
+		serveErrs <- gardenServer.ListenAndServe()
+	}()
+
+	client := gardenclient.New(gardenconnection.New("tcp", r.Address))
+	deadline := time.Now().Add(r.StartTimeout)
+	for client.Ping() != nil {
+		if time.Now().After(deadline) {
+			r.stop(gardenServer)
+			return fmt.Errorf("fake garden did not answer a ping within %s", r.StartTimeout)
+		}
+
+		select {
+		case err := <-serveErrs:
+			return err
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+
+	r.Logger.Info("started", lager.Data{"address": r.Address})
+	close(ready)
+
+	select {
+	case <-signals:
+		r.stop(gardenServer)
+		return nil
+	case err := <-serveErrs:
+		r.Backend.Stop()
+		return err
+	}
+}
+
+// stop stops the backend first, since the server waits for calls in flight
+// and the proxy only lets hung calls go once stopped.
+func (r *Runner) stop(gardenServer *server.GardenServer) {
+	r.Backend.Stop()
+	gardenServer.Stop()
+}
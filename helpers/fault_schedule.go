@@ -0,0 +1,244 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/tedsuo/ifrit"
+)
+
+const faultScheduleExitTimeout = 30 * time.Second
+
+// FaultSchedule runs a timeline of faults (kills, freezes, restarts and waits)
+// against named ifrit processes, usually in the background while a spec
+// asserts invariants:
+//
+//	schedule := helpers.NewFaultSchedule().
+//		Target("auctioneer", auctioneer, func() ifrit.Runner { return componentMaker.Auctioneer() }).
+//		FreezeFor("auctioneer", 5*time.Second)
+//	errs := schedule.Start()
+//	...
+//	Eventually(errs).Should(Receive(BeNil()))
+//	auctioneer = schedule.Process("auctioneer")
+//
+// Steps run strictly in order; the first failing step aborts the timeline.
+type FaultSchedule struct {
+	lock    sync.Mutex
+	targets map[string]*faultTarget
+	steps   []faultStep
+}
+
+type faultTarget struct {
+	process ifrit.Process
+	runner  func() ifrit.Runner
+}
+
+type faultStep struct {
+	description string
+	action      func() error
+}
+
+func NewFaultSchedule() *FaultSchedule {
+	return &FaultSchedule{
+		targets: map[string]*faultTarget{},
+	}
+}
+
+// Target registers a running process under name. runner is used to build a
+// fresh runner when the process is restarted without an explicit runner; it
+// may be nil if the schedule never restarts the process.
+func (s *FaultSchedule) Target(name string, process ifrit.Process, runner func() ifrit.Runner) *FaultSchedule {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.targets[name] = &faultTarget{process: process, runner: runner}
+	return s
+}
+
+// Process returns the current process registered under name. Restarts
+// replace the process, so specs should use this to clean up afterwards.
+func (s *FaultSchedule) Process(name string) ifrit.Process {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	target, ok := s.targets[name]
+	if !ok {
+		return nil
+	}
+	return target.process
+}
+
+// Kill sends SIGKILL to the process and waits for it to exit.
+func (s *FaultSchedule) Kill(name string) *FaultSchedule {
+	return s.addStep("kill "+name, func() error {
+		return s.signalAndWait(name, os.Kill)
+	})
+}
+
+// Stop interrupts the process and waits for it to exit.
+func (s *FaultSchedule) Stop(name string) *FaultSchedule {
+	return s.addStep("stop "+name, func() error {
+		return s.signalAndWait(name, os.Interrupt)
+	})
+}
+
+// FreezeFor freezes the process, waits for duration and thaws it again.
+func (s *FaultSchedule) FreezeFor(name string, duration time.Duration) *FaultSchedule {
+	return s.Freeze(name).Wait(duration).Thaw(name)
+}
+
+// Restart stops the process if it is still running and starts a new one
+// from the runner registered with Target.
+func (s *FaultSchedule) Restart(name string) *FaultSchedule {
+	return s.addStep("restart "+name, func() error {
+		s.lock.Lock()
+		target, ok := s.targets[name]
+		s.lock.Unlock()
+		if !ok {
+			return fmt.Errorf("unknown fault target %q", name)
+		}
+		if target.runner == nil {
+			return fmt.Errorf("fault target %q has no runner to restart with", name)
+		}
+		return s.restart(name, target.runner())
+	})
+}
+
+// RestartWith stops the process if it is still running and starts runner in
+// its place, e.g. the same component built with a different config.
+func (s *FaultSchedule) RestartWith(name string, runner ifrit.Runner) *FaultSchedule {
+	return s.addStep("restart "+name+" with new config", func() error {
+		return s.restart(name, runner)
+	})
+}
+
+// Wait pauses the timeline for duration.
+func (s *FaultSchedule) Wait(duration time.Duration) *FaultSchedule {
+	return s.addStep(fmt.Sprintf("wait %s", duration), func() error {
+		time.Sleep(duration)
+		return nil
+	})
+}
+
+// WaitUntil polls predicate until it returns true, failing the timeline if
+// that does not happen within timeout.
+func (s *FaultSchedule) WaitUntil(description string, predicate func() bool, timeout time.Duration) *FaultSchedule {
+	return s.addStep("wait until "+description, func() error {
+		deadline := time.After(timeout)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for !predicate() {
+			select {
+			case <-deadline:
+				return fmt.Errorf("timed out after %s waiting until %s", timeout, description)
+			case <-ticker.C:
+			}
+		}
+		return nil
+	})
+}
+
+// Do runs an arbitrary action as part of the timeline.
+func (s *FaultSchedule) Do(description string, action func() error) *FaultSchedule {
+	return s.addStep(description, action)
+}
+
+// Run executes the timeline and blocks until it finishes.
+func (s *FaultSchedule) Run() error {
+	s.lock.Lock()
+	steps := append([]faultStep{}, s.steps...)
+	s.lock.Unlock()
+
+	start := time.Now()
+	for i, step := range steps {
+		fmt.Fprintf(GinkgoWriter, "[fault-schedule] +%s step %d/%d: %s\n", time.Since(start).Round(time.Millisecond), i+1, len(steps), step.description)
+
+		err := step.action()
+		if err != nil {
+			return fmt.Errorf("fault schedule step %d (%s) failed: %s", i+1, step.description, err)
+		}
+	}
+
+	fmt.Fprintf(GinkgoWriter, "[fault-schedule] +%s done\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Start runs the timeline in the background. The returned channel receives
+// the result of Run once the timeline finishes.
+func (s *FaultSchedule) Start() <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Run()
+	}()
+	return errs
+}
+
+func (s *FaultSchedule) addStep(description string, action func() error) *FaultSchedule {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.steps = append(s.steps, faultStep{description: description, action: action})
+	return s
+}
+
+func (s *FaultSchedule) signal(name string, signal os.Signal) error {
+	process := s.Process(name)
+	if process == nil {
+		return fmt.Errorf("unknown fault target %q", name)
+	}
+
+	process.Signal(signal)
+	return nil
+}
+
+func (s *FaultSchedule) signalAndWait(name string, signal os.Signal) error {
+	process := s.Process(name)
+	if process == nil {
+		return fmt.Errorf("unknown fault target %q", name)
+	}
+
+	process.Signal(signal)
+
+	select {
+	case <-process.Wait():
+		return nil
+	case <-time.After(faultScheduleExitTimeout):
+		return fmt.Errorf("%s did not exit within %s after %s", name, faultScheduleExitTimeout, signal)
+	}
+}
+
+func (s *FaultSchedule) restart(name string, runner ifrit.Runner) error {
+	process := s.Process(name)
+	if process != nil {
+		select {
+		case <-process.Wait():
+		default:
+			err := s.signalAndWait(name, os.Interrupt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	newProcess := ifrit.Background(runner)
+	select {
+	case <-newProcess.Ready():
+	case err := <-newProcess.Wait():
+		return fmt.Errorf("%s exited while restarting: %v", name, err)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	target, ok := s.targets[name]
+	if !ok {
+		target = &faultTarget{}
+		s.targets[name] = target
+	}
+	target.process = newProcess
+	return nil
+}
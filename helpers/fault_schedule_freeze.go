@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+package helpers
+
+import "syscall"
+
+// Freeze sends SIGSTOP to the process.
+func (s *FaultSchedule) Freeze(name string) *FaultSchedule {
+	return s.addStep("freeze "+name, func() error {
+		return s.signal(name, syscall.SIGSTOP)
+	})
+}
+
+// Thaw sends SIGCONT to a previously frozen process.
+func (s *FaultSchedule) Thaw(name string) *FaultSchedule {
+	return s.addStep("thaw "+name, func() error {
+		return s.signal(name, syscall.SIGCONT)
+	})
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package helpers_test
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/inigo/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("FaultSchedule freezing", func() {
+	It("sends SIGSTOP and then SIGCONT", func() {
+		log := &eventLog{}
+		process := ifrit.Invoke(recordingRunner(log, "component"))
+		defer func() {
+			process.Signal(os.Kill)
+			Eventually(process.Wait()).Should(Receive())
+		}()
+
+		err := helpers.NewFaultSchedule().
+			Target("component", process, nil).
+			FreezeFor("component", 10*time.Millisecond).
+			Run()
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(log.Events).Should(Equal([]string{
+			"start component",
+			"component " + syscall.SIGSTOP.String(),
+			"component " + syscall.SIGCONT.String(),
+		}))
+	})
+})
@@ -0,0 +1,19 @@
+package helpers
+
+import "errors"
+
+var errFreezeUnsupported = errors.New("freezing processes is not supported on windows")
+
+// Freeze fails the timeline; windows has no SIGSTOP.
+func (s *FaultSchedule) Freeze(name string) *FaultSchedule {
+	return s.addStep("freeze "+name, func() error {
+		return errFreezeUnsupported
+	})
+}
+
+// Thaw fails the timeline; windows has no SIGCONT.
+func (s *FaultSchedule) Thaw(name string) *FaultSchedule {
+	return s.addStep("thaw "+name, func() error {
+		return errFreezeUnsupported
+	})
+}
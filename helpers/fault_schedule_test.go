@@ -0,0 +1,130 @@
+package helpers_test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/inigo/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+type eventLog struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) Events() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]string{}, l.events...)
+}
+
+// recordingRunner logs when it starts and every signal it receives, and
+// exits on os.Kill or os.Interrupt.
+func recordingRunner(log *eventLog, name string) ifrit.Runner {
+	return ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		log.add("start " + name)
+		close(ready)
+		for signal := range signals {
+			log.add(name + " " + signal.String())
+			if signal == os.Kill || signal == os.Interrupt {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+var _ = Describe("FaultSchedule", func() {
+	var (
+		log      *eventLog
+		process  ifrit.Process
+		schedule *helpers.FaultSchedule
+	)
+
+	BeforeEach(func() {
+		log = &eventLog{}
+		process = ifrit.Invoke(recordingRunner(log, "component"))
+		schedule = helpers.NewFaultSchedule().Target("component", process, func() ifrit.Runner {
+			return recordingRunner(log, "component")
+		})
+	})
+
+	AfterEach(func() {
+		if current := schedule.Process("component"); current != nil {
+			current.Signal(os.Kill)
+			Eventually(current.Wait()).Should(Receive())
+		}
+	})
+
+	It("runs its steps in order", func() {
+		err := schedule.
+			Kill("component").
+			Do("note the kill", func() error { log.add("after kill"); return nil }).
+			Restart("component").
+			WaitUntil("the component restarted", func() bool {
+				return len(log.Events()) >= 4
+			}, time.Second).
+			Do("note the wait", func() error { log.add("after wait"); return nil }).
+			Run()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(log.Events()).To(Equal([]string{
+			"start component",
+			"component " + os.Kill.String(),
+			"after kill",
+			"start component",
+			"after wait",
+		}))
+	})
+
+	It("replaces the process on restart", func() {
+		Expect(schedule.Restart("component").Run()).To(Succeed())
+
+		Eventually(process.Wait()).Should(Receive())
+		restarted := schedule.Process("component")
+		Expect(restarted).NotTo(Equal(process))
+		Consistently(restarted.Wait()).ShouldNot(Receive())
+	})
+
+	It("restarts with the given runner", func() {
+		Expect(schedule.RestartWith("component", recordingRunner(log, "reconfigured")).Run()).To(Succeed())
+		Expect(log.Events()).To(ContainElement("start reconfigured"))
+	})
+
+	It("stops at the first failing step", func() {
+		err := schedule.
+			Do("fail", func() error { return errors.New("boom") }).
+			Kill("component").
+			Run()
+		Expect(err).To(MatchError("fault schedule step 1 (fail) failed: boom"))
+		Consistently(process.Wait()).ShouldNot(Receive())
+	})
+
+	It("fails when WaitUntil times out", func() {
+		err := schedule.WaitUntil("never", func() bool { return false }, 200*time.Millisecond).Run()
+		Expect(err).To(MatchError(ContainSubstring("timed out after 200ms waiting until never")))
+	})
+
+	It("fails on unknown targets", func() {
+		err := schedule.Kill("bogus").Run()
+		Expect(err).To(MatchError(ContainSubstring(`unknown fault target "bogus"`)))
+	})
+
+	It("runs in the background with Start", func() {
+		errs := schedule.Wait(100 * time.Millisecond).Kill("component").Start()
+		Consistently(process.Wait(), 50*time.Millisecond).ShouldNot(Receive())
+		Eventually(errs).Should(Receive(BeNil()))
+		Eventually(process.Wait()).Should(Receive())
+	})
+})
@@ -0,0 +1,480 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// FaultServer serves artifacts with injected faults, and accepts uploads,
+// so that download and upload specs can go beyond the happy path.
+//
+//	server := helpers.NewFaultServer(os.Getenv("EXTERNAL_ADDRESS"))
+//	server.Serve("/lrp.zip", content, helpers.FailFirst(2, http.StatusServiceUnavailable))
+//	... DownloadAction{From: server.URL("/lrp.zip")} ...
+//	Expect(server.RequestCount("/lrp.zip")).To(Equal(3))
+type FaultServer struct {
+	server *httptest.Server
+
+	lock     sync.Mutex
+	routes   map[string]*faultRoute
+	requests map[string]int
+	log      []RecordedRequest
+	uploads  []Upload
+	errors   []error
+}
+
+// RecordedRequest is a request the fault server received, with the status it
+// was answered with. Connections cut short by a fault still count as 200.
+type RecordedRequest struct {
+	Method     string
+	Path       string
+	Header     http.Header
+	Status     int
+	ReceivedAt time.Time
+}
+
+// Conditional reports whether the request asked to revalidate a cached copy.
+func (r RecordedRequest) Conditional() bool {
+	return r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != ""
+}
+
+// Fault changes how a route responds.
+type Fault func(*faultConfig)
+
+type faultConfig struct {
+	failFirst          int
+	failStatus         int
+	redirects          int
+	delay              time.Duration
+	bytesPerSecond     int
+	truncateAt         int
+	resetAt            int
+	contentLengthDelta int
+	noCacheHeaders     bool
+	uploadStatus       int
+}
+
+type faultRoute struct {
+	content      []byte
+	lastModified time.Time
+	upload       bool
+	config       faultConfig
+}
+
+// Upload is a request received by a route set up with AcceptUploads.
+type Upload struct {
+	Path   string
+	Method string
+	Header http.Header
+	// Body is the raw request body
+	Body []byte
+	// Parts holds the parts of a multipart body, in order
+	Parts []UploadPart
+}
+
+type UploadPart struct {
+	FormName string
+	FileName string
+	Header   map[string][]string
+	Body     []byte
+}
+
+// FailFirst responds to the first n requests with status, then behaves
+// normally.
+func FailFirst(n, status int) Fault {
+	return func(c *faultConfig) {
+		c.failFirst = n
+		c.failStatus = status
+	}
+}
+
+// RedirectChain redirects every request through hops redirects before
+// serving the artifact.
+func RedirectChain(hops int) Fault {
+	return func(c *faultConfig) { c.redirects = hops }
+}
+
+// Delay waits before sending the response headers.
+func Delay(d time.Duration) Fault {
+	return func(c *faultConfig) { c.delay = d }
+}
+
+// Throttle trickles the body out at bytesPerSecond, slow-loris style.
+func Throttle(bytesPerSecond int) Fault {
+	return func(c *faultConfig) { c.bytesPerSecond = bytesPerSecond }
+}
+
+// Truncate closes the connection cleanly after n bytes of the body, while
+// still announcing the full Content-Length.
+func Truncate(n int) Fault {
+	return func(c *faultConfig) { c.truncateAt = n }
+}
+
+// ResetAfter resets the connection (TCP RST) after n bytes of the body.
+func ResetAfter(n int) Fault {
+	return func(c *faultConfig) { c.resetAt = n }
+}
+
+// WrongContentLength announces a Content-Length that is off by delta bytes
+// from the body actually sent.
+func WrongContentLength(delta int) Fault {
+	return func(c *faultConfig) { c.contentLengthDelta = delta }
+}
+
+// NoCacheHeaders leaves out ETag and Last-Modified, so responses cannot be
+// cached or revalidated.
+func NoCacheHeaders() Fault {
+	return func(c *faultConfig) { c.noCacheHeaders = true }
+}
+
+// UploadStatus sets the status uploads are acknowledged with once any
+// FailFirst failures are used up. It defaults to 201 Created.
+func UploadStatus(status int) Fault {
+	return func(c *faultConfig) { c.uploadStatus = status }
+}
+
+// NewFaultServer starts a plain HTTP fault server listening on listenHost,
+// e.g. $EXTERNAL_ADDRESS so that containers can reach it.
+func NewFaultServer(listenHost string) *FaultServer {
+	s := newFaultServer(listenHost)
+	s.server.Start()
+	return s
+}
+
+// NewTLSFaultServer starts an HTTPS fault server with the given certificate,
+// e.g. one from certauthority's GenerateExpiredCertAndKey or
+// GenerateUntrustedCertAndKey.
+func NewTLSFaultServer(listenHost, certFile, keyFile string) *FaultServer {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	Expect(err).NotTo(HaveOccurred())
+
+	s := newFaultServer(listenHost)
+	s.server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	s.server.StartTLS()
+	return s
+}
+
+func newFaultServer(listenHost string) *FaultServer {
+	listener, err := net.Listen("tcp", listenHost+":0")
+	Expect(err).NotTo(HaveOccurred())
+
+	s := &FaultServer{
+		routes:   map[string]*faultRoute{},
+		requests: map[string]int{},
+	}
+
+	s.server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer GinkgoRecover()
+		s.handle(w, r)
+	}))
+	s.server.Listener = listener
+
+	return s
+}
+
+// URL returns the URL path is served from.
+func (s *FaultServer) URL(path string) string {
+	return s.server.URL + path
+}
+
+// Serve serves content at path for GET and HEAD requests.
+func (s *FaultServer) Serve(path string, content []byte, faults ...Fault) {
+	s.addRoute(path, &faultRoute{content: content, lastModified: time.Now()}, faults)
+}
+
+// AcceptUploads records POST and PUT requests to path. See Uploads.
+func (s *FaultServer) AcceptUploads(path string, faults ...Fault) {
+	s.addRoute(path, &faultRoute{upload: true}, faults)
+}
+
+// RequestCount returns how many requests path has received, redirects
+// included.
+func (s *FaultServer) RequestCount(path string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.requests[path]
+}
+
+// Requests returns every request path has received so far, in order.
+func (s *FaultServer) Requests(path string) []RecordedRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	requests := []RecordedRequest{}
+	for _, request := range s.log {
+		if request.Path == path {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// Uploads returns every upload received so far, in order.
+func (s *FaultServer) Uploads() []Upload {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Upload{}, s.uploads...)
+}
+
+// Errors returns whatever went wrong on the server's side while serving,
+// e.g. a fault it could not inject. The server answers those requests with a
+// 500, since it cannot fail the spec from its own goroutines.
+func (s *FaultServer) Errors() []error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]error{}, s.errors...)
+}
+
+func (s *FaultServer) Close() {
+	s.server.Close()
+}
+
+func (s *FaultServer) addRoute(path string, route *faultRoute, faults []Fault) {
+	route.config = faultConfig{
+		truncateAt:   -1,
+		resetAt:      -1,
+		uploadStatus: http.StatusCreated,
+	}
+	for _, fault := range faults {
+		fault(&route.config)
+	}
+
+	s.lock.Lock()
+	s.routes[path] = route
+	s.lock.Unlock()
+}
+
+func (s *FaultServer) handle(w http.ResponseWriter, r *http.Request) {
+	received := time.Now()
+
+	s.lock.Lock()
+	route, ok := s.routes[r.URL.Path]
+	s.requests[r.URL.Path]++
+	attempt := s.requests[r.URL.Path]
+	s.lock.Unlock()
+
+	status := http.StatusNotFound
+	if ok {
+		status = s.respond(w, r, route, attempt)
+	} else {
+		w.WriteHeader(status)
+	}
+
+	s.lock.Lock()
+	s.log = append(s.log, RecordedRequest{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Header:     r.Header,
+		Status:     status,
+		ReceivedAt: received,
+	})
+	s.lock.Unlock()
+}
+
+func (s *FaultServer) respond(w http.ResponseWriter, r *http.Request, route *faultRoute, attempt int) int {
+	config := route.config
+	if attempt <= config.failFirst {
+		w.WriteHeader(config.failStatus)
+		return config.failStatus
+	}
+
+	if config.redirects > 0 {
+		hop, _ := strconv.Atoi(r.URL.Query().Get("hop"))
+		if hop < config.redirects {
+			http.Redirect(w, r, fmt.Sprintf("%s?hop=%d", r.URL.Path, hop+1), http.StatusFound)
+			return http.StatusFound
+		}
+	}
+
+	time.Sleep(config.delay)
+
+	if route.upload {
+		return s.recordUpload(w, r, config)
+	}
+
+	switch r.Method {
+	case "GET", "HEAD":
+		return s.serveContent(w, r, route)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return http.StatusMethodNotAllowed
+	}
+}
+
+func (s *FaultServer) serveContent(w http.ResponseWriter, r *http.Request, route *faultRoute) int {
+	config := route.config
+	header := w.Header()
+	header.Set("Content-Type", "application/octet-stream")
+
+	if !config.noCacheHeaders {
+		etag := fmt.Sprintf(`"%x"`, md5.Sum(route.content))
+		header.Set("ETag", etag)
+		header.Set("Last-Modified", route.lastModified.UTC().Format(http.TimeFormat))
+
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return http.StatusNotModified
+		}
+	}
+
+	body := route.content
+	closeAt := -1
+	for _, at := range []int{config.truncateAt, config.resetAt} {
+		if at >= 0 && at < len(body) && (closeAt < 0 || at < closeAt) {
+			closeAt = at
+		}
+	}
+
+	contentLength := len(route.content) + config.contentLengthDelta
+	if closeAt < 0 && config.contentLengthDelta == 0 {
+		header.Set("Content-Length", strconv.Itoa(contentLength))
+		w.WriteHeader(http.StatusOK)
+		if r.Method != "HEAD" {
+			writeThrottled(w, body, config.bytesPerSecond)
+		}
+		return http.StatusOK
+	}
+
+	// the remaining faults break HTTP framing, so write the response by hand
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return s.fail(w, errors.New("fault server connection cannot be hijacked"))
+	}
+
+	conn, buf, err := hijacker.Hijack()
+	if err != nil {
+		return s.fail(w, err)
+	}
+	defer conn.Close()
+
+	header.Set("Content-Length", strconv.Itoa(contentLength))
+	fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\n")
+	header.Write(buf)
+	fmt.Fprintf(buf, "\r\n")
+
+	if r.Method != "HEAD" {
+		if closeAt >= 0 {
+			body = body[:closeAt]
+		}
+		writeThrottled(buf, body, config.bytesPerSecond)
+	}
+	buf.Flush()
+
+	if closeAt >= 0 && closeAt == config.resetAt {
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			tcpConn.SetLinger(0)
+		}
+	}
+	return http.StatusOK
+}
+
+func (s *FaultServer) fail(w http.ResponseWriter, err error) int {
+	s.lock.Lock()
+	s.errors = append(s.errors, err)
+	s.lock.Unlock()
+
+	w.WriteHeader(http.StatusInternalServerError)
+	return http.StatusInternalServerError
+}
+
+func (s *FaultServer) recordUpload(w http.ResponseWriter, r *http.Request, config faultConfig) int {
+	if r.Method != "POST" && r.Method != "PUT" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return http.StatusMethodNotAllowed
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return http.StatusBadRequest
+	}
+
+	upload := Upload{
+		Path:   r.URL.Path,
+		Method: r.Method,
+		Header: r.Header,
+		Body:   body,
+	}
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil && strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+
+			partBody, _ := ioutil.ReadAll(part)
+			upload.Parts = append(upload.Parts, UploadPart{
+				FormName: part.FormName(),
+				FileName: part.FileName(),
+				Header:   part.Header,
+				Body:     partBody,
+			})
+		}
+	}
+
+	s.lock.Lock()
+	s.uploads = append(s.uploads, upload)
+	s.lock.Unlock()
+
+	w.WriteHeader(config.uploadStatus)
+	return config.uploadStatus
+}
+
+func writeThrottled(w io.Writer, body []byte, bytesPerSecond int) {
+	if bytesPerSecond <= 0 {
+		w.Write(body)
+		return
+	}
+
+	chunkSize := bytesPerSecond / 10
+	if chunkSize == 0 {
+		chunkSize = 1
+	}
+	interval := time.Second * time.Duration(chunkSize) / time.Duration(bytesPerSecond)
+
+	for len(body) > 0 {
+		n := chunkSize
+		if n > len(body) {
+			n = len(body)
+		}
+
+		_, err := w.Write(body[:n])
+		if err != nil {
+			return
+		}
+		flush(w)
+
+		body = body[n:]
+		time.Sleep(interval)
+	}
+}
+
+func flush(w io.Writer) {
+	switch f := w.(type) {
+	case http.Flusher:
+		f.Flush()
+	case *bufio.ReadWriter:
+		f.Flush()
+	}
+}
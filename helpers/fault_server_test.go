@@ -0,0 +1,330 @@
+package helpers_test
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/helpers/certauthority"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FaultServer", func() {
+	const path = "/artifact"
+
+	var (
+		server  *helpers.FaultServer
+		client  *http.Client
+		content []byte
+	)
+
+	get := func(header ...string) *http.Response {
+		request, err := http.NewRequest("GET", server.URL(path), nil)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i+1 < len(header); i += 2 {
+			request.Header.Set(header[i], header[i+1])
+		}
+
+		response, err := client.Do(request)
+		Expect(err).NotTo(HaveOccurred())
+		return response
+	}
+
+	readBody := func(response *http.Response) ([]byte, error) {
+		defer response.Body.Close()
+		return ioutil.ReadAll(response.Body)
+	}
+
+	BeforeEach(func() {
+		server = helpers.NewFaultServer("127.0.0.1")
+		client = &http.Client{}
+		content = bytes.Repeat([]byte("0123456789"), 10)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(server.Errors()).To(BeEmpty())
+	})
+
+	Describe("serving", func() {
+		BeforeEach(func() {
+			server.Serve(path, content)
+		})
+
+		It("serves the content with cache headers", func() {
+			response := get()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header.Get("ETag")).NotTo(BeEmpty())
+			Expect(response.Header.Get("Last-Modified")).NotTo(BeEmpty())
+			Expect(readBody(response)).To(Equal(content))
+		})
+
+		It("answers revalidations of the current content with a 304", func() {
+			etag := get().Header.Get("ETag")
+
+			response := get("If-None-Match", etag)
+			Expect(response.StatusCode).To(Equal(http.StatusNotModified))
+
+			requests := server.Requests(path)
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].Conditional()).To(BeFalse())
+			Expect(requests[1].Conditional()).To(BeTrue())
+			Expect(requests[1].Status).To(Equal(http.StatusNotModified))
+		})
+
+		It("does not serve other paths", func() {
+			response, err := client.Get(server.URL("/other"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(server.RequestCount("/other")).To(Equal(1))
+		})
+	})
+
+	Describe("FailFirst", func() {
+		BeforeEach(func() {
+			server.Serve(path, content, helpers.FailFirst(2, http.StatusServiceUnavailable))
+		})
+
+		It("fails the first requests, then serves the content", func() {
+			Expect(get().StatusCode).To(Equal(http.StatusServiceUnavailable))
+			Expect(get().StatusCode).To(Equal(http.StatusServiceUnavailable))
+
+			response := get()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(readBody(response)).To(Equal(content))
+			Expect(server.RequestCount(path)).To(Equal(3))
+		})
+	})
+
+	Describe("RedirectChain", func() {
+		BeforeEach(func() {
+			server.Serve(path, content, helpers.RedirectChain(3))
+		})
+
+		It("redirects through every hop before serving the content", func() {
+			var hops int
+			client.CheckRedirect = func(*http.Request, []*http.Request) error {
+				hops++
+				return nil
+			}
+
+			response := get()
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(readBody(response)).To(Equal(content))
+			Expect(hops).To(Equal(3))
+			Expect(server.RequestCount(path)).To(Equal(4))
+		})
+	})
+
+	Describe("Delay", func() {
+		BeforeEach(func() {
+			server.Serve(path, content, helpers.Delay(200*time.Millisecond))
+		})
+
+		It("holds back the response", func() {
+			start := time.Now()
+			response := get()
+			Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+			Expect(readBody(response)).To(Equal(content))
+		})
+	})
+
+	Describe("Throttle", func() {
+		BeforeEach(func() {
+			server.Serve(path, content, helpers.Throttle(200))
+		})
+
+		It("trickles the body out", func() {
+			start := time.Now()
+			Expect(readBody(get())).To(Equal(content))
+			Expect(time.Since(start)).To(BeNumerically(">=", 400*time.Millisecond))
+		})
+	})
+
+	Describe("Truncate", func() {
+		BeforeEach(func() {
+			server.Serve(path, content, helpers.Truncate(10))
+		})
+
+		It("closes the connection early while announcing the full length", func() {
+			response := get()
+			Expect(response.ContentLength).To(Equal(int64(len(content))))
+
+			body, err := readBody(response)
+			Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+			Expect(body).To(Equal(content[:10]))
+		})
+	})
+
+	Describe("ResetAfter", func() {
+		BeforeEach(func() {
+			server.Serve(path, content, helpers.ResetAfter(10))
+		})
+
+		It("resets the connection part way through the body", func() {
+			_, err := readBody(get())
+			Expect(errors.Is(err, syscall.ECONNRESET)).To(BeTrue(), "expected a connection reset, got %v", err)
+		})
+	})
+
+	Describe("WrongContentLength", func() {
+		It("cuts the body short when the length is too small", func() {
+			server.Serve(path, content, helpers.WrongContentLength(-10))
+
+			response := get()
+			Expect(response.ContentLength).To(Equal(int64(len(content) - 10)))
+			Expect(readBody(response)).To(Equal(content[:len(content)-10]))
+		})
+
+		It("leaves the body incomplete when the length is too large", func() {
+			server.Serve(path, content, helpers.WrongContentLength(10))
+
+			response := get()
+			Expect(response.ContentLength).To(Equal(int64(len(content) + 10)))
+
+			body, err := readBody(response)
+			Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+			Expect(body).To(Equal(content))
+		})
+	})
+
+	Describe("NoCacheHeaders", func() {
+		BeforeEach(func() {
+			server.Serve(path, content, helpers.NoCacheHeaders())
+		})
+
+		It("leaves out the cache headers and serves revalidations in full", func() {
+			response := get("If-None-Match", `"anything"`)
+			Expect(response.StatusCode).To(Equal(http.StatusOK))
+			Expect(response.Header).NotTo(HaveKey("Etag"))
+			Expect(response.Header).NotTo(HaveKey("Last-Modified"))
+			Expect(readBody(response)).To(Equal(content))
+		})
+	})
+
+	Describe("AcceptUploads", func() {
+		It("records multipart uploads part by part", func() {
+			server.AcceptUploads(path)
+
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			part, err := writer.CreateFormFile("upload", "droplet.tgz")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = part.Write([]byte("droplet bits"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(writer.Close()).To(Succeed())
+
+			response, err := client.Post(server.URL(path), writer.FormDataContentType(), body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.StatusCode).To(Equal(http.StatusCreated))
+
+			uploads := server.Uploads()
+			Expect(uploads).To(HaveLen(1))
+			Expect(uploads[0].Method).To(Equal("POST"))
+			Expect(uploads[0].Parts).To(HaveLen(1))
+			Expect(uploads[0].Parts[0].FormName).To(Equal("upload"))
+			Expect(uploads[0].Parts[0].FileName).To(Equal("droplet.tgz"))
+			Expect(uploads[0].Parts[0].Body).To(Equal([]byte("droplet bits")))
+		})
+
+		It("records raw uploads and acknowledges them with the configured status", func() {
+			server.AcceptUploads(path, helpers.FailFirst(1, http.StatusInternalServerError), helpers.UploadStatus(http.StatusAccepted))
+
+			put := func() int {
+				request, err := http.NewRequest("PUT", server.URL(path), bytes.NewReader(content))
+				Expect(err).NotTo(HaveOccurred())
+				response, err := client.Do(request)
+				Expect(err).NotTo(HaveOccurred())
+				return response.StatusCode
+			}
+
+			Expect(put()).To(Equal(http.StatusInternalServerError))
+			Expect(put()).To(Equal(http.StatusAccepted))
+
+			uploads := server.Uploads()
+			Expect(uploads).To(HaveLen(1))
+			Expect(uploads[0].Method).To(Equal("PUT"))
+			Expect(uploads[0].Body).To(Equal(content))
+			Expect(uploads[0].Parts).To(BeEmpty())
+		})
+
+		It("does not accept downloads", func() {
+			server.AcceptUploads(path)
+			Expect(get().StatusCode).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Describe("NewTLSFaultServer", func() {
+		var (
+			depotDir  string
+			authority certauthority.CertAuthority
+		)
+
+		tlsServer := func(generate func(string, []string) (string, string, error)) *helpers.FaultServer {
+			keyFile, certFile, err := generate("fault-server", []string{"127.0.0.1"})
+			Expect(err).NotTo(HaveOccurred())
+
+			tlsServer := helpers.NewTLSFaultServer("127.0.0.1", certFile, keyFile)
+			tlsServer.Serve(path, content)
+			return tlsServer
+		}
+
+		BeforeEach(func() {
+			var err error
+			depotDir, err = ioutil.TempDir("", "fault-server-depot")
+			Expect(err).NotTo(HaveOccurred())
+
+			authority, err = certauthority.NewCertAuthority(depotDir, "fault-server-ca")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, caFile := authority.CAAndKey()
+			caCert, err := ioutil.ReadFile(caFile)
+			Expect(err).NotTo(HaveOccurred())
+			rootCAs := x509.NewCertPool()
+			Expect(rootCAs.AppendCertsFromPEM(caCert)).To(BeTrue())
+
+			client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(depotDir)).To(Succeed())
+		})
+
+		It("serves with a certificate from the authority", func() {
+			tlsServer := tlsServer(func(commonName string, sans []string) (string, string, error) {
+				return authority.GenerateSelfSignedCertAndKey(commonName, sans, false)
+			})
+			defer tlsServer.Close()
+
+			response, err := client.Get(tlsServer.URL(path))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(readBody(response)).To(Equal(content))
+		})
+
+		It("serves with an expired certificate", func() {
+			tlsServer := tlsServer(authority.GenerateExpiredCertAndKey)
+			defer tlsServer.Close()
+
+			_, err := client.Get(tlsServer.URL(path))
+			Expect(err).To(MatchError(ContainSubstring("expired")))
+		})
+
+		It("serves with a certificate the authority did not sign", func() {
+			tlsServer := tlsServer(authority.GenerateUntrustedCertAndKey)
+			defer tlsServer.Close()
+
+			_, err := client.Get(tlsServer.URL(path))
+			Expect(err).To(MatchError(ContainSubstring("unknown authority")))
+		})
+	})
+})
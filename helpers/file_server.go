@@ -0,0 +1,203 @@
+package helpers
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	"code.cloudfoundry.org/inigo/fixtures"
+	. "github.com/onsi/gomega"
+)
+
+// FileServerHandle publishes files through a file server started from
+// ComponentMaker.FileServer, and tells specs where to download them from and
+// what checksums to expect.
+//
+//	fileServer, staticDir := componentMaker.FileServer()
+//	handle := helpers.NewFileServerHandle(staticDir, componentMaker.Addresses().FileServer)
+//	app := handle.PublishArchive("app.zip", fixtures.GoServerApp(), fixtures.Zip)
+type FileServerHandle struct {
+	StaticDir string
+	Address   string
+
+	// TLS configures the HTTPS proxy started by StartTLS. Set it (and adjust
+	// e.g. ClientAuth) before calling StartTLS.
+	TLS *tls.Config
+
+	tlsServer *httptest.Server
+}
+
+// PublishedFile is a file served by the file server.
+type PublishedFile struct {
+	Name string
+	// Path is where the file lives on disk
+	Path string
+	URL  string
+	// HTTPSURL is only set once the handle's TLS proxy has been started
+	HTTPSURL string
+
+	// hex encoded digests, as used in a DownloadAction's ChecksumValue
+	MD5    string
+	SHA1   string
+	SHA256 string
+
+	// ETag is the quoted md5 digest
+	ETag string
+	Size int64
+}
+
+func NewFileServerHandle(staticDir, address string) *FileServerHandle {
+	return &FileServerHandle{
+		StaticDir: staticDir,
+		Address:   address,
+	}
+}
+
+// Publish writes content to name in the static directory. name may contain
+// slashes to publish into a subdirectory.
+func (h *FileServerHandle) Publish(name string, content []byte) PublishedFile {
+	filePath := h.pathFor(name)
+
+	err := ioutil.WriteFile(filePath, content, 0644)
+	Expect(err).NotTo(HaveOccurred())
+
+	return h.published(name)
+}
+
+// PublishArchive packages files in format (Zip or TGZ) and publishes the
+// archive as name. Loose files do not say how to start them, so publish
+// droplets with PublishApp or PublishDroplet instead.
+func (h *FileServerHandle) PublishArchive(name string, files []archive_helper.ArchiveFile, format fixtures.Format) PublishedFile {
+	filePath := h.pathFor(name)
+
+	switch format {
+	case fixtures.Zip:
+		archive_helper.CreateZipArchive(filePath, files)
+	case fixtures.TGZ:
+		archive_helper.CreateTarGZArchive(filePath, files)
+	default:
+		Expect(format).To(BeElementOf(fixtures.Zip, fixtures.TGZ), "PublishArchive only makes zips and tgzs, see PublishApp and PublishDroplet")
+	}
+
+	return h.published(name)
+}
+
+// PublishApp packages the named fixture app (see fixtures.Package) in format
+// and publishes it as name. A Droplet starts the app as its web process.
+func (h *FileServerHandle) PublishApp(name, app string, format fixtures.Format) PublishedFile {
+	fixtures.Package(app, format, h.pathFor(name))
+	return h.published(name)
+}
+
+// PublishDroplet writes droplet and publishes it as name.
+func (h *FileServerHandle) PublishDroplet(name string, droplet *fixtures.DropletBuilder) PublishedFile {
+	droplet.WriteTo(h.pathFor(name))
+	return h.published(name)
+}
+
+// URL returns the plain HTTP URL that name is served from.
+func (h *FileServerHandle) URL(name string) string {
+	return h.urlFor("http", h.Address, name)
+}
+
+// StartTLS starts an HTTPS proxy in front of the file server, configured by
+// h.TLS. Files published from then on have an HTTPSURL, and HTTPSURL can be
+// used for files published earlier.
+func (h *FileServerHandle) StartTLS() {
+	Expect(h.TLS).NotTo(BeNil(), "set FileServerHandle.TLS before starting it")
+	Expect(h.tlsServer).To(BeNil(), "the file server TLS proxy is already running")
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: h.Address})
+	h.tlsServer = httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		proxy.ServeHTTP(rw, req)
+	}))
+	h.tlsServer.TLS = h.TLS
+	h.tlsServer.StartTLS()
+}
+
+// HTTPSURL returns the URL name is served from through the TLS proxy.
+func (h *FileServerHandle) HTTPSURL(name string) string {
+	Expect(h.tlsServer).NotTo(BeNil(), "the file server TLS proxy has not been started")
+
+	tlsURL, err := url.Parse(h.tlsServer.URL)
+	Expect(err).NotTo(HaveOccurred())
+	return h.urlFor("https", tlsURL.Host, name)
+}
+
+// Close stops the TLS proxy, if it was started.
+func (h *FileServerHandle) Close() {
+	if h.tlsServer != nil {
+		h.tlsServer.Close()
+		h.tlsServer = nil
+	}
+}
+
+func (h *FileServerHandle) pathFor(name string) string {
+	filePath := filepath.Join(h.StaticDir, filepath.FromSlash(name))
+
+	err := os.MkdirAll(filepath.Dir(filePath), 0755)
+	Expect(err).NotTo(HaveOccurred())
+
+	return filePath
+}
+
+func (h *FileServerHandle) urlFor(scheme, host, name string) string {
+	fileURL := url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   path.Join("/v1/static", name),
+	}
+	return fileURL.String()
+}
+
+func (h *FileServerHandle) published(name string) PublishedFile {
+	filePath := h.pathFor(name)
+
+	content, err := ioutil.ReadFile(filePath)
+	Expect(err).NotTo(HaveOccurred())
+
+	md5Sum := fmt.Sprintf("%x", md5.Sum(content))
+	file := PublishedFile{
+		Name:   name,
+		Path:   filePath,
+		URL:    h.URL(name),
+		MD5:    md5Sum,
+		SHA1:   fmt.Sprintf("%x", sha1.Sum(content)),
+		SHA256: fmt.Sprintf("%x", sha256.Sum256(content)),
+		ETag:   `"` + md5Sum + `"`,
+		Size:   int64(len(content)),
+	}
+
+	if h.tlsServer != nil {
+		file.HTTPSURL = h.HTTPSURL(name)
+	}
+
+	return file
+}
+
+// Checksum returns the digest for algorithm (md5, sha1 or sha256), e.g. to
+// fill in a DownloadAction or CachedDependency.
+func (f PublishedFile) Checksum(algorithm string) string {
+	switch algorithm {
+	case "md5":
+		return f.MD5
+	case "sha1":
+		return f.SHA1
+	case "sha256":
+		return f.SHA256
+	}
+
+	Expect(algorithm).To(BeElementOf("md5", "sha1", "sha256"), "unknown checksum algorithm")
+	return ""
+}
@@ -0,0 +1,89 @@
+package helpers_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"code.cloudfoundry.org/inigo/fixtures"
+	"code.cloudfoundry.org/inigo/helpers"
+	yaml "gopkg.in/yaml.v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileServerHandle", func() {
+	var (
+		staticDir string
+		handle    *helpers.FileServerHandle
+	)
+
+	BeforeEach(func() {
+		var err error
+		staticDir, err = ioutil.TempDir("", "file-server-handle")
+		Expect(err).NotTo(HaveOccurred())
+
+		handle = helpers.NewFileServerHandle(staticDir, "127.0.0.1:8080")
+	})
+
+	AfterEach(func() {
+		handle.Close()
+		Expect(os.RemoveAll(staticDir)).To(Succeed())
+	})
+
+	Describe("PublishApp", func() {
+		It("publishes a droplet that starts the app", func() {
+			published := handle.PublishApp("droplets/app.tgz", fixtures.ExitWithCode, fixtures.Droplet)
+			Expect(published.URL).To(Equal("http://127.0.0.1:8080/v1/static/droplets/app.tgz"))
+
+			files := tgzContents(published.Path)
+			Expect(files).To(HaveKey("app/exit-with-code"))
+			Expect(files).NotTo(HaveKey("app/staging_info.yml"))
+
+			var stagingInfo fixtures.StagingInfo
+			Expect(yaml.Unmarshal([]byte(files["staging_info.yml"]), &stagingInfo)).To(Succeed())
+			Expect(stagingInfo.StartCommand).To(Equal("./exit-with-code"))
+			Expect(stagingInfo.ProcessTypes).To(HaveKeyWithValue("web", "./exit-with-code"))
+		})
+	})
+
+	Describe("PublishArchive", func() {
+		It("refuses to make a droplet out of loose files", func() {
+			failures := InterceptGomegaFailures(func() {
+				handle.PublishArchive("app.tgz", nil, fixtures.Droplet)
+			})
+			Expect(failures).To(ContainElement(ContainSubstring("PublishApp")))
+		})
+	})
+})
+
+// tgzContents returns the body of every file in the tgz at path, by
+// name.
+func tgzContents(path string) map[string]string {
+	file, err := os.Open(path)
+	Expect(err).NotTo(HaveOccurred())
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	Expect(err).NotTo(HaveOccurred())
+
+	contents := map[string]string{}
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return contents
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		body, err := ioutil.ReadAll(reader)
+		Expect(err).NotTo(HaveOccurred())
+		contents[header.Name] = string(body)
+	}
+}
@@ -0,0 +1,278 @@
+package helpers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoServerClient drives the control API of the go-server fixture, so that
+// specs can make a running instance crash, fail its health check, ignore
+// SIGTERM, hang or allocate memory mid-test, and reads and writes files on
+// the volume mounted at its MOUNT_POINT_DIR.
+//
+// The client talks to address directly (e.g. an instance's host port) or,
+// when host is set, to the router at address using host as the route.
+type GoServerClient struct {
+	address    string
+	host       string
+	httpClient *http.Client
+}
+
+func NewGoServerClient(address, host string) *GoServerClient {
+	return &GoServerClient{
+		address:    address,
+		host:       host,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Exit makes the server exit with code.
+func (c *GoServerClient) Exit(code int) error {
+	return c.control("exit", url.Values{"code": {strconv.Itoa(code)}})
+}
+
+// SetHealthy opens or closes the listener on the server's HEALTH_PORT.
+func (c *GoServerClient) SetHealthy(healthy bool) error {
+	status := "fail"
+	if healthy {
+		status = "pass"
+	}
+	return c.control("health", url.Values{"status": {status}})
+}
+
+// IgnoreSIGTERM makes the server ignore (or handle again) SIGTERM.
+func (c *GoServerClient) IgnoreSIGTERM(ignore bool) error {
+	return c.control("ignore-sigterm", url.Values{"enabled": {strconv.FormatBool(ignore)}})
+}
+
+// Hang makes the server block every request other than control requests
+// until Hang(false) is called.
+func (c *GoServerClient) Hang(hang bool) error {
+	return c.control("hang", url.Values{"enabled": {strconv.FormatBool(hang)}})
+}
+
+// Allocate makes the server allocate and hold on to mb more megabytes.
+func (c *GoServerClient) Allocate(mb int) error {
+	return c.control("allocate", url.Values{"mb": {strconv.Itoa(mb)}})
+}
+
+func (c *GoServerClient) control(action string, query url.Values) error {
+	request := &http.Request{
+		Method: "GET",
+		URL: &url.URL{
+			Scheme:   "http",
+			Host:     c.address,
+			Path:     "/control/" + action,
+			RawQuery: query.Encode(),
+		},
+		Host: c.host,
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("go-server /control/%s failed with status %d: %s", action, response.StatusCode, body)
+	}
+
+	return nil
+}
+
+// EgressResult is the result of go-server's /egress probe.
+type EgressResult struct {
+	Target      string   `json:"target"`
+	Proto       string   `json:"proto"`
+	ResolvedIPs []string `json:"resolved_ips"`
+	Connected   bool     `json:"connected"`
+	ErrorClass  string   `json:"error_class"`
+	Error       string   `json:"error"`
+	LatencyMs   float64  `json:"latency_ms"`
+}
+
+// Egress asks the server to dial target ("host:port", or just "host" for
+// icmp) over proto (tcp, udp or icmp) from inside its container. Pair it
+// with a local listener from Callback to test security groups without
+// internet access.
+func (c *GoServerClient) Egress(target, proto string) (EgressResult, error) {
+	var result EgressResult
+	err := c.getJSON("/egress", url.Values{"target": {target}, "proto": {proto}}, &result)
+	return result, err
+}
+
+// Introspection is what go-server's /introspect reports about the container
+// it runs in.
+type Introspection struct {
+	CgroupVersion int `json:"cgroup_version"`
+	Memory        struct {
+		// LimitBytes is -1 when the cgroup has no limit
+		LimitBytes int64 `json:"limit_bytes"`
+		UsageBytes int64 `json:"usage_bytes"`
+	} `json:"memory"`
+	CPU struct {
+		// Weight is cpu.weight on cgroup v2 hosts and cpu.shares on cgroup v1
+		Weight int64 `json:"weight"`
+	} `json:"cpu"`
+	Disk struct {
+		Path        string `json:"path"`
+		TotalBytes  uint64 `json:"total_bytes"`
+		FreeBytes   uint64 `json:"free_bytes"`
+		AvailBytes  uint64 `json:"avail_bytes"`
+		TotalInodes uint64 `json:"total_inodes"`
+		FreeInodes  uint64 `json:"free_inodes"`
+	} `json:"disk"`
+	User struct {
+		UID    int   `json:"uid"`
+		GID    int   `json:"gid"`
+		Groups []int `json:"groups"`
+		// Capabilities maps CapInh, CapPrm, CapEff, CapBnd and CapAmb to
+		// their hex masks from /proc/self/status
+		Capabilities map[string]string `json:"capabilities"`
+	} `json:"user"`
+	Mounts []struct {
+		Device     string   `json:"device"`
+		MountPoint string   `json:"mount_point"`
+		FSType     string   `json:"fs_type"`
+		Options    []string `json:"options"`
+	} `json:"mounts"`
+	Interfaces []struct {
+		Name      string   `json:"name"`
+		MTU       int      `json:"mtu"`
+		Flags     string   `json:"flags"`
+		Addresses []string `json:"addresses"`
+	} `json:"interfaces"`
+	InstanceEnv map[string]string `json:"instance_env"`
+}
+
+// HasEffectiveCapability reports whether capability (its bit number, e.g.
+// 21 for CAP_SYS_ADMIN) is in the effective set.
+func (i Introspection) HasEffectiveCapability(capability uint) bool {
+	mask, err := strconv.ParseUint(i.User.Capabilities["CapEff"], 16, 64)
+	if err != nil {
+		return false
+	}
+	return mask&(1<<capability) != 0
+}
+
+// Introspect returns what the server sees of its container's limits and
+// identity. The disk quota is measured at diskPath, or / when it is empty.
+func (c *GoServerClient) Introspect(diskPath string) (Introspection, error) {
+	query := url.Values{}
+	if diskPath != "" {
+		query.Set("path", diskPath)
+	}
+
+	var result Introspection
+	err := c.getJSON("/introspect", query, &result)
+	return result, err
+}
+
+func (c *GoServerClient) getJSON(path string, query url.Values, result interface{}) error {
+	request := &http.Request{
+		Method: "GET",
+		URL: &url.URL{
+			Scheme:   "http",
+			Host:     c.address,
+			Path:     path,
+			RawQuery: query.Encode(),
+		},
+		Host: c.host,
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("go-server %s failed with status %d: %s", path, response.StatusCode, body)
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// VolumeFile is an entry listed by go-server's /volume/list.
+type VolumeFile struct {
+	Name  string `json:"name"`
+	Size  int64  `json:"size"`
+	IsDir bool   `json:"is_dir"`
+}
+
+// ReadVolumeFile returns the contents of path, relative to the server's
+// MOUNT_POINT_DIR.
+func (c *GoServerClient) ReadVolumeFile(path string) (string, error) {
+	body, err := c.do("GET", "/volume/read", url.Values{"path": {path}}, nil)
+	return string(body), err
+}
+
+// WriteVolumeFile replaces path, relative to the server's MOUNT_POINT_DIR,
+// with content, creating any missing directories.
+func (c *GoServerClient) WriteVolumeFile(path, content string) error {
+	_, err := c.do("PUT", "/volume/write", url.Values{"path": {path}}, strings.NewReader(content))
+	return err
+}
+
+// AppendVolumeFile appends content to path, relative to the server's
+// MOUNT_POINT_DIR.
+func (c *GoServerClient) AppendVolumeFile(path, content string) error {
+	_, err := c.do("PUT", "/volume/write", url.Values{"path": {path}, "append": {"true"}}, strings.NewReader(content))
+	return err
+}
+
+// LockedAppendVolumeFile appends content to path while holding an exclusive
+// lock on it for at least hold. The server rewrites the whole file under the
+// lock, so concurrent writers only all get their lines in if the volume's
+// locking works across the instances sharing it.
+func (c *GoServerClient) LockedAppendVolumeFile(path, content string, hold time.Duration) error {
+	_, err := c.do("PUT", "/volume/lock", url.Values{"path": {path}, "hold": {hold.String()}}, strings.NewReader(content))
+	return err
+}
+
+// ListVolumeDir lists dir, relative to the server's MOUNT_POINT_DIR.
+func (c *GoServerClient) ListVolumeDir(dir string) ([]VolumeFile, error) {
+	var files []VolumeFile
+	err := c.getJSON("/volume/list", url.Values{"path": {dir}}, &files)
+	return files, err
+}
+
+func (c *GoServerClient) do(method, path string, query url.Values, body io.Reader) ([]byte, error) {
+	request, err := http.NewRequest(method, (&url.URL{
+		Scheme:   "http",
+		Host:     c.address,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	request.Host = c.host
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("go-server %s failed with status %d: %s", path, response.StatusCode, responseBody)
+	}
+
+	return responseBody, nil
+}
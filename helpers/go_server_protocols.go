@@ -0,0 +1,238 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/websocket"
+)
+
+// Protocol returns the protocol (e.g. "HTTP/2.0") that the server saw the
+// request arrive over, speaking HTTP/2 with prior knowledge when h2c is set.
+// The server needs H2C set for h2c requests to reach it as HTTP/2.
+func (c *GoServerClient) Protocol(h2c bool) (string, error) {
+	client := c.httpClient
+	if h2c {
+		client = c.h2cClient()
+	}
+
+	request := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Scheme: "http", Host: c.address, Path: "/protocol"},
+		Host:   c.host,
+		Header: http.Header{},
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("go-server /protocol failed with status %d: %s", response.StatusCode, body)
+	}
+
+	return string(body), nil
+}
+
+// WebSocketEcho sends message over a WebSocket to the server's /ws/echo and
+// returns what came back.
+func (c *GoServerClient) WebSocketEcho(message string) (string, error) {
+	host := c.host
+	if host == "" {
+		host = c.address
+	}
+
+	config, err := websocket.NewConfig("ws://"+host+"/ws/echo", "http://"+host)
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := net.DialTimeout("tcp", c.address, c.httpClient.Timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(c.httpClient.Timeout))
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		return "", err
+	}
+	defer ws.Close()
+
+	err = websocket.Message.Send(ws, message)
+	if err != nil {
+		return "", err
+	}
+
+	var reply string
+	err = websocket.Message.Receive(ws, &reply)
+	return reply, err
+}
+
+// GRPCHealthCheck calls grpc.health.v1.Health/Check over h2c and returns the
+// serving status, i.e. "SERVING" or "NOT_SERVING". The server needs H2C set.
+func (c *GoServerClient) GRPCHealthCheck() (string, error) {
+	// an empty HealthCheckRequest
+	frame := []byte{0, 0, 0, 0, 0}
+
+	request := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Scheme: "http", Host: c.address, Path: "/grpc.health.v1.Health/Check"},
+		Host:   c.host,
+		Header: http.Header{
+			"Content-Type": {"application/grpc"},
+			"Te":           {"trailers"},
+		},
+		Body:          ioutil.NopCloser(bytes.NewReader(frame)),
+		ContentLength: int64(len(frame)),
+	}
+
+	response, err := c.h2cClient().Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("grpc health check failed with status %d: %s", response.StatusCode, body)
+	}
+
+	if status := response.Trailer.Get("Grpc-Status"); status != "0" {
+		return "", fmt.Errorf("grpc health check failed with grpc-status %q: %s", status, response.Trailer.Get("Grpc-Message"))
+	}
+
+	return decodeServingStatus(body)
+}
+
+func (c *GoServerClient) h2cClient() *http.Client {
+	return &http.Client{
+		Timeout: c.httpClient.Timeout,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+}
+
+// decodeServingStatus decodes a length-prefixed HealthCheckResponse.
+func decodeServingStatus(frame []byte) (string, error) {
+	if len(frame) < 5 {
+		return "", errors.New("grpc response is too short")
+	}
+
+	length := binary.BigEndian.Uint32(frame[1:5])
+	message := frame[5:]
+	if uint32(len(message)) < length {
+		return "", errors.New("grpc response is truncated")
+	}
+	message = message[:length]
+
+	// an empty message means the status is the default, UNKNOWN
+	status := uint64(0)
+	for len(message) > 0 {
+		key, n := binary.Uvarint(message)
+		if n <= 0 || key&0x7 != 0 {
+			return "", errors.New("unexpected field in grpc health response")
+		}
+		value, m := binary.Uvarint(message[n:])
+		if m <= 0 {
+			return "", errors.New("invalid varint in grpc health response")
+		}
+		if key>>3 == 1 {
+			status = value
+		}
+		message = message[n+m:]
+	}
+
+	switch status {
+	case 0:
+		return "UNKNOWN", nil
+	case 1:
+		return "SERVING", nil
+	case 2:
+		return "NOT_SERVING", nil
+	case 3:
+		return "SERVICE_UNKNOWN", nil
+	}
+	return "", fmt.Errorf("unknown serving status %d", status)
+}
+
+// TCPEcho writes message to a go-server TCP_ECHO_PORT at address (directly or
+// through a TCP router) and returns what was echoed back.
+func TCPEcho(address, message string) (string, error) {
+	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return echo(conn, message)
+}
+
+// TLSEcho is TCPEcho over TLS, e.g. to a TCP_ECHO_PORT through the
+// instance's envoy proxy.
+func TLSEcho(address, message string, config *tls.Config) (string, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", address, config)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return echo(conn, message)
+}
+
+// UDPEcho sends message to a go-server UDP_ECHO_PORT at address and returns
+// the datagram that was echoed back.
+func UDPEcho(address, message string) (string, error) {
+	conn, err := net.DialTimeout("udp", address, 10*time.Second)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	return echo(conn, message)
+}
+
+func echo(conn net.Conn, message string) (string, error) {
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	_, err := conn.Write([]byte(message))
+	if err != nil {
+		return "", err
+	}
+
+	reply := make([]byte, len(message))
+	n := 0
+	for n < len(reply) {
+		m, err := conn.Read(reply[n:])
+		if err != nil {
+			return string(reply[:n]), err
+		}
+		n += m
+	}
+	return string(reply), nil
+}
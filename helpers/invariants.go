@@ -0,0 +1,327 @@
+package helpers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/routing-info/cfroutes"
+	. "github.com/onsi/ginkgo"
+)
+
+// ClusterState is a single sample of the cluster taken by an
+// InvariantMonitor.
+type ClusterState struct {
+	Time        time.Time
+	ActualLRPs  []*models.ActualLRP
+	DesiredLRPs []*models.DesiredLRP
+	Tasks       []*models.Task
+
+	// RouterStatusCodes maps every route hostname of a desired LRP to the
+	// status code returned by the router for it, or 0 when the router could
+	// not be reached. It is empty when the monitor has no router address.
+	RouterStatusCodes map[string]int
+}
+
+// Invariant is a property that must hold for every sample. Check receives the
+// previous sample (nil on the first one) so that invariants can reason about
+// transitions as well as snapshots.
+type Invariant struct {
+	Name  string
+	Check func(previous, current *ClusterState) error
+}
+
+// Violation records an invariant that did not hold.
+type Violation struct {
+	Time      time.Time
+	Invariant string
+	Err       error
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s: %s", v.Time.Format(time.RFC3339Nano), v.Invariant, v.Err)
+}
+
+// InvariantMonitor continuously samples the BBS (and optionally the router)
+// while a spec runs and records every violated invariant. It is an
+// ifrit.Runner:
+//
+//	monitor := helpers.NewInvariantMonitor(lgr, bbsClient, componentMaker.Addresses().Router, helpers.DefaultInvariants()...)
+//	monitorProcess := ginkgomon.Invoke(monitor)
+//	...
+//	helpers.StopProcesses(monitorProcess)
+//	Expect(monitor.Violations()).To(BeEmpty())
+type InvariantMonitor struct {
+	SampleInterval time.Duration
+
+	logger     lager.Logger
+	bbsClient  bbs.InternalClient
+	routerAddr string
+	invariants []Invariant
+
+	lock       sync.Mutex
+	violations []Violation
+	samples    int
+}
+
+func NewInvariantMonitor(logger lager.Logger, bbsClient bbs.InternalClient, routerAddr string, invariants ...Invariant) *InvariantMonitor {
+	return &InvariantMonitor{
+		SampleInterval: 500 * time.Millisecond,
+		logger:         logger.Session("invariant-monitor"),
+		bbsClient:      bbsClient,
+		routerAddr:     routerAddr,
+		invariants:     invariants,
+	}
+}
+
+// AddInvariant plugs in a custom invariant. It must be called before the
+// monitor is started.
+func (m *InvariantMonitor) AddInvariant(invariant Invariant) {
+	m.invariants = append(m.invariants, invariant)
+}
+
+func (m *InvariantMonitor) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	ticker := time.NewTicker(m.SampleInterval)
+	defer ticker.Stop()
+
+	var previous *ClusterState
+	previous = m.sample(previous)
+
+	close(ready)
+
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+			previous = m.sample(previous)
+		}
+	}
+}
+
+// Violations returns every violation recorded so far.
+func (m *InvariantMonitor) Violations() []Violation {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return append([]Violation{}, m.violations...)
+}
+
+// Samples returns how many samples were checked so far.
+func (m *InvariantMonitor) Samples() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.samples
+}
+
+func (m *InvariantMonitor) sample(previous *ClusterState) *ClusterState {
+	current, err := m.collect()
+	if err != nil {
+		// a component may legitimately be down during a spec; only record
+		// invariants over states that could actually be observed
+		fmt.Fprintf(GinkgoWriter, "[invariant-monitor] failed to sample cluster state: %s\n", err)
+		return previous
+	}
+
+	violations := []Violation{}
+	for _, invariant := range m.invariants {
+		err := invariant.Check(previous, current)
+		if err != nil {
+			violations = append(violations, Violation{Time: current.Time, Invariant: invariant.Name, Err: err})
+		}
+	}
+
+	m.lock.Lock()
+	m.samples++
+	m.violations = append(m.violations, violations...)
+	m.lock.Unlock()
+
+	for _, violation := range violations {
+		fmt.Fprintf(GinkgoWriter, "[invariant-monitor] violation: %s\n", violation)
+	}
+
+	return current
+}
+
+func (m *InvariantMonitor) collect() (*ClusterState, error) {
+	state := &ClusterState{
+		Time:              time.Now(),
+		RouterStatusCodes: map[string]int{},
+	}
+
+	var err error
+	state.ActualLRPs, err = m.bbsClient.ActualLRPs(m.logger, models.ActualLRPFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	state.DesiredLRPs, err = m.bbsClient.DesiredLRPs(m.logger, models.DesiredLRPFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	state.Tasks, err = m.bbsClient.Tasks(m.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.routerAddr == "" {
+		return state, nil
+	}
+
+	// the router being down must not hide the BBS invariants, so a failed
+	// request only leaves a 0 status code for its hostname
+	for _, desired := range state.DesiredLRPs {
+		for _, hostname := range routeHostnames(desired) {
+			statusCode, err := ResponseCodeFromHostPoller(m.routerAddr, hostname)()
+			if err != nil {
+				fmt.Fprintf(GinkgoWriter, "[invariant-monitor] failed to reach the router for %s: %s\n", hostname, err)
+			}
+			state.RouterStatusCodes[hostname] = statusCode
+		}
+	}
+
+	return state, nil
+}
+
+// DefaultInvariants returns the built-in Diego invariants.
+func DefaultInvariants() []Invariant {
+	return []Invariant{
+		NoDuplicateRunningInstances(),
+		TasksStayOnOneCell(),
+		RunningLRPsAreRouted(10 * time.Second),
+	}
+}
+
+// NoDuplicateRunningInstances checks that no index of an LRP has more than one
+// ordinary RUNNING instance. Evacuating instances are allowed to overlap with
+// their replacement.
+func NoDuplicateRunningInstances() Invariant {
+	return Invariant{
+		Name: "no duplicate running instances",
+		Check: func(_, current *ClusterState) error {
+			running := map[string][]string{}
+			for _, lrp := range current.ActualLRPs {
+				if lrp.State != models.ActualLRPStateRunning || lrp.Presence == models.ActualLRP_Evacuating {
+					continue
+				}
+				key := fmt.Sprintf("%s/%d", lrp.ProcessGuid, lrp.Index)
+				running[key] = append(running[key], fmt.Sprintf("%s@%s", lrp.InstanceGuid, lrp.CellId))
+			}
+
+			duplicates := []string{}
+			for key, instances := range running {
+				if len(instances) > 1 {
+					duplicates = append(duplicates, fmt.Sprintf("%s running as %s", key, strings.Join(instances, ", ")))
+				}
+			}
+
+			if len(duplicates) > 0 {
+				return fmt.Errorf("multiple running instances: %s", strings.Join(duplicates, "; "))
+			}
+			return nil
+		},
+	}
+}
+
+// TasksStayOnOneCell checks that a task which was placed on a cell is never
+// reported on a different cell, even when samples in between did not show it
+// on any cell. The invariant remembers every placement it has seen, so each
+// monitor needs its own.
+func TasksStayOnOneCell() Invariant {
+	placements := map[string]string{}
+
+	return Invariant{
+		Name: "tasks stay on one cell",
+		Check: func(_, current *ClusterState) error {
+			moved := []string{}
+			for _, task := range current.Tasks {
+				if task.CellId == "" {
+					continue
+				}
+
+				firstCell, ok := placements[task.TaskGuid]
+				if !ok {
+					placements[task.TaskGuid] = task.CellId
+					continue
+				}
+				if task.CellId != firstCell {
+					moved = append(moved, fmt.Sprintf("%s moved from %s to %s", task.TaskGuid, firstCell, task.CellId))
+				}
+			}
+
+			if len(moved) > 0 {
+				return fmt.Errorf("tasks observed on more than one cell: %s", strings.Join(moved, "; "))
+			}
+			return nil
+		},
+	}
+}
+
+// RunningLRPsAreRouted checks that every route of an LRP that has been
+// running for longer than gracePeriod is known to the router. It is a no-op
+// when the monitor has no router address.
+func RunningLRPsAreRouted(gracePeriod time.Duration) Invariant {
+	return Invariant{
+		Name: "running LRPs are routed",
+		Check: func(_, current *ClusterState) error {
+			if len(current.RouterStatusCodes) == 0 {
+				return nil
+			}
+
+			routedSince := map[string]time.Time{}
+			for _, lrp := range current.ActualLRPs {
+				if lrp.State != models.ActualLRPStateRunning {
+					continue
+				}
+				since := time.Unix(0, lrp.Since)
+				if earliest, ok := routedSince[lrp.ProcessGuid]; !ok || since.Before(earliest) {
+					routedSince[lrp.ProcessGuid] = since
+				}
+			}
+
+			missing := []string{}
+			for _, desired := range current.DesiredLRPs {
+				since, ok := routedSince[desired.ProcessGuid]
+				if !ok || current.Time.Sub(since) < gracePeriod {
+					continue
+				}
+
+				for _, hostname := range routeHostnames(desired) {
+					if current.RouterStatusCodes[hostname] == http.StatusNotFound {
+						missing = append(missing, fmt.Sprintf("%s (%s)", hostname, desired.ProcessGuid))
+					}
+				}
+			}
+
+			if len(missing) > 0 {
+				return fmt.Errorf("running LRPs missing routes: %s", strings.Join(missing, ", "))
+			}
+			return nil
+		},
+	}
+}
+
+func routeHostnames(desired *models.DesiredLRP) []string {
+	if desired.Routes == nil {
+		return nil
+	}
+
+	routes, err := cfroutes.CFRoutesFromRoutingInfo(*desired.Routes)
+	if err != nil {
+		return nil
+	}
+
+	hostnames := []string{}
+	for _, route := range routes {
+		hostnames = append(hostnames, route.Hostnames...)
+	}
+	return hostnames
+}
@@ -0,0 +1,156 @@
+package helpers_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bbs/fake_bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/lager/lagertest"
+	"code.cloudfoundry.org/routing-info/cfroutes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("InvariantMonitor", func() {
+	var (
+		bbsClient  *fake_bbs.FakeInternalClient
+		routerAddr string
+		monitor    *helpers.InvariantMonitor
+		process    ifrit.Process
+		states     chan *helpers.ClusterState
+	)
+
+	BeforeEach(func() {
+		bbsClient = &fake_bbs.FakeInternalClient{}
+
+		routes := cfroutes.CFRoutes{{Hostnames: []string{"routed.example.com"}, Port: 8080}}.RoutingInfo()
+		bbsClient.DesiredLRPsReturns([]*models.DesiredLRP{{ProcessGuid: "lrp", Routes: &routes}}, nil)
+		bbsClient.ActualLRPsReturns([]*models.ActualLRP{
+			{ActualLRPKey: models.NewActualLRPKey("lrp", 0, "domain"), ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-a", "cell-a"), State: models.ActualLRPStateRunning},
+			{ActualLRPKey: models.NewActualLRPKey("lrp", 0, "domain"), ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-b", "cell-b"), State: models.ActualLRPStateRunning},
+		}, nil)
+
+		states = make(chan *helpers.ClusterState, 100)
+		routerAddr = ""
+	})
+
+	JustBeforeEach(func() {
+		monitor = helpers.NewInvariantMonitor(lagertest.NewTestLogger("test"), bbsClient, routerAddr, helpers.NoDuplicateRunningInstances())
+		monitor.SampleInterval = 10 * time.Millisecond
+		monitor.AddInvariant(helpers.Invariant{
+			Name: "recorder",
+			Check: func(_, current *helpers.ClusterState) error {
+				select {
+				case states <- current:
+				default:
+				}
+				return nil
+			},
+		})
+		process = ifrit.Invoke(monitor)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	It("records a violation for every sample that breaks an invariant", func() {
+		Eventually(monitor.Samples).Should(BeNumerically(">=", 2))
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive())
+
+		violations := monitor.Violations()
+		Expect(violations).To(HaveLen(monitor.Samples()))
+		Expect(violations[0].Invariant).To(Equal("no duplicate running instances"))
+		Expect(violations[0].Err).To(MatchError(ContainSubstring("lrp/0 running as")))
+	})
+
+	Context("when the BBS cannot be reached", func() {
+		BeforeEach(func() {
+			bbsClient.TasksReturns(nil, errors.New("boom"))
+		})
+
+		It("does not check any invariant", func() {
+			Eventually(bbsClient.TasksCallCount).Should(BeNumerically(">=", 2))
+			Expect(monitor.Samples()).To(BeZero())
+			Expect(monitor.Violations()).To(BeEmpty())
+		})
+	})
+
+	Context("with a router", func() {
+		var router *httptest.Server
+
+		BeforeEach(func() {
+			router = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))
+			routerAddr = strings.TrimPrefix(router.URL, "http://")
+		})
+
+		AfterEach(func() {
+			router.Close()
+		})
+
+		It("records the router's status code for every route", func() {
+			var state *helpers.ClusterState
+			Eventually(states).Should(Receive(&state))
+			Expect(state.RouterStatusCodes).To(Equal(map[string]int{"routed.example.com": http.StatusTeapot}))
+		})
+	})
+
+	Context("when the router cannot be reached", func() {
+		BeforeEach(func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			routerAddr = listener.Addr().String()
+			Expect(listener.Close()).To(Succeed())
+		})
+
+		It("still checks the BBS invariants", func() {
+			var state *helpers.ClusterState
+			Eventually(states).Should(Receive(&state))
+			Expect(state.RouterStatusCodes).To(Equal(map[string]int{"routed.example.com": 0}))
+			Expect(state.ActualLRPs).To(HaveLen(2))
+
+			Eventually(monitor.Violations).ShouldNot(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("TasksStayOnOneCell", func() {
+	var invariant helpers.Invariant
+
+	sample := func(cellID string) *helpers.ClusterState {
+		return &helpers.ClusterState{Tasks: []*models.Task{{TaskGuid: "task", CellId: cellID}}}
+	}
+
+	BeforeEach(func() {
+		invariant = helpers.TasksStayOnOneCell()
+	})
+
+	It("allows a task to stay on its cell", func() {
+		Expect(invariant.Check(nil, sample("cell-a"))).To(Succeed())
+		Expect(invariant.Check(sample("cell-a"), sample("cell-a"))).To(Succeed())
+	})
+
+	It("catches a task moving between two samples", func() {
+		Expect(invariant.Check(nil, sample("cell-a"))).To(Succeed())
+		Expect(invariant.Check(sample("cell-a"), sample("cell-b"))).To(MatchError(ContainSubstring("task moved from cell-a to cell-b")))
+	})
+
+	It("catches a task that moves while it is missing from the samples", func() {
+		Expect(invariant.Check(nil, sample("cell-a"))).To(Succeed())
+		Expect(invariant.Check(sample("cell-a"), &helpers.ClusterState{})).To(Succeed())
+		Expect(invariant.Check(&helpers.ClusterState{}, sample(""))).To(Succeed())
+		Expect(invariant.Check(sample(""), sample("cell-b"))).To(MatchError(ContainSubstring("task moved from cell-a to cell-b")))
+	})
+})
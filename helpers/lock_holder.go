@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/inigo/world"
+	locketmodels "code.cloudfoundry.org/locket/models"
+
+	. "github.com/onsi/gomega"
+)
+
+// LockHolder returns the owner of the Locket lock key, i.e. the UUID of the
+// ControlPlaneInstance holding it. It errors while nobody holds the lock.
+func LockHolder(client locketmodels.LocketClient, key string) (string, error) {
+	resp, err := client.Fetch(context.Background(), &locketmodels.FetchRequest{Key: key})
+	if err != nil {
+		return "", err
+	}
+	return resp.Resource.Owner, nil
+}
+
+func LockHolderPoller(client locketmodels.LocketClient, key string) func() (string, error) {
+	return func() (string, error) {
+		return LockHolder(client, key)
+	}
+}
+
+// LockHoldingInstance waits for one of instances to hold the lock key and
+// returns it.
+func LockHoldingInstance(client locketmodels.LocketClient, key string, instances []world.ControlPlaneInstance) world.ControlPlaneInstance {
+	var holder world.ControlPlaneInstance
+	TimedEventuallyWithOffset(1, func() (string, error) {
+		holder = world.ControlPlaneInstance{}
+		owner, err := LockHolder(client, key)
+		for _, instance := range instances {
+			if owner != "" && instance.UUID == owner {
+				holder = instance
+			}
+		}
+		return holder.UUID, err
+	}).ShouldNot(BeEmpty(), "none of the instances holds %s", key)
+
+	return holder
+}
+
+// ExpectFailover waits, for no longer than within, for one of instances other
+// than previous to take over the lock key, and returns the new holder. Call it
+// after stopping previous:
+//
+//	holder := helpers.LockHoldingInstance(locketClient, world.BBSLockKey, bbses)
+//	ginkgomon.Kill(bbsProcesses[holder.Name])
+//	helpers.ExpectFailover(locketClient, world.BBSLockKey, holder, bbses, 30*time.Second)
+func ExpectFailover(client locketmodels.LocketClient, key string, previous world.ControlPlaneInstance, instances []world.ControlPlaneInstance, within time.Duration) world.ControlPlaneInstance {
+	standbys := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		if instance.UUID != previous.UUID {
+			standbys = append(standbys, instance.UUID)
+		}
+	}
+
+	TimedEventuallyWithOffset(1, LockHolderPoller(client, key), within).Should(BeElementOf(standbys), "no standby took over %s from %s", key, previous.Name)
+
+	return LockHoldingInstance(client, key, instances)
+}
@@ -2,45 +2,443 @@ package helpers
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
+	"sync"
 
+	"code.cloudfoundry.org/bbs/events"
 	"code.cloudfoundry.org/bbs/models"
 	"github.com/onsi/gomega"
 	"github.com/onsi/gomega/format"
 )
 
+// EventField is a single field compared by a BBS event matcher. Only the
+// fields passed to a matcher are compared, so matchers can be as loose or as
+// strict as a spec needs.
+type EventField struct {
+	Name  string
+	Value interface{}
+}
+
+func WithProcessGuid(processGuid string) EventField {
+	return EventField{Name: "ProcessGuid", Value: processGuid}
+}
+
+func WithIndex(index int) EventField {
+	return EventField{Name: "Index", Value: int32(index)}
+}
+
+func WithDomain(domain string) EventField {
+	return EventField{Name: "Domain", Value: domain}
+}
+
+func WithInstanceGuid(instanceGuid string) EventField {
+	return EventField{Name: "InstanceGuid", Value: instanceGuid}
+}
+
+func WithCellId(cellId string) EventField {
+	return EventField{Name: "CellId", Value: cellId}
+}
+
+// WithState matches the ActualLRP state of an event, or the state after the
+// change for ActualLRPInstanceChanged events.
+func WithState(state string) EventField {
+	return EventField{Name: "State", Value: state}
+}
+
+// WithBeforeState matches the ActualLRP state before an
+// ActualLRPInstanceChanged event.
+func WithBeforeState(state string) EventField {
+	return EventField{Name: "BeforeState", Value: state}
+}
+
+func WithCrashCount(crashCount int) EventField {
+	return EventField{Name: "CrashCount", Value: int32(crashCount)}
+}
+
+func WithCrashReason(crashReason string) EventField {
+	return EventField{Name: "CrashReason", Value: crashReason}
+}
+
+// WithInstances matches the number of instances of a DesiredLRP, or the
+// number after the change for DesiredLRPChanged events.
+func WithInstances(instances int) EventField {
+	return EventField{Name: "Instances", Value: int32(instances)}
+}
+
+func WithBeforeInstances(instances int) EventField {
+	return EventField{Name: "BeforeInstances", Value: int32(instances)}
+}
+
+func WithTaskGuid(taskGuid string) EventField {
+	return EventField{Name: "TaskGuid", Value: taskGuid}
+}
+
+// WithTaskState matches the state of a task, or the state after the change
+// for TaskChanged events.
+func WithTaskState(state models.Task_State) EventField {
+	return EventField{Name: "TaskState", Value: state}
+}
+
+func WithBeforeTaskState(state models.Task_State) EventField {
+	return EventField{Name: "BeforeTaskState", Value: state}
+}
+
+func WithFailed(failed bool) EventField {
+	return EventField{Name: "Failed", Value: failed}
+}
+
+func MatchActualLRPInstanceCreatedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeActualLRPInstanceCreated, Fields: fields}
+}
+
+func MatchActualLRPInstanceChangedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeActualLRPInstanceChanged, Fields: fields}
+}
+
+func MatchActualLRPInstanceRemovedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeActualLRPInstanceRemoved, Fields: fields}
+}
+
+func MatchActualLRPCrashedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeActualLRPCrashed, Fields: fields}
+}
+
+func MatchDesiredLRPCreatedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeDesiredLRPCreated, Fields: fields}
+}
+
+func MatchDesiredLRPChangedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeDesiredLRPChanged, Fields: fields}
+}
+
+func MatchDesiredLRPRemovedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeDesiredLRPRemoved, Fields: fields}
+}
+
+func MatchTaskCreatedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeTaskCreated, Fields: fields}
+}
+
+func MatchTaskChangedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeTaskChanged, Fields: fields}
+}
+
+func MatchTaskRemovedEvent(fields ...EventField) gomega.OmegaMatcher {
+	return &BBSEventMatcher{EventType: models.EventTypeTaskRemoved, Fields: fields}
+}
+
+// BBSEventMatcher matches a models.Event of EventType whose fields equal
+// every one of Fields.
+type BBSEventMatcher struct {
+	EventType string
+	Fields    []EventField
+}
+
+func (matcher *BBSEventMatcher) Match(actual interface{}) (success bool, err error) {
+	event, ok := actual.(models.Event)
+	if !ok || event.EventType() != matcher.EventType {
+		return false, nil
+	}
+
+	actualFields := eventFields(event)
+	for _, field := range matcher.Fields {
+		actualValue, ok := actualFields[field.Name]
+		if !ok || !reflect.DeepEqual(actualValue, field.Value) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (matcher *BBSEventMatcher) FailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected\n%s\nto be a %s event with\n%s", format.Object(actual, 1), matcher.EventType, matcher.describeFields(actual))
+}
+
+func (matcher *BBSEventMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected\n%s\nnot to be a %s event with\n%s", format.Object(actual, 1), matcher.EventType, matcher.describeFields(actual))
+}
+
+func (matcher *BBSEventMatcher) String() string {
+	fields := make([]string, 0, len(matcher.Fields))
+	for _, field := range matcher.Fields {
+		fields = append(fields, fmt.Sprintf("%s=%v", field.Name, field.Value))
+	}
+	return fmt.Sprintf("%s{%s}", matcher.EventType, strings.Join(fields, ", "))
+}
+
+func (matcher *BBSEventMatcher) describeFields(actual interface{}) string {
+	var actualFields map[string]interface{}
+	if event, ok := actual.(models.Event); ok {
+		actualFields = eventFields(event)
+	}
+
+	lines := make([]string, 0, len(matcher.Fields))
+	for _, field := range matcher.Fields {
+		actualValue, ok := actualFields[field.Name]
+		if !ok {
+			lines = append(lines, fmt.Sprintf("  %s=%v (actual: <missing>)", field.Name, field.Value))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %s=%v (actual: %v)", field.Name, field.Value, actualValue))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func eventFields(event models.Event) map[string]interface{} {
+	switch e := event.(type) {
+	case *models.ActualLRPInstanceCreatedEvent:
+		return actualLRPFields(e.ActualLrp)
+	case *models.ActualLRPInstanceRemovedEvent:
+		return actualLRPFields(e.ActualLrp)
+	case *models.ActualLRPInstanceChangedEvent:
+		fields := map[string]interface{}{
+			"ProcessGuid":  e.ProcessGuid,
+			"Index":        e.Index,
+			"Domain":       e.Domain,
+			"InstanceGuid": e.InstanceGuid,
+			"CellId":       e.CellId,
+		}
+		if e.Before != nil {
+			fields["BeforeState"] = e.Before.State
+		}
+		if e.After != nil {
+			fields["State"] = e.After.State
+			fields["CrashCount"] = e.After.CrashCount
+			fields["CrashReason"] = e.After.CrashReason
+		}
+		return fields
+	case *models.ActualLRPCrashedEvent:
+		return map[string]interface{}{
+			"ProcessGuid":  e.ProcessGuid,
+			"Index":        e.Index,
+			"Domain":       e.Domain,
+			"InstanceGuid": e.InstanceGuid,
+			"CellId":       e.CellId,
+			"CrashCount":   e.CrashCount,
+			"CrashReason":  e.CrashReason,
+		}
+	case *models.DesiredLRPCreatedEvent:
+		return desiredLRPFields(e.DesiredLrp)
+	case *models.DesiredLRPRemovedEvent:
+		return desiredLRPFields(e.DesiredLrp)
+	case *models.DesiredLRPChangedEvent:
+		fields := desiredLRPFields(e.After)
+		if e.Before != nil {
+			fields["BeforeInstances"] = e.Before.Instances
+		}
+		return fields
+	case *models.TaskCreatedEvent:
+		return taskFields(e.Task)
+	case *models.TaskRemovedEvent:
+		return taskFields(e.Task)
+	case *models.TaskChangedEvent:
+		fields := taskFields(e.After)
+		if e.Before != nil {
+			fields["BeforeTaskState"] = e.Before.State
+		}
+		return fields
+	}
+
+	return map[string]interface{}{}
+}
+
+func actualLRPFields(lrp *models.ActualLRP) map[string]interface{} {
+	if lrp == nil {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"ProcessGuid":  lrp.ProcessGuid,
+		"Index":        lrp.Index,
+		"Domain":       lrp.Domain,
+		"InstanceGuid": lrp.InstanceGuid,
+		"CellId":       lrp.CellId,
+		"State":        lrp.State,
+		"CrashCount":   lrp.CrashCount,
+		"CrashReason":  lrp.CrashReason,
+	}
+}
+
+func desiredLRPFields(lrp *models.DesiredLRP) map[string]interface{} {
+	if lrp == nil {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"ProcessGuid": lrp.ProcessGuid,
+		"Domain":      lrp.Domain,
+		"Instances":   lrp.Instances,
+	}
+}
+
+func taskFields(task *models.Task) map[string]interface{} {
+	if task == nil {
+		return map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"TaskGuid":  task.TaskGuid,
+		"Domain":    task.Domain,
+		"CellId":    task.CellId,
+		"TaskState": task.State,
+		"Failed":    task.Failed,
+	}
+}
+
+// EventChannel pumps events from eventSource into a channel, for use with
+// ReceiveEventsInOrder. It stops, and closes the channel, once the source is
+// closed or fails; closing done closes the source.
+func EventChannel(eventSource events.EventSource, done <-chan struct{}) <-chan models.Event {
+	eventChannel := make(chan models.Event, 1024)
+
+	go func() {
+		<-done
+		eventSource.Close()
+	}()
+
+	go func() {
+		defer close(eventChannel)
+		for {
+			event, err := eventSource.Next()
+			if err != nil {
+				return
+			}
+
+			// nobody is reading once done is closed, so don't block on a full
+			// channel
+			select {
+			case eventChannel <- event:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return eventChannel
+}
+
+// ReceiveEventsInOrder succeeds once events matching each of the matchers
+// have been seen in the given order; unrelated events in between are
+// ignored. The actual value is either a []models.Event or a
+// <-chan models.Event (see EventChannel). Channels are drained without
+// blocking and progress is kept between calls, so the matcher is meant to be
+// polled with Eventually:
 //
+//	eventChannel := helpers.EventChannel(eventSource, done)
+//	Eventually(eventChannel).Should(helpers.ReceiveEventsInOrder(
+//		helpers.MatchDesiredLRPCreatedEvent(helpers.WithProcessGuid(processGuid)),
+//		helpers.MatchActualLRPInstanceChangedEvent(helpers.WithProcessGuid(processGuid), helpers.WithState(models.ActualLRPStateRunning)),
+//	))
+func ReceiveEventsInOrder(matchers ...gomega.OmegaMatcher) gomega.OmegaMatcher {
+	return &receiveEventsInOrderMatcher{matchers: matchers}
+}
+
+type receiveEventsInOrderMatcher struct {
+	matchers []gomega.OmegaMatcher
+
+	lock     sync.Mutex
+	received []models.Event
+	matched  int
+}
+
+func (matcher *receiveEventsInOrderMatcher) Match(actual interface{}) (success bool, err error) {
+	matcher.lock.Lock()
+	defer matcher.lock.Unlock()
 
-func MatchActualLRPCrashedEvent(processGuid, instanceGuid, cellId string, index int) gomega.OmegaMatcher {
-	return &ActualLRPCrashedEventMatcher{
-		ProcessGuid:  processGuid,
-		Index:        index,
-		InstanceGuid: instanceGuid,
-		CellId:       cellId,
+	switch events := actual.(type) {
+	case []models.Event:
+		matcher.received = append([]models.Event{}, events...)
+		matcher.matched = 0
+		for _, event := range matcher.received {
+			err := matcher.consume(event)
+			if err != nil {
+				return false, err
+			}
+		}
+	case <-chan models.Event:
+		err := matcher.drain(events)
+		if err != nil {
+			return false, err
+		}
+	case chan models.Event:
+		err := matcher.drain(events)
+		if err != nil {
+			return false, err
+		}
+	default:
+		return false, fmt.Errorf("ReceiveEventsInOrder expects a []models.Event or a channel of models.Event.  Got:\n%s", format.Object(actual, 1))
 	}
+
+	return matcher.matched == len(matcher.matchers), nil
 }
 
-type ActualLRPCrashedEventMatcher struct {
-	ProcessGuid  string
-	InstanceGuid string
-	CellId       string
-	Index        int
+func (matcher *receiveEventsInOrderMatcher) drain(events <-chan models.Event) error {
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			matcher.received = append(matcher.received, event)
+			err := matcher.consume(event)
+			if err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
 }
 
-func (matcher *ActualLRPCrashedEventMatcher) Match(actual interface{}) (success bool, err error) {
-	event, ok := actual.(*models.ActualLRPCrashedEvent)
-	if !ok {
-		return false, nil
+func (matcher *receiveEventsInOrderMatcher) consume(event models.Event) error {
+	if matcher.matched == len(matcher.matchers) {
+		return nil
+	}
+
+	success, err := matcher.matchers[matcher.matched].Match(event)
+	if err != nil {
+		return err
+	}
+	if success {
+		matcher.matched++
+	}
+	return nil
+}
+
+func (matcher *receiveEventsInOrderMatcher) FailureMessage(actual interface{}) (message string) {
+	matcher.lock.Lock()
+	defer matcher.lock.Unlock()
+
+	expected := make([]string, 0, len(matcher.matchers))
+	for i, m := range matcher.matchers {
+		marker := "   "
+		if i < matcher.matched {
+			marker = "ok "
+		} else if i == matcher.matched {
+			marker = "-> "
+		}
+		expected = append(expected, fmt.Sprintf("  %s%s", marker, describeMatcher(m)))
 	}
-	return event.ProcessGuid == matcher.ProcessGuid &&
-		event.Index == int32(matcher.Index) &&
-		event.CellId == matcher.CellId &&
-		event.InstanceGuid == matcher.InstanceGuid, nil
+
+	received := make([]string, 0, len(matcher.received))
+	for _, event := range matcher.received {
+		received = append(received, fmt.Sprintf("  %s %s", event.EventType(), event.Key()))
+	}
+
+	return fmt.Sprintf("Expected to receive events in order, matched %d of %d:\n%s\nReceived %d events:\n%s",
+		matcher.matched, len(matcher.matchers), strings.Join(expected, "\n"),
+		len(matcher.received), strings.Join(received, "\n"))
 }
 
-func (matcher *ActualLRPCrashedEventMatcher) FailureMessage(actual interface{}) (message string) {
-	return fmt.Sprintf("Expected\n%s\nto be a ActualLRPCrashedEvent with\n  ProcessGuid=%s\n  Index=%d", format.Object(actual, 1), matcher.ProcessGuid, matcher.Index)
+func (matcher *receiveEventsInOrderMatcher) NegatedFailureMessage(actual interface{}) (message string) {
+	return fmt.Sprintf("Expected not to receive %d events in order, but did", len(matcher.matchers))
 }
 
-func (matcher *ActualLRPCrashedEventMatcher) NegatedFailureMessage(actual interface{}) (message string) {
-	return fmt.Sprintf("Expected\n%s\nnot to be a ActualLRPCrashedEvent with\n  ProcessGuid=%s\n  Index=%d", format.Object(actual, 1), matcher.ProcessGuid, matcher.Index)
+func describeMatcher(matcher gomega.OmegaMatcher) string {
+	if stringer, ok := matcher.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%#v", matcher)
 }
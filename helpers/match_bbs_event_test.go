@@ -0,0 +1,232 @@
+package helpers_test
+
+import (
+	"errors"
+	"sync"
+
+	"code.cloudfoundry.org/bbs/events/eventfakes"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/inigo/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BBS event matchers", func() {
+	actualLRP := func(processGuid, cellID, state string) *models.ActualLRP {
+		return &models.ActualLRP{
+			ActualLRPKey:         models.NewActualLRPKey(processGuid, 0, "domain"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", cellID),
+			State:                state,
+		}
+	}
+
+	created := func(processGuid string) models.Event {
+		return &models.ActualLRPInstanceCreatedEvent{ActualLrp: actualLRP(processGuid, "cell-a", models.ActualLRPStateUnclaimed)}
+	}
+
+	changed := func(processGuid, before, after string) models.Event {
+		return &models.ActualLRPInstanceChangedEvent{
+			ActualLRPKey:         models.NewActualLRPKey(processGuid, 0, "domain"),
+			ActualLRPInstanceKey: models.NewActualLRPInstanceKey("instance-guid", "cell-a"),
+			Before:               &models.ActualLRPInfo{State: before},
+			After:                &models.ActualLRPInfo{State: after},
+		}
+	}
+
+	Describe("BBSEventMatcher", func() {
+		It("only compares the fields it is given", func() {
+			event := created("some-guid")
+
+			Expect(event).To(helpers.MatchActualLRPInstanceCreatedEvent())
+			Expect(event).To(helpers.MatchActualLRPInstanceCreatedEvent(helpers.WithProcessGuid("some-guid")))
+			Expect(event).To(helpers.MatchActualLRPInstanceCreatedEvent(
+				helpers.WithProcessGuid("some-guid"),
+				helpers.WithIndex(0),
+				helpers.WithCellId("cell-a"),
+				helpers.WithState(models.ActualLRPStateUnclaimed),
+			))
+
+			Expect(event).NotTo(helpers.MatchActualLRPInstanceCreatedEvent(helpers.WithProcessGuid("some-guid"), helpers.WithCellId("cell-b")))
+		})
+
+		It("compares the states before and after a change", func() {
+			event := changed("some-guid", models.ActualLRPStateClaimed, models.ActualLRPStateRunning)
+
+			Expect(event).To(helpers.MatchActualLRPInstanceChangedEvent(
+				helpers.WithBeforeState(models.ActualLRPStateClaimed),
+				helpers.WithState(models.ActualLRPStateRunning),
+			))
+			Expect(event).NotTo(helpers.MatchActualLRPInstanceChangedEvent(helpers.WithState(models.ActualLRPStateClaimed)))
+		})
+
+		It("does not match other event types", func() {
+			Expect(created("some-guid")).NotTo(helpers.MatchActualLRPInstanceRemovedEvent(helpers.WithProcessGuid("some-guid")))
+			Expect(created("some-guid")).NotTo(helpers.MatchDesiredLRPCreatedEvent())
+			Expect("not an event").NotTo(helpers.MatchActualLRPInstanceCreatedEvent())
+		})
+
+		It("lists every compared field, with its actual value, when it fails", func() {
+			matcher := helpers.MatchActualLRPInstanceCreatedEvent(
+				helpers.WithProcessGuid("some-guid"),
+				helpers.WithCellId("cell-b"),
+				helpers.WithTaskGuid("some-task"),
+			)
+
+			event := created("some-guid")
+			Expect(matcher.Match(event)).To(BeFalse())
+
+			message := matcher.FailureMessage(event)
+			Expect(message).To(ContainSubstring("to be a " + models.EventTypeActualLRPInstanceCreated + " event with"))
+			Expect(message).To(ContainSubstring("ProcessGuid=some-guid (actual: some-guid)"))
+			Expect(message).To(ContainSubstring("CellId=cell-b (actual: cell-a)"))
+			Expect(message).To(ContainSubstring("TaskGuid=some-task (actual: <missing>)"))
+		})
+	})
+
+	Describe("ReceiveEventsInOrder", func() {
+		var matcher OmegaMatcher
+
+		BeforeEach(func() {
+			matcher = helpers.ReceiveEventsInOrder(
+				helpers.MatchActualLRPInstanceCreatedEvent(helpers.WithProcessGuid("some-guid")),
+				helpers.MatchActualLRPInstanceChangedEvent(helpers.WithProcessGuid("some-guid"), helpers.WithState(models.ActualLRPStateRunning)),
+			)
+		})
+
+		Context("with a slice", func() {
+			It("matches events in order, skipping unrelated ones", func() {
+				Expect([]models.Event{
+					created("other-guid"),
+					created("some-guid"),
+					changed("other-guid", models.ActualLRPStateClaimed, models.ActualLRPStateRunning),
+					changed("some-guid", models.ActualLRPStateClaimed, models.ActualLRPStateRunning),
+				}).To(matcher)
+			})
+
+			It("does not match events out of order", func() {
+				events := []models.Event{
+					changed("some-guid", models.ActualLRPStateClaimed, models.ActualLRPStateRunning),
+					created("some-guid"),
+				}
+				Expect(matcher.Match(events)).To(BeFalse())
+
+				message := matcher.FailureMessage(events)
+				Expect(message).To(ContainSubstring("matched 1 of 2"))
+				Expect(message).To(MatchRegexp(`ok ` + models.EventTypeActualLRPInstanceCreated + `\{ProcessGuid=some-guid\}`))
+				Expect(message).To(MatchRegexp(`-> ` + models.EventTypeActualLRPInstanceChanged + `\{ProcessGuid=some-guid, State=RUNNING\}`))
+				Expect(message).To(ContainSubstring("Received 2 events"))
+			})
+		})
+
+		Context("with a channel", func() {
+			It("keeps its progress between polls", func() {
+				events := make(chan models.Event, 10)
+
+				events <- created("some-guid")
+				Expect(matcher.Match(events)).To(BeFalse())
+
+				events <- changed("some-guid", models.ActualLRPStateClaimed, models.ActualLRPStateRunning)
+				Expect(matcher.Match(events)).To(BeTrue())
+			})
+
+			It("can be polled with Eventually", func() {
+				events := make(chan models.Event)
+				go func() {
+					events <- created("some-guid")
+					events <- changed("some-guid", models.ActualLRPStateUnclaimed, models.ActualLRPStateClaimed)
+					events <- changed("some-guid", models.ActualLRPStateClaimed, models.ActualLRPStateRunning)
+				}()
+
+				Eventually((<-chan models.Event)(events)).Should(matcher)
+			})
+
+			It("does not match events out of order", func() {
+				events := make(chan models.Event, 10)
+				events <- changed("some-guid", models.ActualLRPStateClaimed, models.ActualLRPStateRunning)
+				events <- created("some-guid")
+
+				Consistently(events).ShouldNot(matcher)
+			})
+		})
+
+		It("errors on anything but events", func() {
+			_, err := matcher.Match("not events")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("EventChannel", func() {
+		var (
+			source *eventfakes.FakeEventSource
+			feed   chan models.Event
+			closed chan struct{}
+			done   chan struct{}
+		)
+
+		BeforeEach(func() {
+			feed = make(chan models.Event)
+			closed = make(chan struct{})
+			done = make(chan struct{})
+
+			var closeOnce sync.Once
+			source = &eventfakes.FakeEventSource{}
+			source.NextStub = func() (models.Event, error) {
+				select {
+				case event := <-feed:
+					return event, nil
+				case <-closed:
+					return nil, errors.New("closed")
+				}
+			}
+			source.CloseStub = func() error {
+				closeOnce.Do(func() { close(closed) })
+				return nil
+			}
+		})
+
+		It("forwards events until the source is closed", func() {
+			events := helpers.EventChannel(source, done)
+
+			feed <- created("some-guid")
+			Eventually(events).Should(Receive(Equal(created("some-guid"))))
+
+			Expect(source.Close()).To(Succeed())
+			Eventually(events).Should(BeClosed())
+			close(done)
+		})
+
+		It("closes the source and stops once done is closed, even if nobody reads", func() {
+			events := helpers.EventChannel(source, done)
+
+			go func() {
+				defer GinkgoRecover()
+				for i := 0; i < 2000; i++ {
+					select {
+					case feed <- created("some-guid"):
+					case <-closed:
+						return
+					}
+				}
+			}()
+			Eventually(func() int { return len(events) }).Should(Equal(cap(events)))
+
+			close(done)
+			Eventually(source.CloseCallCount).Should(Equal(1))
+
+			// the buffered events are still there to be read, then the
+			// channel is closed
+			Eventually(func() bool {
+				for {
+					select {
+					case _, ok := <-events:
+						if !ok {
+							return true
+						}
+					default:
+						return false
+					}
+				}
+			}).Should(BeTrue())
+		})
+	})
+})
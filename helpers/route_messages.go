@@ -0,0 +1,195 @@
+package helpers
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	RouterRegisterSubject   = "router.register"
+	RouterUnregisterSubject = "router.unregister"
+)
+
+// RouteMessage is a router.register or router.unregister message, as
+// published by the route-emitter.
+type RouteMessage struct {
+	Subject    string    `json:"-"`
+	ReceivedAt time.Time `json:"-"`
+
+	Host                 string            `json:"host"`
+	Port                 uint32            `json:"port"`
+	TLSPort              uint32            `json:"tls_port,omitempty"`
+	URIs                 []string          `json:"uris"`
+	App                  string            `json:"app,omitempty"`
+	PrivateInstanceID    string            `json:"private_instance_id,omitempty"`
+	PrivateInstanceIndex string            `json:"private_instance_index,omitempty"`
+	ServerCertDomainSAN  string            `json:"server_cert_domain_san,omitempty"`
+	RouteServiceURL      string            `json:"route_service_url,omitempty"`
+	Tags                 map[string]string `json:"tags,omitempty"`
+}
+
+// RouteMessageRecorder subscribes to the router's NATS subjects and records
+// every route (un)registration, so that specs can check what was emitted
+// without going through the router.
+//
+//	user, password := componentMaker.NATSCredentials()
+//	recorder := helpers.NewRouteMessageRecorder(componentMaker.Addresses().NATS, user, password)
+//	defer recorder.Close()
+//	Eventually(recorder.RegisteredURIs).Should(ContainElement(helpers.DefaultHost))
+type RouteMessageRecorder struct {
+	conn *nats.Conn
+
+	lock     sync.Mutex
+	messages []RouteMessage
+}
+
+// NewRouteMessageRecorder connects to the NATS server at address; options
+// can add e.g. nats.Secure for a server started with TLS.
+func NewRouteMessageRecorder(address, username, password string, options ...nats.Option) *RouteMessageRecorder {
+	options = append([]nats.Option{nats.UserInfo(username, password)}, options...)
+
+	conn, err := nats.Connect("nats://"+address, options...)
+	Expect(err).NotTo(HaveOccurred())
+
+	recorder := &RouteMessageRecorder{conn: conn}
+
+	for _, subject := range []string{RouterRegisterSubject, RouterUnregisterSubject} {
+		_, err := conn.Subscribe(subject, recorder.record)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// make sure the server has the subscriptions before anything is emitted
+	Expect(conn.Flush()).To(Succeed())
+
+	return recorder
+}
+
+func (r *RouteMessageRecorder) record(msg *nats.Msg) {
+	message := RouteMessage{}
+	err := json.Unmarshal(msg.Data, &message)
+	if err != nil {
+		return
+	}
+	message.Subject = msg.Subject
+	message.ReceivedAt = time.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messages = append(r.messages, message)
+}
+
+// Messages returns every message received so far, in order.
+func (r *RouteMessageRecorder) Messages() []RouteMessage {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	messages := make([]RouteMessage, len(r.messages))
+	copy(messages, r.messages)
+	return messages
+}
+
+func (r *RouteMessageRecorder) Registrations() []RouteMessage {
+	return r.messagesOn(RouterRegisterSubject)
+}
+
+func (r *RouteMessageRecorder) Unregistrations() []RouteMessage {
+	return r.messagesOn(RouterUnregisterSubject)
+}
+
+// RegisteredURIs returns the URIs that have been registered, each once.
+func (r *RouteMessageRecorder) RegisteredURIs() []string {
+	return uniqueURIs(r.Registrations())
+}
+
+// UnregisteredURIs returns the URIs that have been unregistered, each once.
+func (r *RouteMessageRecorder) UnregisteredURIs() []string {
+	return uniqueURIs(r.Unregistrations())
+}
+
+// RoutingTable replays the messages received so far and returns, for every
+// URI, the sorted host:port endpoints the router would currently send it to.
+// It does not expire registrations the way the router prunes stale ones.
+func (r *RouteMessageRecorder) RoutingTable() map[string][]string {
+	endpoints := map[string]map[string]bool{}
+	for _, message := range r.Messages() {
+		endpoint := net.JoinHostPort(message.Host, strconv.Itoa(int(message.Port)))
+		for _, uri := range message.URIs {
+			if endpoints[uri] == nil {
+				endpoints[uri] = map[string]bool{}
+			}
+			if message.Subject == RouterRegisterSubject {
+				endpoints[uri][endpoint] = true
+			} else {
+				delete(endpoints[uri], endpoint)
+			}
+		}
+	}
+
+	table := map[string][]string{}
+	for uri, set := range endpoints {
+		if len(set) == 0 {
+			continue
+		}
+		for endpoint := range set {
+			table[uri] = append(table[uri], endpoint)
+		}
+		sort.Strings(table[uri])
+	}
+	return table
+}
+
+// FirstRegistration returns when uri was first registered, e.g. to measure
+// how long after desiring an LRP its route was emitted.
+func (r *RouteMessageRecorder) FirstRegistration(uri string) (time.Time, bool) {
+	for _, message := range r.Registrations() {
+		for _, registered := range message.URIs {
+			if registered == uri {
+				return message.ReceivedAt, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Reset forgets the messages received so far.
+func (r *RouteMessageRecorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.messages = nil
+}
+
+func (r *RouteMessageRecorder) Close() {
+	r.conn.Close()
+}
+
+func (r *RouteMessageRecorder) messagesOn(subject string) []RouteMessage {
+	messages := []RouteMessage{}
+	for _, message := range r.Messages() {
+		if message.Subject == subject {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+func uniqueURIs(messages []RouteMessage) []string {
+	seen := map[string]bool{}
+	uris := []string{}
+	for _, message := range messages {
+		for _, uri := range message.URIs {
+			if !seen[uri] {
+				seen[uri] = true
+				uris = append(uris, uri)
+			}
+		}
+	}
+	return uris
+}
@@ -2,42 +2,252 @@ package helpers
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	"github.com/tedsuo/ifrit"
 )
 
+// StopStep is a single rung of the escalation chain used to stop a process:
+// Signal is sent and the process is given Timeout to exit before the next
+// step is taken.
+type StopStep struct {
+	Signal  os.Signal
+	Timeout time.Duration
+}
+
+// StopPolicy describes how processes are stopped.
+//
+// Steps are tried in order until the process exits. The timeout of the first
+// (graceful) step can be overridden per component through ComponentTimeouts,
+// keyed by NamedProcess.Name. When ArtifactDir is set, the output captured
+// for a process that had to be sent SIGQUIT (which includes the goroutine
+// dump of Go binaries) is written to a file in that directory; otherwise it is
+// written to the GinkgoWriter.
+type StopPolicy struct {
+	Steps             []StopStep
+	ComponentTimeouts map[string]time.Duration
+	ArtifactDir       string
+}
+
+// DefaultStopPolicy sends SIGTERM, then SIGQUIT to collect a goroutine dump,
+// then SIGKILL. On Windows processes are killed straight away.
+//
+// The graceful timeout can be changed with $DEFAULT_STOP_TIMEOUT and the
+// artifact directory with $STOP_ARTIFACT_DIR.
+func DefaultStopPolicy() StopPolicy {
+	gracefulTimeout := 20 * time.Second
+	if timeout := os.Getenv("DEFAULT_STOP_TIMEOUT"); timeout != "" {
+		var err error
+		gracefulTimeout, err = time.ParseDuration(timeout)
+		Expect(err).NotTo(HaveOccurred(), fmt.Sprintf("%s not a valid duration", timeout))
+	}
+
+	policy := StopPolicy{
+		ComponentTimeouts: map[string]time.Duration{},
+		ArtifactDir:       os.Getenv("STOP_ARTIFACT_DIR"),
+	}
+
+	if runtime.GOOS == "windows" {
+		policy.Steps = []StopStep{
+			{Signal: syscall.SIGKILL, Timeout: gracefulTimeout},
+		}
+	} else {
+		policy.Steps = []StopStep{
+			{Signal: syscall.SIGTERM, Timeout: gracefulTimeout},
+			{Signal: syscall.SIGQUIT, Timeout: 10 * time.Second},
+			{Signal: syscall.SIGKILL, Timeout: 5 * time.Second},
+		}
+	}
+
+	return policy
+}
+
+// WithComponentTimeout returns a copy of the policy that gives the named
+// component timeout to exit after the first signal.
+func (policy StopPolicy) WithComponentTimeout(name string, timeout time.Duration) StopPolicy {
+	timeouts := make(map[string]time.Duration, len(policy.ComponentTimeouts)+1)
+	for k, v := range policy.ComponentTimeouts {
+		timeouts[k] = v
+	}
+	timeouts[name] = timeout
+	policy.ComponentTimeouts = timeouts
+	return policy
+}
+
+// NamedProcess pairs a process with a name for reporting. Output is optional;
+// when present (e.g. a ginkgomon.Runner's Buffer()) it is saved as an artifact
+// if the process has to be sent SIGQUIT.
+type NamedProcess struct {
+	Name    string
+	Process ifrit.Process
+	Output  *gbytes.Buffer
+}
+
+// HungProcess describes a process that did not exit after the first signal.
+type HungProcess struct {
+	Name        string
+	SignalsSent []os.Signal
+	Exited      bool
+	WaitedFor   time.Duration
+	Artifact    string
+}
+
+func (h HungProcess) String() string {
+	signals := make([]string, 0, len(h.SignalsSent))
+	for _, signal := range h.SignalsSent {
+		signals = append(signals, signal.String())
+	}
+
+	status := "exited"
+	if !h.Exited {
+		status = "still running"
+	}
+
+	description := fmt.Sprintf("%s did not shut down cleanly after %s (sent %s, %s)", h.Name, h.WaitedFor, strings.Join(signals, " -> "), status)
+	if h.Artifact != "" {
+		description += fmt.Sprintf("; output saved to %s", h.Artifact)
+	}
+	return description
+}
+
+// StopReport lists every process that hung while being stopped.
+type StopReport struct {
+	Hung []HungProcess
+}
+
+func (r StopReport) Clean() bool {
+	return len(r.Hung) == 0
+}
+
+func (r StopReport) String() string {
+	if r.Clean() {
+		return "all processes shut down cleanly"
+	}
+
+	lines := make([]string, 0, len(r.Hung))
+	for _, hung := range r.Hung {
+		lines = append(lines, hung.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NewNamedProcess names a process started from runner. Its output is taken
+// from the runner when it keeps one, as a ginkgomon.Runner does.
+func NewNamedProcess(name string, runner ifrit.Runner, process ifrit.Process) NamedProcess {
+	named := NamedProcess{Name: name, Process: process}
+	if buffered, ok := runner.(interface{ Buffer() *gbytes.Buffer }); ok {
+		named.Output = buffered.Buffer()
+	}
+	return named
+}
+
+// StopProcesses stops the given processes with the DefaultStopPolicy and
+// fails the current spec if any of them did not shut down cleanly. Prefer
+// StopNamed, which can say which component hung and save its output.
 func StopProcesses(processes ...ifrit.Process) {
-	failures := InterceptGomegaFailures(func() {
-		for _, process := range processes {
-			if process == nil {
-				// sometimes components aren't initialized in individual tests, but a full
-				// suite may want AfterEach to clean up everything
-				continue
-			}
-
-			if runtime.GOOS == "windows" {
-				process.Signal(syscall.SIGKILL)
-			} else {
-				process.Signal(syscall.SIGTERM)
-			}
-
-			select {
-			case <-process.Wait():
-			case <-time.After(20 * time.Second):
-				fmt.Fprintf(GinkgoWriter, "!!!!!!!!!!!!!!!! STOP TIMEOUT !!!!!!!!!!!!!!!!")
-
-				process.Signal(syscall.SIGQUIT)
-				Eventually(process.Wait(), 10*time.Second).Should(Receive())
-
-				Expect(true).To(BeFalse(), "process did not shut down cleanly; SIGQUIT sent")
-			}
-		}
-	})
-
-	Expect(failures).To(BeEmpty(), "at least one process failed to shut down cleanly")
+	named := make([]NamedProcess, 0, len(processes))
+	for i, process := range processes {
+		named = append(named, NamedProcess{Name: "process-" + strconv.Itoa(i), Process: process})
+	}
+
+	StopNamed(named...)
+}
+
+// StopNamed stops the given processes with the DefaultStopPolicy and fails
+// the current spec if any of them did not shut down cleanly.
+func StopNamed(processes ...NamedProcess) {
+	report := StopNamedProcesses(DefaultStopPolicy(), processes...)
+	Expect(report.Hung).To(BeEmpty(), "at least one process failed to shut down cleanly:\n%s", report)
+}
+
+// StopNamedProcesses stops the given processes in order according to policy
+// and returns a report naming each process that hung. It does not fail the
+// spec; callers decide what to do with the report.
+func StopNamedProcesses(policy StopPolicy, processes ...NamedProcess) StopReport {
+	report := StopReport{}
+
+	for _, process := range processes {
+		if process.Process == nil {
+			// sometimes components aren't initialized in individual tests, but a full
+			// suite may want AfterEach to clean up everything
+			continue
+		}
+
+		hung, ok := stopProcess(policy, process)
+		if !ok {
+			report.Hung = append(report.Hung, hung)
+		}
+	}
+
+	return report
+}
+
+func stopProcess(policy StopPolicy, process NamedProcess) (HungProcess, bool) {
+	hung := HungProcess{Name: process.Name}
+	start := time.Now()
+
+	for i, step := range policy.Steps {
+		timeout := step.Timeout
+		if override, ok := policy.ComponentTimeouts[process.Name]; ok && i == 0 {
+			timeout = override
+		}
+
+		process.Process.Signal(step.Signal)
+		hung.SignalsSent = append(hung.SignalsSent, step.Signal)
+
+		select {
+		case <-process.Process.Wait():
+			hung.Exited = true
+		case <-time.After(timeout):
+			fmt.Fprintf(GinkgoWriter, "!!!!!!!!!!!!!!!! STOP TIMEOUT: %s did not exit %s after %s !!!!!!!!!!!!!!!!\n", process.Name, timeout, step.Signal)
+		}
+
+		if step.Signal == syscall.SIGQUIT {
+			hung.Artifact = saveStopArtifact(policy.ArtifactDir, process)
+		}
+
+		if hung.Exited {
+			break
+		}
+	}
+
+	hung.WaitedFor = time.Since(start)
+	return hung, hung.Exited && len(hung.SignalsSent) == 1
+}
+
+func saveStopArtifact(artifactDir string, process NamedProcess) string {
+	if process.Output == nil {
+		return ""
+	}
+
+	contents := process.Output.Contents()
+	if artifactDir == "" {
+		fmt.Fprintf(GinkgoWriter, "output of %s after SIGQUIT:\n%s\n", process.Name, contents)
+		return ""
+	}
+
+	err := os.MkdirAll(artifactDir, 0755)
+	if err != nil {
+		fmt.Fprintf(GinkgoWriter, "failed to create stop artifact dir %s: %s\n", artifactDir, err)
+		return ""
+	}
+
+	artifactPath := filepath.Join(artifactDir, fmt.Sprintf("%s-%d.goroutines", process.Name, time.Now().UnixNano()))
+	err = ioutil.WriteFile(artifactPath, contents, 0644)
+	if err != nil {
+		fmt.Fprintf(GinkgoWriter, "failed to write stop artifact %s: %s\n", artifactPath, err)
+		return ""
+	}
+
+	return artifactPath
 }
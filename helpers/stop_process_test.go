@@ -0,0 +1,79 @@
+//go:build !windows
+// +build !windows
+
+package helpers_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/inigo/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+var _ = Describe("StopNamedProcesses", func() {
+	var (
+		artifactDir string
+		policy      helpers.StopPolicy
+	)
+
+	shell := func(name, script string) (ifrit.Runner, ifrit.Process) {
+		runner := ginkgomon.New(ginkgomon.Config{
+			Name:       name,
+			Command:    exec.Command("/bin/sh", "-c", script),
+			StartCheck: "started",
+		})
+		return runner, ginkgomon.Invoke(runner)
+	}
+
+	BeforeEach(func() {
+		var err error
+		artifactDir, err = ioutil.TempDir("", "stop-artifacts")
+		Expect(err).NotTo(HaveOccurred())
+
+		policy = helpers.StopPolicy{
+			Steps: []helpers.StopStep{
+				{Signal: syscall.SIGTERM, Timeout: 500 * time.Millisecond},
+				{Signal: syscall.SIGQUIT, Timeout: 5 * time.Second},
+				{Signal: syscall.SIGKILL, Timeout: 5 * time.Second},
+			},
+			ArtifactDir: artifactDir,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(artifactDir)).To(Succeed())
+	})
+
+	It("reports nothing when processes exit on SIGTERM", func() {
+		runner, process := shell("obedient", `echo started; exec sleep 60`)
+
+		report := helpers.StopNamedProcesses(policy, helpers.NewNamedProcess("obedient", runner, process))
+		Expect(report.Clean()).To(BeTrue())
+	})
+
+	It("names processes that ignore SIGTERM and saves their output", func() {
+		runner, process := shell("stubborn", `trap '' TERM; trap 'echo dumping goroutines; exit 2' QUIT; echo started; while true; do sleep 0.1; done`)
+
+		report := helpers.StopNamedProcesses(policy, helpers.NewNamedProcess("stubborn", runner, process))
+
+		Expect(report.Hung).To(HaveLen(1))
+		hung := report.Hung[0]
+		Expect(hung.Name).To(Equal("stubborn"))
+		Expect(hung.SignalsSent).To(Equal([]os.Signal{syscall.SIGTERM, syscall.SIGQUIT}))
+		Expect(hung.Exited).To(BeTrue())
+		Expect(report.String()).To(ContainSubstring("stubborn did not shut down cleanly"))
+
+		Expect(hung.Artifact).To(HavePrefix(artifactDir))
+		artifact, err := ioutil.ReadFile(hung.Artifact)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(artifact)).To(ContainSubstring("started"))
+		Expect(string(artifact)).To(ContainSubstring("dumping goroutines"))
+	})
+})
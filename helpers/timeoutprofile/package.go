@@ -0,0 +1 @@
+package timeoutprofile // import "code.cloudfoundry.org/inigo/helpers/timeoutprofile"
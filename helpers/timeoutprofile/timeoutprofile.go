@@ -0,0 +1,112 @@
+package timeoutprofile
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Profile scales the timeouts used throughout the suites so that they can be
+// tuned for the environment they run in.
+type Profile struct {
+	Name   string
+	Factor float64
+
+	EventuallyPollingInterval   time.Duration
+	ConsistentlyPollingInterval time.Duration
+}
+
+const DefaultProfileName = "local"
+
+var profiles = map[string]Profile{
+	"local": {
+		Name:                        "local",
+		Factor:                      1,
+		EventuallyPollingInterval:   500 * time.Millisecond,
+		ConsistentlyPollingInterval: 100 * time.Millisecond,
+	},
+	"ci": {
+		Name:                        "ci",
+		Factor:                      2,
+		EventuallyPollingInterval:   500 * time.Millisecond,
+		ConsistentlyPollingInterval: 100 * time.Millisecond,
+	},
+	"slow-disk": {
+		Name:                        "slow-disk",
+		Factor:                      3,
+		EventuallyPollingInterval:   time.Second,
+		ConsistentlyPollingInterval: 250 * time.Millisecond,
+	},
+	"race": {
+		Name:                        "race",
+		Factor:                      2.5,
+		EventuallyPollingInterval:   time.Second,
+		ConsistentlyPollingInterval: 200 * time.Millisecond,
+	},
+}
+
+var profileFlag string
+
+func init() {
+	flag.StringVar(&profileFlag, "timeoutProfile", "", "timeout profile to run with ("+strings.Join(Names(), ", ")+"); overrides $TIMEOUT_PROFILE")
+}
+
+// Names returns the names of all known profiles.
+func Names() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the profile with the given name.
+//
+// returns a non-nil error if no such profile exists.
+func Lookup(name string) (Profile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown timeout profile %q, must be one of: %s", name, strings.Join(Names(), ", "))
+	}
+	return profile, nil
+}
+
+// Current returns the profile selected with the -timeoutProfile flag or the
+// $TIMEOUT_PROFILE environment variable, falling back to the local profile.
+func Current() (Profile, error) {
+	name := profileFlag
+	if name == "" {
+		name = os.Getenv("TIMEOUT_PROFILE")
+	}
+	if name == "" {
+		name = DefaultProfileName
+	}
+	return Lookup(name)
+}
+
+// Scale returns duration multiplied by the profile's factor.
+func (p Profile) Scale(duration time.Duration) time.Duration {
+	return time.Duration(float64(duration) * p.Factor)
+}
+
+// DurationFromEnv returns the duration in the environment variable key if it
+// is set, and the scaled defaultDuration otherwise. Durations set explicitly
+// in the environment are not scaled.
+//
+// returns a non-nil error if the environment variable is not a valid duration.
+func (p Profile) DurationFromEnv(key string, defaultDuration time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return p.Scale(defaultDuration), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("$%s: %s not a valid duration", key, value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,13 @@
+package timeoutprofile_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestTimeoutprofile(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Timeoutprofile Suite")
+}
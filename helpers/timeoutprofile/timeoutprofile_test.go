@@ -0,0 +1,87 @@
+package timeoutprofile_test
+
+import (
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/inigo/helpers/timeoutprofile"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timeout profiles", func() {
+	Describe("Lookup", func() {
+		It("returns the named profile", func() {
+			profile, err := timeoutprofile.Lookup("ci")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.Name).To(Equal("ci"))
+		})
+
+		Context("when the profile does not exist", func() {
+			It("errors", func() {
+				_, err := timeoutprofile.Lookup("bogus")
+				Expect(err).To(MatchError(ContainSubstring(`unknown timeout profile "bogus"`)))
+			})
+		})
+	})
+
+	Describe("Current", func() {
+		var originalProfile string
+
+		BeforeEach(func() {
+			originalProfile = os.Getenv("TIMEOUT_PROFILE")
+		})
+
+		AfterEach(func() {
+			os.Setenv("TIMEOUT_PROFILE", originalProfile)
+		})
+
+		It("defaults to the local profile", func() {
+			os.Unsetenv("TIMEOUT_PROFILE")
+			profile, err := timeoutprofile.Current()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.Name).To(Equal(timeoutprofile.DefaultProfileName))
+		})
+
+		It("uses $TIMEOUT_PROFILE", func() {
+			os.Setenv("TIMEOUT_PROFILE", "slow-disk")
+			profile, err := timeoutprofile.Current()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile.Name).To(Equal("slow-disk"))
+		})
+	})
+
+	Describe("Scale", func() {
+		It("multiplies durations by the profile factor", func() {
+			profile := timeoutprofile.Profile{Factor: 2.5}
+			Expect(profile.Scale(2 * time.Minute)).To(Equal(5 * time.Minute))
+		})
+	})
+
+	Describe("DurationFromEnv", func() {
+		var profile timeoutprofile.Profile
+
+		BeforeEach(func() {
+			profile = timeoutprofile.Profile{Factor: 2}
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("SOME_TIMEOUT")
+		})
+
+		It("scales the default when the variable is not set", func() {
+			Expect(profile.DurationFromEnv("SOME_TIMEOUT", 10*time.Second)).To(Equal(20 * time.Second))
+		})
+
+		It("uses explicitly set durations as they are", func() {
+			os.Setenv("SOME_TIMEOUT", "3s")
+			Expect(profile.DurationFromEnv("SOME_TIMEOUT", 10*time.Second)).To(Equal(3 * time.Second))
+		})
+
+		It("errors on invalid durations", func() {
+			os.Setenv("SOME_TIMEOUT", "forever")
+			_, err := profile.DurationFromEnv("SOME_TIMEOUT", 10*time.Second)
+			Expect(err).To(MatchError("$SOME_TIMEOUT: forever not a valid duration"))
+		})
+	})
+})
@@ -1,35 +1,186 @@
 package helpers
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
 	"time"
 
+	"code.cloudfoundry.org/inigo/helpers/timeoutprofile"
+	"github.com/onsi/ginkgo"
 	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
 )
 
 var DEFAULT_EVENTUALLY_TIMEOUT = 1 * time.Minute
 var DEFAULT_CONSISTENTLY_DURATION = 5 * time.Second
 
-func RegisterDefaultTimeouts() {
-	var err error
-	if os.Getenv("DEFAULT_EVENTUALLY_TIMEOUT") != "" {
-		DEFAULT_EVENTUALLY_TIMEOUT, err = time.ParseDuration(os.Getenv("DEFAULT_EVENTUALLY_TIMEOUT"))
-		if err != nil {
-			panic(err)
-		}
+// RegisterDefaultTimeouts configures gomega's default durations from the
+// current timeout profile (see timeoutprofile.Current). Durations set in
+// $DEFAULT_EVENTUALLY_TIMEOUT and $DEFAULT_CONSISTENTLY_DURATION are used as
+// they are.
+//
+// returns a non-nil error if the profile or any of the durations are invalid.
+func RegisterDefaultTimeouts() error {
+	profile, err := timeoutprofile.Current()
+	if err != nil {
+		return err
 	}
 
-	if os.Getenv("DEFAULT_CONSISTENTLY_DURATION") != "" {
-		DEFAULT_CONSISTENTLY_DURATION, err = time.ParseDuration(os.Getenv("DEFAULT_CONSISTENTLY_DURATION"))
-		if err != nil {
-			panic(err)
-		}
+	DEFAULT_EVENTUALLY_TIMEOUT, err = profile.DurationFromEnv("DEFAULT_EVENTUALLY_TIMEOUT", 1*time.Minute)
+	if err != nil {
+		return err
+	}
+
+	DEFAULT_CONSISTENTLY_DURATION, err = profile.DurationFromEnv("DEFAULT_CONSISTENTLY_DURATION", 5*time.Second)
+	if err != nil {
+		return err
 	}
 
 	gomega.SetDefaultEventuallyTimeout(DEFAULT_EVENTUALLY_TIMEOUT)
 	gomega.SetDefaultConsistentlyDuration(DEFAULT_CONSISTENTLY_DURATION)
 
 	// most things hit some component; don't hammer it
-	gomega.SetDefaultConsistentlyPollingInterval(100 * time.Millisecond)
-	gomega.SetDefaultEventuallyPollingInterval(500 * time.Millisecond)
+	gomega.SetDefaultConsistentlyPollingInterval(profile.ConsistentlyPollingInterval)
+	gomega.SetDefaultEventuallyPollingInterval(profile.EventuallyPollingInterval)
+
+	return nil
+}
+
+// EventuallyTiming is how long a single timed Eventually took to succeed
+// (or fail). Name is the file and line of the assertion.
+type EventuallyTiming struct {
+	Name      string        `json:"name"`
+	Spec      string        `json:"spec"`
+	Duration  time.Duration `json:"duration_ns"`
+	Timeout   time.Duration `json:"timeout_ns"`
+	Succeeded bool          `json:"succeeded"`
+}
+
+var (
+	eventuallyTimingsLock sync.Mutex
+	eventuallyTimings     []EventuallyTiming
+)
+
+// TimedEventually behaves like gomega's Eventually but records how long the
+// assertion took, under the file and line it is called from, so that slow
+// specs can be found and timeout profiles tuned from data. The suites use it
+// in place of Eventually. See EventuallyTimings and WriteEventuallyTimings.
+func TimedEventually(actual interface{}, intervals ...interface{}) *TimedAsyncAssertion {
+	return TimedEventuallyWithOffset(1, actual, intervals...)
+}
+
+// TimedEventuallyWithOffset is TimedEventually for helpers that poll on
+// behalf of their caller; the timing is named after the caller offset frames
+// up, as with gomega's EventuallyWithOffset.
+func TimedEventuallyWithOffset(offset int, actual interface{}, intervals ...interface{}) *TimedAsyncAssertion {
+	name := "unknown"
+	if _, file, line, ok := runtime.Caller(offset + 1); ok {
+		name = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	timeout := DEFAULT_EVENTUALLY_TIMEOUT
+	if len(intervals) > 0 {
+		if d, ok := toDuration(intervals[0]); ok {
+			timeout = d
+		}
+	}
+
+	return &TimedAsyncAssertion{
+		name:      name,
+		timeout:   timeout,
+		assertion: gomega.EventuallyWithOffset(offset+1, actual, intervals...),
+	}
+}
+
+// toDuration reads an Eventually interval the way gomega does: a
+// time.Duration, a duration string, or a number of seconds.
+func toDuration(input interface{}) (time.Duration, bool) {
+	switch value := input.(type) {
+	case time.Duration:
+		return value, true
+	case string:
+		d, err := time.ParseDuration(value)
+		return d, err == nil
+	}
+
+	v := reflect.ValueOf(input)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return time.Duration(v.Int()) * time.Second, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return time.Duration(v.Uint()) * time.Second, true
+	case reflect.Float32, reflect.Float64:
+		return time.Duration(v.Float() * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+type TimedAsyncAssertion struct {
+	name      string
+	timeout   time.Duration
+	assertion gomega.AsyncAssertion
+}
+
+func (a *TimedAsyncAssertion) Should(matcher types.GomegaMatcher, optionalDescription ...interface{}) (succeeded bool) {
+	start := time.Now()
+	// ginkgo's fail handler panics on failure, so record from a defer
+	defer func() { a.record(time.Since(start), succeeded) }()
+	return a.assertion.Should(matcher, optionalDescription...)
+}
+
+func (a *TimedAsyncAssertion) ShouldNot(matcher types.GomegaMatcher, optionalDescription ...interface{}) (succeeded bool) {
+	start := time.Now()
+	// ginkgo's fail handler panics on failure, so record from a defer
+	defer func() { a.record(time.Since(start), succeeded) }()
+	return a.assertion.ShouldNot(matcher, optionalDescription...)
+}
+
+func (a *TimedAsyncAssertion) record(duration time.Duration, succeeded bool) {
+	eventuallyTimingsLock.Lock()
+	defer eventuallyTimingsLock.Unlock()
+
+	eventuallyTimings = append(eventuallyTimings, EventuallyTiming{
+		Name:      a.name,
+		Spec:      ginkgo.CurrentGinkgoTestDescription().FullTestText,
+		Duration:  duration,
+		Timeout:   a.timeout,
+		Succeeded: succeeded,
+	})
+}
+
+// EventuallyTimings returns every recorded timing, slowest first.
+func EventuallyTimings() []EventuallyTiming {
+	eventuallyTimingsLock.Lock()
+	timings := append([]EventuallyTiming{}, eventuallyTimings...)
+	eventuallyTimingsLock.Unlock()
+
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].Duration > timings[j].Duration
+	})
+	return timings
+}
+
+// WriteEventuallyTimings writes the recorded timings as JSON to the file in
+// $EVENTUALLY_TIMINGS_PATH, suffixed with the parallel node. It does nothing
+// when the variable is not set, so suites can call it unconditionally from
+// AfterSuite.
+func WriteEventuallyTimings() error {
+	path := os.Getenv("EVENTUALLY_TIMINGS_PATH")
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(EventuallyTimings(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(fmt.Sprintf("%s.%d", path, ginkgo.GinkgoParallelProcess()), data, 0644)
 }
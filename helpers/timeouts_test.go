@@ -0,0 +1,56 @@
+package helpers_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/inigo/helpers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TimedEventually", func() {
+	currentTiming := func() *helpers.EventuallyTiming {
+		spec := CurrentGinkgoTestDescription().FullTestText
+		for _, timing := range helpers.EventuallyTimings() {
+			if timing.Spec == spec {
+				return &timing
+			}
+		}
+		return nil
+	}
+
+	It("records assertions that pass, under their call site", func() {
+		helpers.TimedEventually(func() bool { return true }, time.Second).Should(BeTrue())
+
+		timing := currentTiming()
+		Expect(timing).NotTo(BeNil())
+		Expect(timing.Name).To(MatchRegexp(`^timeouts_test\.go:\d+$`))
+		Expect(timing.Succeeded).To(BeTrue())
+		Expect(timing.Timeout).To(Equal(time.Second))
+	})
+
+	It("records assertions that fail, even though failing panics", func() {
+		func() {
+			RegisterFailHandler(func(message string, callerSkip ...int) { panic(message) })
+			defer RegisterFailHandler(Fail)
+			defer func() { Expect(recover()).NotTo(BeNil()) }()
+
+			helpers.TimedEventually(func() bool { return false }, 100*time.Millisecond).Should(BeTrue())
+		}()
+
+		timing := currentTiming()
+		Expect(timing).NotTo(BeNil())
+		Expect(timing.Succeeded).To(BeFalse())
+		Expect(timing.Duration).To(BeNumerically(">=", 100*time.Millisecond))
+	})
+
+	It("reads the timeout the way Eventually does", func() {
+		helpers.TimedEventually(func() bool { return true }, 5).Should(BeTrue())
+		Expect(currentTiming().Timeout).To(Equal(5 * time.Second))
+	})
+
+	It("defaults the timeout", func() {
+		helpers.TimedEventually(func() bool { return true }).ShouldNot(BeFalse())
+		Expect(currentTiming().Timeout).To(Equal(helpers.DEFAULT_EVENTUALLY_TIMEOUT))
+	})
+})
@@ -36,12 +36,16 @@ var _ = Describe("LRPs with volume mounts", func() {
 	BeforeEach(func() {
 		var fileServerRunner ifrit.Runner
 		fileServerRunner, fileServerStaticDir = componentMaker.FileServer()
+		initialServices := grouper.Members{
+			{"sql", componentMaker.SQL()},
+			{"nats", componentMaker.NATS()},
+		}
+		if componentMaker.ConsulEnabled() {
+			initialServices = append(initialServices, grouper.Member{"consul", componentMaker.Consul()})
+		}
+
 		plumbing = ginkgomon.Invoke(grouper.NewOrdered(os.Kill, grouper.Members{
-			{"initial-services", grouper.NewParallel(os.Kill, grouper.Members{
-				{"sql", componentMaker.SQL()},
-				{"nats", componentMaker.NATS()},
-				{"consul", componentMaker.Consul()},
-			})},
+			{"initial-services", grouper.NewParallel(os.Kill, initialServices)},
 			{"locket", componentMaker.Locket()},
 			{"bbs", componentMaker.BBS()},
 		}))
@@ -62,7 +66,7 @@ var _ = Describe("LRPs with volume mounts", func() {
 		bbsClient = componentMaker.BBSClient()
 		archiveFiles = fixtures.GoServerApp()
 
-		Eventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(logger) }).Should(HaveLen(1))
+		helpers.TimedEventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(logger) }).Should(HaveLen(1))
 	})
 
 	JustBeforeEach(func() {
@@ -135,8 +139,8 @@ var _ = Describe("LRPs with volume mounts", func() {
 		})
 
 		It("can write to a file on the mounted volume", func() {
-			Eventually(helpers.LRPStatePoller(logger, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
-			Eventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
+			helpers.TimedEventually(helpers.LRPStatePoller(logger, bbsClient, processGuid, nil)).Should(Equal(models.ActualLRPStateRunning))
+			helpers.TimedEventually(helpers.HelloWorldInstancePoller(componentMaker.Addresses().Router, helpers.DefaultHost)).Should(ConsistOf([]string{"0"}))
 			body, statusCode, err := helpers.ResponseBodyAndStatusCodeFromHost(componentMaker.Addresses().Router, helpers.DefaultHost, "write")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(string(body)).To(Equal("Hello Persistant World!\n"))
@@ -152,7 +156,7 @@ var _ = Describe("LRPs with volume mounts", func() {
 
 			It("should error placing the lrp", func() {
 				var actualLRP *models.ActualLRP
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					index := int32(0)
 					lrps, err := bbsClient.ActualLRPs(logger, models.ActualLRPFilter{ProcessGuid: processGuid, Index: &index})
 					Expect(err).NotTo(HaveOccurred())
@@ -175,7 +179,7 @@ var _ = Describe("LRPs with volume mounts", func() {
 
 			It("should error placing the task", func() {
 				var actualLRP *models.ActualLRP
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					index := int32(0)
 					lrps, err := bbsClient.ActualLRPs(logger, models.ActualLRPFilter{ProcessGuid: processGuid, Index: &index})
 					Expect(err).NotTo(HaveOccurred())
@@ -198,7 +202,7 @@ var _ = Describe("LRPs with volume mounts", func() {
 
 			It("should error placing the task", func() {
 				var actualLRP *models.ActualLRP
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					index := int32(0)
 					lrps, err := bbsClient.ActualLRPs(logger, models.ActualLRPFilter{ProcessGuid: processGuid, Index: &index})
 					Expect(err).NotTo(HaveOccurred())
@@ -32,11 +32,15 @@ var _ = Describe("Tasks", func() {
 		var fileServerRunner ifrit.Runner
 		fileServerRunner, _ = componentMaker.FileServer()
 
+		initialServices := grouper.Members{
+			{"sql", componentMaker.SQL()},
+		}
+		if componentMaker.ConsulEnabled() {
+			initialServices = append(initialServices, grouper.Member{"consul", componentMaker.Consul()})
+		}
+
 		plumbing = ginkgomon.Invoke(grouper.NewOrdered(os.Kill, grouper.Members{
-			{"initial-services", grouper.NewParallel(os.Kill, grouper.Members{
-				{"sql", componentMaker.SQL()},
-				{"consul", componentMaker.Consul()},
-			})},
+			{"initial-services", grouper.NewParallel(os.Kill, initialServices)},
 			{"locket", componentMaker.Locket()},
 			{"bbs", componentMaker.BBS()},
 		}))
@@ -52,7 +56,7 @@ var _ = Describe("Tasks", func() {
 		bbsServiceClient := componentMaker.BBSServiceClient(logger)
 		bbsClient = componentMaker.BBSClient()
 
-		Eventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(logger) }).Should(HaveLen(1))
+		helpers.TimedEventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(logger) }).Should(HaveLen(1))
 	})
 
 	AfterEach(func() {
@@ -88,7 +92,7 @@ var _ = Describe("Tasks", func() {
 
 			It("can write files to the mounted volume", func() {
 				var task *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(logger, guid)
@@ -127,7 +131,7 @@ var _ = Describe("Tasks", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				var task *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(logger, expectedTask.TaskGuid)
@@ -170,7 +174,7 @@ var _ = Describe("Tasks", func() {
 				Expect(err).NotTo(HaveOccurred())
 
 				var task *models.Task
-				Eventually(func() interface{} {
+				helpers.TimedEventually(func() interface{} {
 					var err error
 
 					task, err = bbsClient.TaskByGuid(logger, expectedTask.TaskGuid)
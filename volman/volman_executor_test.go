@@ -20,11 +20,11 @@ import (
 	"code.cloudfoundry.org/executor"
 	executorinit "code.cloudfoundry.org/executor/initializer"
 	"code.cloudfoundry.org/executor/initializer/configuration"
+	"code.cloudfoundry.org/inigo/helpers"
 	"code.cloudfoundry.org/inigo/world"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
 	. "github.com/onsi/ginkgo"
-	ginkgoconfig "github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
@@ -101,7 +101,7 @@ var _ = Describe("Executor/Garden/Volman", func() {
 			EnvoyConfigRefreshDelay:            durationjson.Duration(time.Second),
 			EnvoyDrainTimeout:                  durationjson.Duration(15 * time.Minute),
 		}
-		config.VolmanDriverPaths = path.Join(componentMaker.VolmanDriverConfigDir(), fmt.Sprintf("node-%d", ginkgoconfig.GinkgoConfig.ParallelNode))
+		config.VolmanDriverPaths = componentMaker.VolmanDriverPath()
 		config.GardenNetwork = "tcp"
 		config.GardenAddr = componentMaker.Addresses().Garden
 		config.HealthyMonitoringInterval = durationjson.Duration(time.Second)
@@ -216,7 +216,7 @@ var _ = Describe("Executor/Garden/Volman", func() {
 				It("container start should succeed", func() {
 					err := executorClient.RunContainer(logger, &runReq)
 					Expect(err).NotTo(HaveOccurred())
-					Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+					helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 					Expect(getContainer(guid).RunResult.Failed).Should(BeFalse())
 				})
 			})
@@ -300,7 +300,7 @@ var _ = Describe("Executor/Garden/Volman", func() {
 						BeforeEach(func() {
 							err := executorClient.RunContainer(logger, &runReq)
 							Expect(err).NotTo(HaveOccurred())
-							Eventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
+							helpers.TimedEventually(containerStatePoller(guid)).Should(Equal(executor.StateCompleted))
 							Expect(getContainer(guid).RunResult.Failed).Should(BeFalse())
 						})
 
@@ -355,7 +355,7 @@ var _ = Describe("Executor/Garden/Volman", func() {
 								runReq2 = executor.NewRunRequest(guid2, &runInfo, executor.Tags{})
 								err = executorClient.RunContainer(logger, &runReq2)
 								Expect(err).NotTo(HaveOccurred())
-								Eventually(containerStatePoller(guid2)).Should(Equal(executor.StateCompleted))
+								helpers.TimedEventually(containerStatePoller(guid2)).Should(Equal(executor.StateCompleted))
 								Expect(getContainer(guid2).RunResult.Failed).Should(BeFalse())
 								err = executorClient.DeleteContainer(logger, guid2)
 								Expect(err).NotTo(HaveOccurred())
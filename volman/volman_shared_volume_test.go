@@ -0,0 +1,301 @@
+package volman_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	archive_helper "code.cloudfoundry.org/archiver/extractor/test_helper"
+	"code.cloudfoundry.org/bbs"
+	"code.cloudfoundry.org/bbs/models"
+	"code.cloudfoundry.org/durationjson"
+	"code.cloudfoundry.org/inigo/fixtures"
+	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/world"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	repconfig "code.cloudfoundry.org/rep/cmd/rep/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+var _ = Describe("LRPs sharing a volume across cells", func() {
+	const driverName = "shareddriver"
+
+	var (
+		plumbing, components       ifrit.Process
+		cellAProcess, cellBProcess ifrit.Process
+		cellA, cellB               *world.CellHandle
+		drivers                    []*helpers.FakeVolumeDriver
+		sharedMountDir             string
+		logger                     lager.Logger
+		bbsClient                  bbs.InternalClient
+		processGuid                string
+		volumeId                   string
+		lrp                        *models.DesiredLRP
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("volman-shared-volume")
+
+		fileServerRunner, fileServerStaticDir := componentMaker.FileServer()
+		initialServices := grouper.Members{
+			{"sql", componentMaker.SQL()},
+		}
+		if componentMaker.ConsulEnabled() {
+			initialServices = append(initialServices, grouper.Member{"consul", componentMaker.Consul()})
+		}
+
+		plumbing = ginkgomon.Invoke(grouper.NewOrdered(os.Kill, grouper.Members{
+			{"initial-services", grouper.NewParallel(os.Kill, initialServices)},
+			{"locket", componentMaker.Locket()},
+			{"bbs", componentMaker.BBS()},
+		}))
+
+		helpers.ConsulWaitUntilReady(componentMaker.Addresses())
+
+		// specs talk to each instance directly (see instanceClients), so
+		// there is no router or route-emitter
+		components = ginkgomon.Invoke(grouper.NewParallel(os.Kill, grouper.Members{
+			{"file-server", fileServerRunner},
+			{"auctioneer", componentMaker.Auctioneer()},
+		}))
+
+		archive_helper.CreateZipArchive(
+			filepath.Join(fileServerStaticDir, "lrp.zip"),
+			fixtures.GoServerApp(),
+		)
+
+		evacuationTimeout := func(config *repconfig.RepConfig) {
+			config.EvacuationTimeout = durationjson.Duration(30 * time.Second)
+		}
+		cellA = componentMaker.Cell(0, evacuationTimeout)
+		cellB = componentMaker.Cell(1, evacuationTimeout)
+
+		// one driver per cell, all keeping their volumes in the same place,
+		// as a global-scope driver backed by an NFS server would
+		sharedMountDir = world.TempDir("shared-volumes")
+		drivers = nil
+		for n, cell := range []*world.CellHandle{cellA, cellB} {
+			driver := helpers.NewFakeVolumeDriver(logger, driverName, cell.ID, sharedMountDir)
+			driver.SetScope("global")
+			driver.Register(componentMaker.VolmanDriverPathN(n))
+			drivers = append(drivers, driver)
+		}
+
+		cellAProcess = ginkgomon.Invoke(cellA.Runner)
+		cellBProcess = ginkgomon.Invoke(cellB.Runner)
+
+		bbsClient = componentMaker.BBSClient()
+		bbsServiceClient := componentMaker.BBSServiceClient(logger)
+		helpers.TimedEventually(func() (models.CellSet, error) { return bbsServiceClient.Cells(logger) }).Should(HaveLen(2))
+
+		processGuid = helpers.GenerateGuid()
+		volumeId = fmt.Sprintf("shared-volume-%d", time.Now().UnixNano())
+
+		lrp = helpers.DefaultLRPCreateRequest(componentMaker.Addresses(), processGuid, "log-guid", 2)
+		lrp.Setup = nil
+		lrp.CachedDependencies = []*models.CachedDependency{{
+			From:      fmt.Sprintf("http://%s/v1/static/%s", componentMaker.Addresses().FileServer, "lrp.zip"),
+			To:        "/tmp/diego",
+			Name:      "lrp bits",
+			CacheKey:  "lrp-cache-key",
+			LogSource: "APP",
+		}}
+		// the fake driver bind mounts a host directory; an unprivileged
+		// container's user namespace would map its owner to nobody and vcap
+		// could not write or lock the files the specs share
+		lrp.Privileged = true
+		lrp.Action = models.WrapAction(&models.RunAction{
+			User: "vcap",
+			Path: "/tmp/diego/go-server",
+			Env: []*models.EnvironmentVariable{
+				{"PORT", "8080"},
+				{"MOUNT_POINT_DIR", "/shared"},
+			},
+		})
+		lrp.VolumeMounts = []*models.VolumeMount{{
+			Driver:       driverName,
+			ContainerDir: "/shared",
+			Mode:         "rw",
+			Shared: &models.SharedDevice{
+				VolumeId:    volumeId,
+				MountConfig: `{"uid":"2000"}`,
+			},
+		}}
+	})
+
+	JustBeforeEach(func() {
+		err := bbsClient.DesireLRP(logger, lrp)
+		Expect(err).NotTo(HaveOccurred())
+
+		helpers.TimedEventually(func() []string {
+			return runningCells(logger, bbsClient, processGuid)
+		}).Should(ConsistOf(cellA.ID, cellB.ID))
+	})
+
+	AfterEach(func() {
+		destroyContainerErrors := helpers.CleanupGarden(gardenClient)
+		helpers.StopProcesses(components, cellAProcess, cellBProcess, plumbing)
+		for _, driver := range drivers {
+			driver.Close()
+		}
+		Expect(destroyContainerErrors).To(
+			BeEmpty(),
+			"%d containers failed to be destroyed!",
+			len(destroyContainerErrors),
+		)
+	})
+
+	It("mounts the volume on both cells", func() {
+		for _, driver := range drivers {
+			Expect(driver).To(helpers.HaveMountedVolume(volumeId, map[string]interface{}{"uid": "2000"}))
+		}
+	})
+
+	It("shows files written on one cell to the instance on the other", func() {
+		instances := instanceClients(logger, bbsClient, processGuid)
+		Expect(instances).To(HaveLen(2))
+
+		Expect(instances[0].WriteVolumeFile("nested/dir/greeting.txt", "hello from instance 0\n")).To(Succeed())
+
+		Expect(instances[1].ReadVolumeFile("nested/dir/greeting.txt")).To(Equal("hello from instance 0\n"))
+		Expect(instances[1].ListVolumeDir("nested/dir")).To(ConsistOf(
+			helpers.VolumeFile{Name: "greeting.txt", Size: int64(len("hello from instance 0\n"))},
+		))
+	})
+
+	It("keeps writers on different cells apart with file locks", func() {
+		instances := instanceClients(logger, bbsClient, processGuid)
+		Expect(instances).To(HaveLen(2))
+
+		const writesPerInstance = 5
+		expectedLines := []string{}
+
+		wg := sync.WaitGroup{}
+		for index, instance := range instances {
+			for i := 0; i < writesPerInstance; i++ {
+				line := fmt.Sprintf("instance-%d-write-%d", index, i)
+				expectedLines = append(expectedLines, line)
+
+				wg.Add(1)
+				go func(instance *helpers.GoServerClient, line string) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(instance.LockedAppendVolumeFile("locked.log", line+"\n", 100*time.Millisecond)).To(Succeed())
+				}(instance, line)
+			}
+		}
+		wg.Wait()
+
+		contents, err := instances[0].ReadVolumeFile("locked.log")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Split(strings.TrimSpace(contents), "\n")).To(ConsistOf(expectedLines))
+	})
+
+	It("keeps the volume's data when an instance restarts", func() {
+		instances := instanceClients(logger, bbsClient, processGuid)
+		Expect(instances[0].WriteVolumeFile("survivor.txt", "still here\n")).To(Succeed())
+
+		By("crashing every instance")
+		for _, instance := range instances {
+			Expect(instance.Exit(1)).To(Succeed())
+		}
+
+		helpers.TimedEventually(func() int32 {
+			lrps, err := bbsClient.ActualLRPs(logger, models.ActualLRPFilter{ProcessGuid: processGuid})
+			Expect(err).NotTo(HaveOccurred())
+			crashes := int32(0)
+			for _, actualLRP := range lrps {
+				if actualLRP.State == models.ActualLRPStateRunning {
+					crashes += actualLRP.CrashCount
+				}
+			}
+			return crashes
+		}).Should(BeEquivalentTo(2))
+
+		for _, instance := range instanceClients(logger, bbsClient, processGuid) {
+			helpers.TimedEventually(func() (string, error) { return instance.ReadVolumeFile("survivor.txt") }).Should(Equal("still here\n"))
+		}
+	})
+
+	It("keeps the volume's data when a cell is evacuated", func() {
+		instances := instanceClients(logger, bbsClient, processGuid)
+		Expect(instances[0].WriteVolumeFile("evacuee.txt", "moved along\n")).To(Succeed())
+
+		By("evacuating cell A")
+		cellA.Evacuate()
+		helpers.TimedEventually(cellAProcess.Wait(), 30*time.Second).Should(Receive())
+
+		helpers.TimedEventually(func() []string {
+			return runningCells(logger, bbsClient, processGuid)
+		}).Should(ConsistOf(cellB.ID, cellB.ID))
+
+		for _, instance := range instanceClients(logger, bbsClient, processGuid) {
+			Expect(instance.ReadVolumeFile("evacuee.txt")).To(Equal("moved along\n"))
+		}
+	})
+
+	Context("when the volume is mounted read-only", func() {
+		BeforeEach(func() {
+			lrp.VolumeMounts[0].Mode = "r"
+
+			volumeDir := filepath.Join(sharedMountDir, volumeId)
+			Expect(os.MkdirAll(volumeDir, 0777)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(volumeDir, "existing.txt"), []byte("read me\n"), 0644)).To(Succeed())
+		})
+
+		It("can read the volume's files but not write them", func() {
+			for _, instance := range instanceClients(logger, bbsClient, processGuid) {
+				Expect(instance.ReadVolumeFile("existing.txt")).To(Equal("read me\n"))
+
+				err := instance.WriteVolumeFile("existing.txt", "overwritten\n")
+				Expect(err).To(MatchError(ContainSubstring("read-only file system")))
+				err = instance.WriteVolumeFile("new.txt", "created\n")
+				Expect(err).To(MatchError(ContainSubstring("read-only file system")))
+			}
+
+			Expect(ioutil.ReadFile(filepath.Join(sharedMountDir, volumeId, "existing.txt"))).To(Equal([]byte("read me\n")))
+		})
+	})
+})
+
+// runningCells returns the cell of every running instance of processGuid.
+func runningCells(logger lager.Logger, bbsClient bbs.InternalClient, processGuid string) []string {
+	lrps, err := bbsClient.ActualLRPs(logger, models.ActualLRPFilter{ProcessGuid: processGuid})
+	Expect(err).NotTo(HaveOccurred())
+
+	cells := []string{}
+	for _, actualLRP := range lrps {
+		if actualLRP.State == models.ActualLRPStateRunning && actualLRP.Presence == models.ActualLRP_Ordinary {
+			cells = append(cells, actualLRP.CellId)
+		}
+	}
+	return cells
+}
+
+// instanceClients returns a go-server client for every running instance of
+// processGuid, by index. They talk to each instance's host port rather than
+// the router, so that specs choose which instance they are talking to.
+func instanceClients(logger lager.Logger, bbsClient bbs.InternalClient, processGuid string) map[int32]*helpers.GoServerClient {
+	lrps, err := bbsClient.ActualLRPs(logger, models.ActualLRPFilter{ProcessGuid: processGuid})
+	Expect(err).NotTo(HaveOccurred())
+
+	clients := map[int32]*helpers.GoServerClient{}
+	for _, actualLRP := range lrps {
+		if actualLRP.State != models.ActualLRPStateRunning || actualLRP.Presence != models.ActualLRP_Ordinary {
+			continue
+		}
+		Expect(actualLRP.Ports).NotTo(BeEmpty())
+		address := fmt.Sprintf("%s:%d", actualLRP.Address, actualLRP.Ports[0].HostPort)
+		clients[actualLRP.Index] = helpers.NewGoServerClient(address, "")
+	}
+	return clients
+}
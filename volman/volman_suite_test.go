@@ -7,13 +7,13 @@ import (
 	"testing"
 
 	"fmt"
-	"path"
 	"path/filepath"
 
 	"code.cloudfoundry.org/consuladapter/consulrunner"
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/garden"
 	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/helpers/buildcache"
 	"code.cloudfoundry.org/inigo/helpers/certauthority"
 	"code.cloudfoundry.org/inigo/helpers/portauthority"
 	"code.cloudfoundry.org/inigo/world"
@@ -25,7 +25,6 @@ import (
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gexec"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 )
@@ -33,6 +32,7 @@ import (
 var (
 	componentMaker world.ComponentMaker
 
+	gardenRunner  ifrit.Runner
 	gardenProcess ifrit.Process
 	gardenClient  garden.Client
 
@@ -87,6 +87,10 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 		SQL:                 fmt.Sprintf("%sdiego_%d", dbBaseConnectionString, config.GinkgoConfig.ParallelNode),
 	}
 
+	if world.LocketOnly() {
+		addresses.Consul = ""
+	}
+
 	node := GinkgoParallelProcess()
 	startPort := 1000 * node
 	portRange := 950
@@ -106,6 +110,7 @@ var _ = SynchronizedBeforeSuite(func() []byte {
 })
 
 var _ = AfterSuite(func() {
+	Expect(helpers.WriteEventuallyTimings()).To(Succeed())
 	Expect(os.RemoveAll(certDepot)).To(Succeed())
 	componentMaker.Teardown()
 })
@@ -113,14 +118,15 @@ var _ = AfterSuite(func() {
 var _ = BeforeEach(func() {
 	logger = lagertest.NewTestLogger("volman-inigo-suite")
 
-	gardenProcess = ginkgomon.Invoke(componentMaker.Garden())
+	gardenRunner = componentMaker.Garden()
+	gardenProcess = ginkgomon.Invoke(gardenRunner)
 	gardenClient = componentMaker.GardenClient()
 
 	localDriverRunner, driverClient = componentMaker.VolmanDriver(logger)
 	localDriverProcess = ginkgomon.Invoke(localDriverRunner)
 
 	// make a dummy spec file not corresponding to a running driver just to make sure volman ignores it
-	driverPluginsPath = path.Join(componentMaker.VolmanDriverConfigDir(), fmt.Sprintf("node-%d", config.GinkgoConfig.ParallelNode))
+	driverPluginsPath = componentMaker.VolmanDriverPath()
 	dockerdriver.WriteDriverSpec(logger, driverPluginsPath, "deaddriver", "json", []byte(`{"Name":"deaddriver","Addr":"https://127.0.0.1:1111"}`))
 
 	volmanClient, driverSyncer = componentMaker.VolmanClient(logger)
@@ -130,7 +136,11 @@ var _ = BeforeEach(func() {
 var _ = AfterEach(func() {
 	destroyContainerErrors := helpers.CleanupGarden(gardenClient)
 
-	helpers.StopProcesses(gardenProcess, driverSyncerProcess, localDriverProcess)
+	helpers.StopNamed(
+		helpers.NewNamedProcess("garden", gardenRunner, gardenProcess),
+		helpers.NewNamedProcess("volman-driver-syncer", driverSyncer, driverSyncerProcess),
+		helpers.NewNamedProcess("local-driver", localDriverRunner, localDriverProcess),
+	)
 
 	Expect(destroyContainerErrors).To(
 		BeEmpty(),
@@ -142,7 +152,10 @@ var _ = AfterEach(func() {
 })
 
 func TestVolman(t *testing.T) {
-	helpers.RegisterDefaultTimeouts()
+	err := helpers.RegisterDefaultTimeouts()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	RegisterFailHandler(Fail)
 
@@ -161,37 +174,37 @@ func CompileTestedExecutables() world.BuiltExecutables {
 
 	Expect(err).NotTo(HaveOccurred())
 	Expect(os.Chdir(os.Getenv("GARDEN_GOPATH"))).To(Succeed())
-	builtExecutables["garden"], err = gexec.Build("./cmd/gdn", "-race", "-a", "-tags", "daemon")
+	builtExecutables["garden"], err = buildcache.Build("./cmd/gdn", "-race", "-a", "-tags", "daemon")
 	Expect(err).NotTo(HaveOccurred())
 	Expect(os.Chdir(cwd)).To(Succeed())
 
-	builtExecutables["local-driver"], err = gexec.Build("code.cloudfoundry.org/localdriver/cmd/localdriver", "-race")
+	builtExecutables["local-driver"], err = buildcache.Build("code.cloudfoundry.org/localdriver/cmd/localdriver", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["auctioneer"], err = gexec.Build("code.cloudfoundry.org/auctioneer/cmd/auctioneer", "-race")
+	builtExecutables["auctioneer"], err = buildcache.Build("code.cloudfoundry.org/auctioneer/cmd/auctioneer", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["rep"], err = gexec.Build("code.cloudfoundry.org/rep/cmd/rep", "-race")
+	builtExecutables["rep"], err = buildcache.Build("code.cloudfoundry.org/rep/cmd/rep", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["bbs"], err = gexec.Build("code.cloudfoundry.org/bbs/cmd/bbs", "-race")
+	builtExecutables["bbs"], err = buildcache.Build("code.cloudfoundry.org/bbs/cmd/bbs", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["locket"], err = gexec.Build("code.cloudfoundry.org/locket/cmd/locket", "-race")
+	builtExecutables["locket"], err = buildcache.Build("code.cloudfoundry.org/locket/cmd/locket", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["file-server"], err = gexec.Build("code.cloudfoundry.org/fileserver/cmd/file-server", "-race")
+	builtExecutables["file-server"], err = buildcache.Build("code.cloudfoundry.org/fileserver/cmd/file-server", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	builtExecutables["route-emitter"], err = gexec.Build("code.cloudfoundry.org/route-emitter/cmd/route-emitter", "-race")
+	builtExecutables["route-emitter"], err = buildcache.Build("code.cloudfoundry.org/route-emitter/cmd/route-emitter", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
 	Expect(os.Chdir(os.Getenv("ROUTER_GOPATH"))).To(Succeed())
-	builtExecutables["router"], err = gexec.Build("code.cloudfoundry.org/gorouter", "-race")
+	builtExecutables["router"], err = buildcache.Build("code.cloudfoundry.org/gorouter", "-race")
 	Expect(err).NotTo(HaveOccurred())
 	Expect(os.Chdir(cwd)).To(Succeed())
 
-	builtExecutables["ssh-proxy"], err = gexec.Build("code.cloudfoundry.org/diego-ssh/cmd/ssh-proxy", "-race")
+	builtExecutables["ssh-proxy"], err = buildcache.Build("code.cloudfoundry.org/diego-ssh/cmd/ssh-proxy", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
 	return builtExecutables
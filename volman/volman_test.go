@@ -1,11 +1,15 @@
 package volman_test
 
 import (
+	"context"
 	"os"
 	"path"
+	"time"
 
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
 	dockerdriverutils "code.cloudfoundry.org/dockerdriver/utils"
 	"code.cloudfoundry.org/inigo/helpers"
+	"code.cloudfoundry.org/inigo/world"
 	"code.cloudfoundry.org/localdriver"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -66,7 +70,7 @@ var _ = Describe("Given volman and localdriver", func() {
 		})
 
 		It("should purge existing mount points", func() {
-			Eventually(func() bool {
+			helpers.TimedEventually(func() bool {
 				_, err := os.Stat(expectedMountPath)
 				return os.IsNotExist(err)
 			}).Should(Equal(false))
@@ -74,10 +78,121 @@ var _ = Describe("Given volman and localdriver", func() {
 			volmanClient, driverSyncer = componentMaker.VolmanClient(logger)
 			driverSyncerProcess = ginkgomon.Invoke(driverSyncer)
 
-			Eventually(func() bool {
+			helpers.TimedEventually(func() bool {
 				_, err := os.Stat(expectedMountPath)
 				return os.IsNotExist(err)
 			}).Should(Equal(true))
 		})
 	})
 })
+
+var _ = Describe("Given volman and a fake driver", func() {
+	var (
+		driver       *helpers.FakeVolumeDriver
+		volumeId     string
+		containerId  string
+		volumeConfig map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		volumeId = "fake-volume"
+		containerId = "test-container"
+		volumeConfig = map[string]interface{}{"uid": "2000", "readonly": "true"}
+
+		driver = helpers.NewFakeVolumeDriver(logger, "fakedriver", "the-cell", world.TempDir("fake-driver-mounts"))
+		driver.Register(componentMaker.VolmanDriverPath())
+
+		// volman only discovers drivers when it syncs
+		helpers.StopProcesses(driverSyncerProcess)
+		volmanClient, driverSyncer = componentMaker.VolmanClient(logger)
+		driverSyncerProcess = ginkgomon.Invoke(driverSyncer)
+	})
+
+	AfterEach(func() {
+		driver.Close()
+	})
+
+	It("mounts the volume with the given opts", func() {
+		mountPointResponse, err := volmanClient.Mount(logger, driver.Name, volumeId, containerId, volumeConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mountPointResponse.Path).NotTo(BeEmpty())
+
+		Expect(driver).To(helpers.HaveMountedVolume(volumeId, volumeConfig))
+		Expect(driver.CallCount("Mount")).To(Equal(1))
+	})
+
+	Context("when mounting fails", func() {
+		BeforeEach(func() {
+			driver.FailMount("mount.nfs: access denied by server")
+		})
+
+		It("returns the driver's error", func() {
+			_, err := volmanClient.Mount(logger, driver.Name, volumeId, containerId, volumeConfig)
+			Expect(err).To(MatchError(ContainSubstring("access denied by server")))
+			Expect(driver.Mounts()).To(BeEmpty())
+		})
+
+		It("records the opts of the failed mount", func() {
+			_, err := volmanClient.Mount(logger, driver.Name, volumeId, containerId, volumeConfig)
+			Expect(err).To(HaveOccurred())
+
+			mountCalls := []helpers.VolumeDriverCall{}
+			for _, call := range driver.Calls() {
+				if call.Method == "Mount" {
+					mountCalls = append(mountCalls, call)
+				}
+			}
+			Expect(mountCalls).NotTo(BeEmpty())
+			Expect(mountCalls[0].Opts).To(Equal(volumeConfig))
+			Expect(mountCalls[0].Err).To(Equal("mount.nfs: access denied by server"))
+		})
+	})
+
+	Context("when mounting is slow", func() {
+		BeforeEach(func() {
+			driver.DelayMount(2 * time.Second)
+		})
+
+		It("waits for the driver", func() {
+			start := time.Now()
+			_, err := volmanClient.Mount(logger, driver.Name, volumeId, containerId, volumeConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically(">=", 2*time.Second))
+		})
+	})
+
+	Context("when the driver advertises global scope", func() {
+		BeforeEach(func() {
+			driver.SetScope("global")
+		})
+
+		It("reports it through its capabilities", func() {
+			client, err := driverhttp.NewRemoteClient(driver.URL(), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			env := driverhttp.NewHttpDriverEnv(logger, context.TODO())
+			Expect(client.Capabilities(env).Capabilities.Scope).To(Equal("global"))
+		})
+
+		It("is still mounted through volman", func() {
+			_, err := volmanClient.Mount(logger, driver.Name, volumeId, containerId, volumeConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(driver).To(helpers.HaveMountedVolume(volumeId, volumeConfig))
+		})
+	})
+
+	Context("when unmounting fails", func() {
+		BeforeEach(func() {
+			_, err := volmanClient.Mount(logger, driver.Name, volumeId, containerId, volumeConfig)
+			Expect(err).NotTo(HaveOccurred())
+
+			driver.FailUnmount("device is busy")
+		})
+
+		It("returns the driver's error and leaves the volume mounted", func() {
+			err := volmanClient.Unmount(logger, driver.Name, volumeId, containerId)
+			Expect(err).To(MatchError(ContainSubstring("device is busy")))
+			Expect(driver).To(helpers.HaveMountedVolume(volumeId, volumeConfig))
+		})
+	})
+})
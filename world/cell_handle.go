@@ -0,0 +1,155 @@
+package world
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/garden"
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/rep"
+	repconfig "code.cloudfoundry.org/rep/cmd/rep/config"
+	"code.cloudfoundry.org/tlsconfig"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// CellHandle is a rep with its own ports, returned by ComponentMaker.Cell. It
+// talks to the rep's admin and state endpoints over mutual TLS, so that specs
+// can evacuate and inspect a cell without building their own clients.
+//
+//	cell := componentMaker.Cell(0)
+//	process := ginkgomon.Invoke(cell.Runner)
+//	cell.Evacuate()
+//	cell.WaitUntilDrained(time.Minute)
+type CellHandle struct {
+	ID     string
+	Runner *ginkgomon.Runner
+
+	// ListenAddr and ListenAddrSecurable are the rep's admin and state
+	// endpoints, reachable on 127.0.0.1
+	ListenAddr          string
+	ListenAddrSecurable string
+
+	config        repconfig.RepConfig
+	httpClient    *http.Client
+	repClient     rep.Client
+	gardenClient  garden.Client
+	adminPort     uint16
+	securablePort uint16
+}
+
+func newCellHandle(maker commonComponentMaker, n int, repN func(int, ...func(*repconfig.RepConfig)) *ginkgomon.Runner, modifyConfigFuncs ...func(*repconfig.RepConfig)) *CellHandle {
+	ports, err := maker.portAllocator.ClaimPorts(2)
+	Expect(err).NotTo(HaveOccurred())
+
+	cell := &CellHandle{
+		ID:                  fmt.Sprintf("cell-%d-%d", n, GinkgoParallelProcess()),
+		ListenAddr:          fmt.Sprintf("0.0.0.0:%d", ports),
+		ListenAddrSecurable: fmt.Sprintf("0.0.0.0:%d", ports+1),
+		gardenClient:        maker.GardenClient(),
+		adminPort:           ports,
+		securablePort:       ports + 1,
+	}
+
+	configure := func(cfg *repconfig.RepConfig) {
+		cfg.CellID = cell.ID
+		cfg.ListenAddr = cell.ListenAddr
+		cfg.ListenAddrSecurable = cell.ListenAddrSecurable
+	}
+	record := func(cfg *repconfig.RepConfig) {
+		cell.config = *cfg
+		cell.ID = cfg.CellID
+	}
+
+	funcs := append([]func(*repconfig.RepConfig){configure}, modifyConfigFuncs...)
+	cell.Runner = repN(n, append(funcs, record)...)
+
+	tlsConfig, err := tlsconfig.Build(
+		tlsconfig.WithInternalServiceDefaults(),
+		tlsconfig.WithIdentityFromFile(maker.repSSL.ServerCert, maker.repSSL.ServerKey),
+	).Client(
+		tlsconfig.WithAuthorityFromFile(maker.repSSL.CACert),
+	)
+	Expect(err).NotTo(HaveOccurred())
+
+	cell.httpClient = &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	// the rep's certificate is valid for 127.0.0.1
+	cell.repClient, err = maker.RepClientFactory().CreateClient(
+		fmt.Sprintf("https://127.0.0.1:%d", cell.adminPort),
+		fmt.Sprintf("https://127.0.0.1:%d", cell.securablePort),
+	)
+	Expect(err).NotTo(HaveOccurred())
+
+	return cell
+}
+
+// Config returns the rep's configuration, once Runner has been built.
+func (c *CellHandle) Config() repconfig.RepConfig {
+	return c.config
+}
+
+// Evacuate asks the rep to evacuate, and expects it to accept.
+func (c *CellHandle) Evacuate() {
+	resp, err := c.httpClient.Post(c.adminURL("/evacuate"), "text/html", nil)
+	Expect(err).NotTo(HaveOccurred())
+	resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusAccepted))
+}
+
+// Ping returns an error unless the rep's admin endpoint reports it healthy.
+func (c *CellHandle) Ping() error {
+	resp, err := c.httpClient.Get(c.adminURL("/ping"))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rep %s responded to ping with %d", c.ID, resp.StatusCode)
+	}
+	return nil
+}
+
+// State returns the rep's view of its cell, as used by the auctioneer.
+func (c *CellHandle) State(logger lager.Logger) (rep.CellState, error) {
+	return c.repClient.State(logger)
+}
+
+// Client returns a rep client for the cell, e.g. to stop instances on it
+// directly.
+func (c *CellHandle) Client() rep.Client {
+	return c.repClient
+}
+
+// Containers returns the containers the cell's executor has created in
+// garden, leaving out its healthcheck containers. Reps without a
+// ContainerOwnerName (as made by the v0 ComponentMaker) cannot be told apart,
+// so for them every garden container is returned.
+func (c *CellHandle) Containers() ([]garden.Container, error) {
+	var properties garden.Properties
+	if owner := c.config.ContainerOwnerName; owner != "" {
+		properties = garden.Properties{"executor:owner": owner}
+	}
+	return c.gardenClient.Containers(properties)
+}
+
+// WaitUntilDrained waits for the cell to have no containers left, e.g. after
+// Evacuate.
+func (c *CellHandle) WaitUntilDrained(timeout time.Duration) {
+	Eventually(func() ([]garden.Container, error) {
+		return c.Containers()
+	}, timeout).Should(BeEmpty(), fmt.Sprintf("cell %s did not drain", c.ID))
+}
+
+func (c *CellHandle) adminURL(path string) string {
+	return fmt.Sprintf("https://127.0.0.1:%d%s", c.adminPort, path)
+}
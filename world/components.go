@@ -41,14 +41,18 @@ import (
 	gardenclient "code.cloudfoundry.org/garden/client"
 	gardenconnection "code.cloudfoundry.org/garden/client/connection"
 	"code.cloudfoundry.org/guardian/gqt/runner"
+	"code.cloudfoundry.org/inigo/helpers/buildcache"
 	"code.cloudfoundry.org/inigo/helpers/certauthority"
+	"code.cloudfoundry.org/inigo/helpers/fakegarden"
 	"code.cloudfoundry.org/inigo/helpers/portauthority"
+	"code.cloudfoundry.org/inigo/helpers/timeoutprofile"
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagerflags"
 	"code.cloudfoundry.org/lager/lagertest"
 	"code.cloudfoundry.org/locket"
 	locketconfig "code.cloudfoundry.org/locket/cmd/locket/config"
 	locketrunner "code.cloudfoundry.org/locket/cmd/locket/testrunner"
+	locketmodels "code.cloudfoundry.org/locket/models"
 	"code.cloudfoundry.org/rep"
 	repconfig "code.cloudfoundry.org/rep/cmd/rep/config"
 	"code.cloudfoundry.org/rep/maintain"
@@ -61,7 +65,6 @@ import (
 	. "github.com/onsi/ginkgo"
 	"github.com/onsi/ginkgo/config"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gexec"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/ginkgomon"
 	"github.com/tedsuo/ifrit/grouper"
@@ -131,6 +134,9 @@ type GrootFSConfig struct {
 	}
 }
 
+// ComponentAddresses are where the components listen. Leave Consul empty to
+// run Diego without Consul; locks and cell presences then go through Locket
+// alone (see ConsulEnabled).
 type ComponentAddresses struct {
 	NATS                string
 	Consul              string
@@ -165,12 +171,15 @@ func DBInfo() (string, string) {
 }
 
 func makeCommonComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses ComponentAddresses, allocator portauthority.PortAllocator, certAuthority certauthority.CertAuthority) commonComponentMaker {
-	startCheckTimeout := 10 * time.Second
-	if timeout, found := os.LookupEnv("START_CHECK_TIMEOUT_DURATION"); found && timeout != "" {
-		var err error
-		startCheckTimeout, err = time.ParseDuration(timeout)
-		Expect(err).NotTo(HaveOccurred(), fmt.Sprintf("%s not a valid duration", timeout))
-	}
+	profile, err := timeoutprofile.Current()
+	Expect(err).NotTo(HaveOccurred())
+
+	startCheckTimeout, err := profile.DurationFromEnv("START_CHECK_TIMEOUT_DURATION", 10*time.Second)
+	Expect(err).NotTo(HaveOccurred())
+
+	// rep is not started until it can ping an executor and run a healthcheck
+	// container on garden; this can take a bit to start, so account for it
+	repStartCheckTimeout := profile.Scale(2 * time.Minute)
 
 	tmpDir := TempDir("component-maker")
 
@@ -184,12 +193,20 @@ func makeCommonComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses Comp
 		gardenGraphPath = TempDirWithParent(tmpDir, "garden-graph")
 	}
 
-	Expect(grootfsBinPath).NotTo(BeEmpty(), "must provide $GROOTFS_BINPATH")
-	if runtime.GOOS == "windows" {
-		Expect(grootfsStorePath).NotTo(BeEmpty(), "must provide $GROOTFS_STORE_PATH")
+	fakeGarden := UseFakeGarden()
+	if fakeGarden {
+		// the fake garden ignores the rootfs, but the rep still needs one per stack
+		if gardenRootFSPath == "" {
+			gardenRootFSPath = TempDirWithParent(tmpDir, "fake-garden-rootfs")
+		}
+	} else {
+		Expect(grootfsBinPath).NotTo(BeEmpty(), "must provide $GROOTFS_BINPATH")
+		if runtime.GOOS == "windows" {
+			Expect(grootfsStorePath).NotTo(BeEmpty(), "must provide $GROOTFS_STORE_PATH")
+		}
+		Expect(gardenBinPath).NotTo(BeEmpty(), "must provide $GARDEN_BINPATH")
+		Expect(gardenRootFSPath).NotTo(BeEmpty(), "must provide $GARDEN_ROOTFS")
 	}
-	Expect(gardenBinPath).NotTo(BeEmpty(), "must provide $GARDEN_BINPATH")
-	Expect(gardenRootFSPath).NotTo(BeEmpty(), "must provide $GARDEN_ROOTFS")
 
 	// tests depend on this env var to be set
 	externalAddress := os.Getenv("EXTERNAL_ADDRESS")
@@ -225,6 +242,10 @@ func makeCommonComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses Comp
 	Expect(err).NotTo(HaveOccurred())
 	routingAPIKey, routingAPICert, err := certAuthority.GenerateSelfSignedCertAndKey("routing_api_server", []string{"routing_api_server"}, false)
 	Expect(err).NotTo(HaveOccurred())
+	routerKey, routerCert, err := certAuthority.GenerateSelfSignedCertAndKey("router_server", []string{"router_server"}, false)
+	Expect(err).NotTo(HaveOccurred())
+	natsKey, natsCert, err := certAuthority.GenerateSelfSignedCertAndKey("nats_server", []string{"nats_server", "127.0.0.1"}, false)
+	Expect(err).NotTo(HaveOccurred())
 	clientKey, clientCert, err := certAuthority.GenerateSelfSignedCertAndKey("client", []string{"client"}, false)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -268,6 +289,30 @@ func makeCommonComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses Comp
 		CACert:     caCert,
 	}
 
+	routerSSLConfig := SSLConfig{
+		ServerCert: routerCert,
+		ServerKey:  routerKey,
+		ClientCert: clientCert,
+		ClientKey:  clientKey,
+		CACert:     caCert,
+	}
+
+	routerStatusPassword, err := uuid.NewV4()
+	Expect(err).NotTo(HaveOccurred())
+
+	natsSSLConfig := SSLConfig{
+		ServerCert: natsCert,
+		ServerKey:  natsKey,
+		ClientCert: clientCert,
+		ClientKey:  clientKey,
+		CACert:     caCert,
+	}
+
+	// the first NATS node's cluster port is claimed up front so that every
+	// later node can route to it
+	natsClusterPort, err := allocator.ClaimPorts(1)
+	Expect(err).NotTo(HaveOccurred())
+
 	storeTimestamp := time.Now().UnixNano()
 
 	unprivilegedGrootfsConfig := GrootFSConfig{
@@ -317,12 +362,20 @@ func makeCommonComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses Comp
 		rootFSes: stackPathMap,
 
 		gardenConfig:           gardenConfig,
+		fakeGarden:             fakeGarden,
 		sshConfig:              sshKeys,
 		bbsSSL:                 bbsSSLConfig,
 		locketSSL:              locketSSLConfig,
 		repSSL:                 repSSLConfig,
 		auctioneerSSL:          auctioneerSSLConfig,
 		routingAPISSL:          routingApiSSLConfig,
+		routerSSL:              routerSSLConfig,
+		routerStatusUser:       "router-status",
+		routerStatusPassword:   routerStatusPassword.String(),
+		natsSSL:                natsSSLConfig,
+		natsUser:               "nats",
+		natsPassword:           "nats",
+		natsClusterPort:        natsClusterPort,
 		sqlCACertFile:          sqlCACert,
 		volmanDriverConfigDir:  volmanConfigDir,
 		dbDriverName:           dbDriverName,
@@ -330,13 +383,16 @@ func makeCommonComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses Comp
 
 		portAllocator: allocator,
 
-		startCheckTimeout: startCheckTimeout,
+		startCheckTimeout:    startCheckTimeout,
+		repStartCheckTimeout: repStartCheckTimeout,
 
 		tmpDir: tmpDir,
 	}
 }
 
 func MakeV0ComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses ComponentAddresses, allocator portauthority.PortAllocator, certAuthority certauthority.CertAuthority) ComponentMaker {
+	// v0 components predate Locket presences and only find each other through Consul
+	Expect(worldAddresses.Consul).NotTo(BeEmpty(), "v0 components need a Consul address")
 	return v0ComponentMaker{commonComponentMaker: makeCommonComponentMaker(builtArtifacts, worldAddresses, allocator, certAuthority)}
 }
 
@@ -346,34 +402,52 @@ func MakeComponentMaker(builtArtifacts BuiltArtifacts, worldAddresses ComponentA
 
 type ComponentMaker interface {
 	VolmanDriverConfigDir() string
+	VolmanDriverPath() string
+	VolmanDriverPathN(n int) string
 	SSHConfig() SSHKeys
 	Artifacts() BuiltArtifacts
 	PortAllocator() portauthority.PortAllocator
 	Addresses() ComponentAddresses
 	Auctioneer(modifyConfigFuncs ...func(cfg *auctioneerconfig.AuctioneerConfig)) ifrit.Runner
+	AuctioneerN(n int, modifyConfigFuncs ...func(cfg *auctioneerconfig.AuctioneerConfig)) ControlPlaneInstance
 	BBS(modifyConfigFuncs ...func(*bbsconfig.BBSConfig)) ifrit.Runner
+	BBSN(n int, modifyConfigFuncs ...func(*bbsconfig.BBSConfig)) ControlPlaneInstance
+	BBSClientAt(address string) bbs.InternalClient
 	BBSClient() bbs.InternalClient
 	RepClientFactory() rep.ClientFactory
 	BBSServiceClient(logger lager.Logger) serviceclient.ServiceClient
 	BBSURL() string
 	BBSSSLConfig() SSLConfig
+	Cell(n int, modifyConfigFuncs ...func(*repconfig.RepConfig)) *CellHandle
 	Consul(argv ...string) ifrit.Runner
 	ConsulCluster() string
+	ConsulEnabled() bool
 	DefaultStack() string
+	FailoverProxy(backends ...string) (ifrit.Runner, string)
+	FakeGardenEnabled() bool
 	FileServer() (ifrit.Runner, string)
 	Garden(fs ...func(*runner.GdnRunnerConfig)) ifrit.Runner
 	GardenClient() garden.Client
+	GardenProxy(proxy *fakegarden.Proxy) (ifrit.Runner, string)
 	GardenWithoutDefaultStack() ifrit.Runner
 	GrootFSDeleteStore()
 	GrootFSInitStore()
 	Locket(modifyConfigFuncs ...func(*locketconfig.LocketConfig)) ifrit.Runner
+	LocketN(n int, modifyConfigFuncs ...func(*locketconfig.LocketConfig)) ControlPlaneInstance
+	LocketClient(logger lager.Logger) locketmodels.LocketClient
+	LocketClientAt(logger lager.Logger, address string) locketmodels.LocketClient
 	NATS(argv ...string) ifrit.Runner
+	NATSN(n int, modifyConfigFuncs ...func(*NATSConfig)) (ifrit.Runner, string)
+	NATSCredentials() (string, string)
+	NATSSSLConfig() SSLConfig
 	Rep(modifyConfigFuncs ...func(*repconfig.RepConfig)) *ginkgomon.Runner
 	RepN(n int, modifyConfigFuncs ...func(*repconfig.RepConfig)) *ginkgomon.Runner
 	RepSSLConfig() SSLConfig
 	RouteEmitter(fs ...func(config *routeemitterconfig.RouteEmitterConfig)) ifrit.Runner
 	RouteEmitterN(n int, fs ...func(config *routeemitterconfig.RouteEmitterConfig)) ifrit.Runner
-	Router() ifrit.Runner
+	Router(modifyConfigFuncs ...func(*RouterConfig)) ifrit.Runner
+	RouterSSLConfig() SSLConfig
+	RouterStatusCredentials() (string, string)
 	RoutingAPI(modifyConfigFuncs ...func(*routingapi.Config)) *routingapi.RoutingAPIRunner
 	SQL(argv ...string) ifrit.Runner
 	SSHProxy(modifyConfigFuncs ...func(*sshproxyconfig.SSHProxyConfig)) ifrit.Runner
@@ -388,18 +462,27 @@ type commonComponentMaker struct {
 	addresses              ComponentAddresses
 	rootFSes               repconfig.RootFSes
 	gardenConfig           GardenSettingsConfig
+	fakeGarden             bool
 	sshConfig              SSHKeys
 	bbsSSL                 SSLConfig
 	locketSSL              SSLConfig
 	repSSL                 SSLConfig
 	auctioneerSSL          SSLConfig
 	routingAPISSL          SSLConfig
+	routerSSL              SSLConfig
+	routerStatusUser       string
+	routerStatusPassword   string
+	natsSSL                SSLConfig
+	natsUser               string
+	natsPassword           string
+	natsClusterPort        uint16
 	sqlCACertFile          string
 	volmanDriverConfigDir  string
 	dbDriverName           string
 	dbBaseConnectionString string
 	portAllocator          portauthority.PortAllocator
 	startCheckTimeout      time.Duration
+	repStartCheckTimeout   time.Duration
 	tmpDir                 string
 }
 
@@ -407,6 +490,22 @@ func (maker commonComponentMaker) VolmanDriverConfigDir() string {
 	return maker.volmanDriverConfigDir
 }
 
+// VolmanDriverPath is where volman and the reps look for driver specs on
+// this ginkgo node.
+func (maker commonComponentMaker) VolmanDriverPath() string {
+	return path.Join(maker.volmanDriverConfigDir, fmt.Sprintf("node-%d", config.GinkgoConfig.ParallelNode))
+}
+
+// VolmanDriverPathN is where the rep made by RepN(n) looks for driver specs.
+// Rep 0 shares VolmanDriverPath with volman; every other rep gets a path of
+// its own, so that a driver can be registered on some cells but not others.
+func (maker commonComponentMaker) VolmanDriverPathN(n int) string {
+	if n == 0 {
+		return maker.VolmanDriverPath()
+	}
+	return path.Join(maker.volmanDriverConfigDir, fmt.Sprintf("node-%d-rep-%d", config.GinkgoConfig.ParallelNode, n))
+}
+
 func (maker commonComponentMaker) SSHConfig() SSHKeys {
 	return maker.sshConfig
 }
@@ -431,14 +530,24 @@ func (maker commonComponentMaker) RepSSLConfig() SSLConfig {
 	return maker.repSSL
 }
 
+func (maker commonComponentMaker) RouterSSLConfig() SSLConfig {
+	return maker.routerSSL
+}
+
+// RouterStatusCredentials returns the basic auth user and password for the
+// router's status endpoint.
+func (maker commonComponentMaker) RouterStatusCredentials() (string, string) {
+	return maker.routerStatusUser, maker.routerStatusPassword
+}
+
 func (maker commonComponentMaker) Setup() {
-	if runtime.GOOS != "windows" {
+	if runtime.GOOS != "windows" && !maker.fakeGarden {
 		maker.GrootFSInitStore()
 	}
 }
 
 func (maker commonComponentMaker) Teardown() {
-	if runtime.GOOS != "windows" {
+	if runtime.GOOS != "windows" && !maker.fakeGarden {
 		maker.GrootFSDeleteStore()
 	}
 
@@ -446,25 +555,6 @@ func (maker commonComponentMaker) Teardown() {
 	Eventually(deleteTmpDir).Should(Succeed())
 }
 
-func (maker commonComponentMaker) NATS(argv ...string) ifrit.Runner {
-	host, port, err := net.SplitHostPort(maker.addresses.NATS)
-	Expect(err).NotTo(HaveOccurred())
-
-	return ginkgomon.New(ginkgomon.Config{
-		Name:              "nats-server",
-		AnsiColorCode:     "30m",
-		StartCheck:        "Server is ready",
-		StartCheckTimeout: maker.startCheckTimeout,
-		Command: exec.Command(
-			"nats-server",
-			append([]string{
-				"--addr", host,
-				"--port", port,
-			}, argv...)...,
-		),
-	})
-}
-
 func (maker commonComponentMaker) SQL(argv ...string) ifrit.Runner {
 	return ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
 		defer GinkgoRecover()
@@ -506,6 +596,8 @@ func (maker commonComponentMaker) SQL(argv ...string) ifrit.Runner {
 }
 
 func (maker commonComponentMaker) Consul(argv ...string) ifrit.Runner {
+	Expect(maker.ConsulEnabled()).To(BeTrue(), "no Consul address was configured")
+
 	_, port, err := net.SplitHostPort(maker.addresses.Consul)
 	Expect(err).NotTo(HaveOccurred())
 	httpPort, err := strconv.Atoi(port)
@@ -619,7 +711,13 @@ func (maker commonComponentMaker) Garden(fs ...func(*runner.GdnRunnerConfig)) if
 	return maker.garden(true, fs...)
 }
 
+// garden makes gdn, or the fake garden when FakeGardenEnabled; the fake
+// takes no configuration, so fs is ignored for it.
 func (maker commonComponentMaker) garden(includeDefaultStack bool, fs ...func(*runner.GdnRunnerConfig)) ifrit.Runner {
+	if maker.fakeGarden {
+		return maker.fakeGardenRunner()
+	}
+
 	defaultRootFS := ""
 	if includeDefaultStack {
 		defaultRootFS = maker.rootFSes.StackPathMap()[maker.DefaultStack()]
@@ -753,14 +851,27 @@ func (maker commonComponentMaker) RoutingAPI(modifyConfigFuncs ...func(*routinga
 }
 
 func (maker commonComponentMaker) Locket(modifyConfigFuncs ...func(*locketconfig.LocketConfig)) ifrit.Runner {
-	return locketrunner.NewLocketRunner(maker.artifacts.Executables["locket"], func(cfg *locketconfig.LocketConfig) {
+	return maker.LocketN(0, modifyConfigFuncs...).Runner
+}
+
+// LocketN makes the nth of several Locket servers sharing one database. The
+// first listens on ComponentAddresses.Locket, which is where every other
+// component looks for Locket; put the others behind a FailoverProxy to fail
+// over between them.
+func (maker commonComponentMaker) LocketN(n int, modifyConfigFuncs ...func(*locketconfig.LocketConfig)) ControlPlaneInstance {
+	instance := ControlPlaneInstance{
+		Name:    instanceName("locket", n),
+		Address: maker.instanceAddresses(n, maker.addresses.Locket)[0],
+	}
+
+	instance.Runner = locketrunner.NewLocketRunner(maker.artifacts.Executables["locket"], func(cfg *locketconfig.LocketConfig) {
 		cfg.CertFile = maker.locketSSL.ServerCert
 		cfg.KeyFile = maker.locketSSL.ServerKey
 		cfg.CaFile = maker.locketSSL.CACert
 		cfg.ConsulCluster = maker.ConsulCluster()
 		cfg.DatabaseConnectionString = maker.addresses.SQL
 		cfg.DatabaseDriver = maker.dbDriverName
-		cfg.ListenAddress = maker.addresses.Locket
+		cfg.ListenAddress = instance.Address
 		cfg.SQLCACertFile = maker.sqlCACertFile
 		cfg.LagerConfig = lagerflags.LagerConfig{
 			LogLevel:   "debug",
@@ -771,6 +882,8 @@ func (maker commonComponentMaker) Locket(modifyConfigFuncs ...func(*locketconfig
 			modifyConfig(cfg)
 		}
 	})
+
+	return instance
 }
 
 func (maker commonComponentMaker) RouteEmitterN(n int, fs ...func(config *routeemitterconfig.RouteEmitterConfig)) ifrit.Runner {
@@ -781,7 +894,7 @@ func (maker commonComponentMaker) RouteEmitterN(n int, fs ...func(config *routee
 	defer configFile.Close()
 
 	cfg := routeemitterconfig.RouteEmitterConfig{
-		ConsulEnabled:     true,
+		ConsulEnabled:     maker.ConsulEnabled(),
 		ConsulSessionName: name,
 		NATSAddresses:     maker.addresses.NATS,
 		BBSAddress:        maker.BBSURL(),
@@ -797,8 +910,8 @@ func (maker commonComponentMaker) RouteEmitterN(n int, fs ...func(config *routee
 		CommunicationTimeout:               durationjson.Duration(30 * time.Second),
 		ConsulDownModeNotificationInterval: durationjson.Duration(time.Minute),
 		LockTTL:                            durationjson.Duration(locket.DefaultSessionTTL),
-		NATSUsername:                       "nats",
-		NATSPassword:                       "nats",
+		NATSUsername:                       maker.natsUser,
+		NATSPassword:                       maker.natsPassword,
 		RouteEmittingWorkers:               20,
 		SyncInterval:                       durationjson.Duration(time.Minute),
 		TCPRouteTTL:                        durationjson.Duration(2 * time.Minute),
@@ -809,6 +922,11 @@ func (maker commonComponentMaker) RouteEmitterN(n int, fs ...func(config *routee
 		RegisterDirectInstanceRoutes:       false,
 	}
 
+	if !maker.ConsulEnabled() {
+		cfg.ClientLocketConfig = maker.locketClientConfig()
+		cfg.UUID = name + "-inigo-lock-owner"
+	}
+
 	for _, f := range fs {
 		f(&cfg)
 	}
@@ -899,103 +1017,65 @@ func (maker commonComponentMaker) FileServer() (ifrit.Runner, string) {
 	}), servedFilesDir
 }
 
-func (maker commonComponentMaker) Router() ifrit.Runner {
-	_, routerPort, err := net.SplitHostPort(maker.addresses.Router)
-	Expect(err).NotTo(HaveOccurred())
-
-	routerPortInt, err := strconv.Atoi(routerPort)
-	Expect(err).NotTo(HaveOccurred())
-
-	_, routerStatusPort, err := net.SplitHostPort(maker.addresses.RouterStatus)
-	Expect(err).NotTo(HaveOccurred())
-
-	routerStatusPortInt, err := strconv.Atoi(routerStatusPort)
-	Expect(err).NotTo(HaveOccurred())
-
-	natsHost, natsPort, err := net.SplitHostPort(maker.addresses.NATS)
-	Expect(err).NotTo(HaveOccurred())
-
-	natsPortInt, err := strconv.Atoi(natsPort)
-	Expect(err).NotTo(HaveOccurred())
-
-	routerConfig := `
-status:
-  port: %d
-  user: ""
-  pass: ""
-nats:
-  hosts:
-  - hostname: %s
-    port: %d
-  user: ""
-  pass: ""
-logging:
-  file: /dev/stdout
-  syslog: ""
-  level: info
-  loggregator_enabled: false
-  metron_address: 127.0.0.1:65534
-port: %d
-index: 0
-zone: ""
-tracing:
-  enable_zipkin: false
-trace_key: ""
-access_log:
-  file: ""
-  enable_streaming: false
-enable_access_log_streaming: false
-debug_addr: ""
-enable_proxy: false
-enable_ssl: false
-ssl_port: 0
-ssl_cert_path: ""
-ssl_key_path: ""
-sslcertificate:
-  certificate: []
-  privatekey: null
-  ocspstaple: []
-  signedcertificatetimestamps: []
-  leaf: null
-skip_ssl_validation: false
-cipher_suites: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256"
-ciphersuites: []
-load_balancer_healthy_threshold: 0s
-publish_start_message_interval: 0s
-suspend_pruning_if_nats_unavailable: false
-prune_stale_droplets_interval: 5s
-droplet_stale_threshold: 10s
-publish_active_apps_interval: 0s
-start_response_delay_interval: 1s
-endpoint_timeout: 0s
-route_services_timeout: 0s
-secure_cookies: false
-oauth:
-  token_endpoint: ""
-  port: 0
-  skip_ssl_validation: false
-  client_name: ""
-  client_secret: ""
-  ca_certs: ""
-routing_api:
-  uri: ""
-  port: 0
-  auth_disabled: false
-route_services_secret: ""
-route_services_secret_decrypt_only: ""
-route_services_recommend_https: false
-extra_headers_to_log: []
-token_fetcher_max_retries: 0
-token_fetcher_retry_interval: 0s
-token_fetcher_expiration_buffer_time: 0
-pid_file: ""
-`
-	routerConfig = fmt.Sprintf(routerConfig, uint16(routerStatusPortInt), natsHost, uint16(natsPortInt), uint16(routerPortInt))
+func (maker commonComponentMaker) Router(modifyConfigFuncs ...func(*RouterConfig)) ifrit.Runner {
+	routerPort := maker.portFromAddress(maker.addresses.Router)
+	routerStatusPort := maker.portFromAddress(maker.addresses.RouterStatus)
+
+	natsHost, _, err := net.SplitHostPort(maker.addresses.NATS)
+	Expect(err).NotTo(HaveOccurred())
+	natsPort := maker.portFromAddress(maker.addresses.NATS)
+
+	sslPort, err := maker.portAllocator.ClaimPorts(1)
+	Expect(err).NotTo(HaveOccurred())
+
+	serverCert := readFile(maker.routerSSL.ServerCert)
+	serverKey := readFile(maker.routerSSL.ServerKey)
+
+	routerConfig := RouterConfig{
+		Status: RouterStatusConfig{
+			Port: routerStatusPort,
+			User: maker.routerStatusUser,
+			Pass: maker.routerStatusPassword,
+		},
+		Nats: RouterNatsConfig{
+			Hosts: []RouterNatsHost{{Hostname: natsHost, Port: natsPort}},
+			User:  maker.natsUser,
+			Pass:  maker.natsPassword,
+		},
+		Logging: RouterLoggingConfig{
+			File:          "/dev/stdout",
+			Level:         "info",
+			MetronAddress: "127.0.0.1:65534",
+		},
+		Port: routerPort,
+
+		// TLS is off by default, but everything needed to turn it on with
+		// cfg.EnableSSL = true is already in place
+		SSLPort:      sslPort,
+		TLSPEM:       []RouterTLSPem{{CertChain: serverCert, PrivateKey: serverKey}},
+		CipherSuites: "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256",
+		CACerts:      readFile(maker.routerSSL.CACert),
+		Backends: RouterBackendsConfig{
+			CertChain:  readFile(maker.routerSSL.ClientCert),
+			PrivateKey: readFile(maker.routerSSL.ClientKey),
+		},
+
+		PruneStaleDropletsInterval: 5 * time.Second,
+		DropletStaleThreshold:      10 * time.Second,
+		StartResponseDelayInterval: 1 * time.Second,
+	}
+
+	for _, f := range modifyConfigFuncs {
+		f(&routerConfig)
+	}
+
+	routerConfigYAML, err := yaml.Marshal(routerConfig)
+	Expect(err).NotTo(HaveOccurred())
 
 	configFile, err := ioutil.TempFile(TempDirWithParent(maker.tmpDir, "router-config"), "router-config")
 	Expect(err).NotTo(HaveOccurred())
 	defer configFile.Close()
-	_, err = configFile.Write([]byte(routerConfig))
+	_, err = configFile.Write(routerConfigYAML)
 	Expect(err).NotTo(HaveOccurred())
 
 	return ginkgomon.New(ginkgomon.Config{
@@ -1014,6 +1094,22 @@ pid_file: ""
 	})
 }
 
+func (maker commonComponentMaker) portFromAddress(address string) uint16 {
+	_, port, err := net.SplitHostPort(address)
+	Expect(err).NotTo(HaveOccurred())
+
+	portInt, err := strconv.ParseUint(port, 10, 16)
+	Expect(err).NotTo(HaveOccurred())
+
+	return uint16(portInt)
+}
+
+func readFile(path string) string {
+	content, err := ioutil.ReadFile(path)
+	Expect(err).NotTo(HaveOccurred())
+	return string(content)
+}
+
 func (maker commonComponentMaker) SSHProxy(modifyConfigFuncs ...func(*sshproxyconfig.SSHProxyConfig)) ifrit.Runner {
 	sshProxyConfig := sshproxyconfig.SSHProxyConfig{
 		Address:            maker.addresses.SSHProxy,
@@ -1070,8 +1166,14 @@ func (maker commonComponentMaker) GardenClient() garden.Client {
 }
 
 func (maker commonComponentMaker) BBSClient() bbs.InternalClient {
+	return maker.BBSClientAt(maker.addresses.BBS)
+}
+
+// BBSClientAt returns a client for the BBS listening on address, e.g. the
+// Address of a ControlPlaneInstance from BBSN.
+func (maker commonComponentMaker) BBSClientAt(address string) bbs.InternalClient {
 	client, err := bbs.NewClient(
-		maker.BBSURL(),
+		"https://"+address,
 		maker.bbsSSL.CACert,
 		maker.bbsSSL.ClientCert,
 		maker.bbsSSL.ClientKey,
@@ -1098,7 +1200,13 @@ func (maker commonComponentMaker) RepClientFactory() rep.ClientFactory {
 	return factory
 }
 
+// BBSServiceClient looks cells up in Consul, or in Locket when Consul is
+// disabled.
 func (maker commonComponentMaker) BBSServiceClient(logger lager.Logger) serviceclient.ServiceClient {
+	if !maker.ConsulEnabled() {
+		return serviceclient.NewServiceClient(maintain.NewNoopCellPresenceClient(), maker.LocketClient(logger))
+	}
+
 	client, err := consuladapter.NewClientFromUrl(maker.ConsulCluster())
 	Expect(err).NotTo(HaveOccurred())
 
@@ -1112,13 +1220,30 @@ func (maker commonComponentMaker) BBSURL() string {
 	return "https://" + maker.addresses.BBS
 }
 
+// ConsulCluster is empty when Consul is disabled, which components take to
+// mean they should not register with it.
 func (maker commonComponentMaker) ConsulCluster() string {
+	if !maker.ConsulEnabled() {
+		return ""
+	}
 	return "http://" + maker.addresses.Consul
 }
 
+// LocketOnly reports whether $INIGO_LOCKET_ONLY asks for the suites to run
+// without Consul, to check that Diego does not need it. Suites then leave
+// ComponentAddresses.Consul empty, which turns Consul off everywhere (see
+// ConsulEnabled).
+func LocketOnly() bool {
+	return os.Getenv("INIGO_LOCKET_ONLY") == "true"
+}
+
+func (maker commonComponentMaker) ConsulEnabled() bool {
+	return maker.addresses.Consul != ""
+}
+
 func (maker commonComponentMaker) VolmanClient(logger lager.Logger) (volman.Manager, ifrit.Runner) {
 	driverConfig := volmanclient.NewDriverConfig()
-	driverConfig.DriverPaths = []string{path.Join(maker.volmanDriverConfigDir, fmt.Sprintf("node-%d", config.GinkgoConfig.ParallelNode))}
+	driverConfig.DriverPaths = []string{maker.VolmanDriverPath()}
 
 	metronClient, err := loggingclient.NewIngressClient(loggingclient.Config{})
 	Expect(err).NotTo(HaveOccurred())
@@ -1137,7 +1262,7 @@ func (maker commonComponentMaker) VolmanDriver(logger lager.Logger) (ifrit.Runne
 			"-debugAddr", debugServerAddress,
 			"-mountDir", maker.volmanDriverConfigDir,
 			"-logLevel", "debug",
-			"-driversPath", path.Join(maker.volmanDriverConfigDir, fmt.Sprintf("node-%d", config.GinkgoConfig.ParallelNode)),
+			"-driversPath", maker.VolmanDriverPath(),
 			"-transport", "tcp-json",
 			"-uniqueVolumeIds",
 		),
@@ -1167,6 +1292,15 @@ type v0ComponentMaker struct {
 	commonComponentMaker
 }
 
+func (maker v0ComponentMaker) AuctioneerN(n int, modifyConfigFuncs ...func(*auctioneerconfig.AuctioneerConfig)) ControlPlaneInstance {
+	Expect(n).To(BeZero(), "v0 components only run one auctioneer")
+	return ControlPlaneInstance{
+		Name:    "auctioneer",
+		Address: maker.addresses.Auctioneer,
+		Runner:  maker.Auctioneer(modifyConfigFuncs...),
+	}
+}
+
 func (maker v0ComponentMaker) Auctioneer(modifyConfigFuncs ...func(*auctioneerconfig.AuctioneerConfig)) ifrit.Runner {
 	cfg := auctioneerconfig.AuctioneerConfig{
 		BBSAddress:        maker.BBSURL(),
@@ -1281,6 +1415,16 @@ func (maker v0ComponentMaker) FileServer() (ifrit.Runner, string) {
 	}), servedFilesDir
 }
 
+func (maker v0ComponentMaker) BBSN(n int, modifyConfigFuncs ...func(*bbsconfig.BBSConfig)) ControlPlaneInstance {
+	Expect(n).To(BeZero(), "v0 components only run one BBS")
+	return ControlPlaneInstance{
+		Name:          "bbs",
+		Address:       maker.addresses.BBS,
+		HealthAddress: maker.addresses.Health,
+		Runner:        maker.BBS(modifyConfigFuncs...),
+	}
+}
+
 func (maker v0ComponentMaker) BBS(modifyConfigFuncs ...func(*bbsconfig.BBSConfig)) ifrit.Runner {
 	cfg := bbsconfig.BBSConfig{
 		AdvertiseURL:             maker.BBSURL(),
@@ -1349,6 +1493,10 @@ func (maker v0ComponentMaker) Rep(modifyConfigFuncs ...func(*repconfig.RepConfig
 	return maker.RepN(0, modifyConfigFuncs...)
 }
 
+func (maker v0ComponentMaker) Cell(n int, modifyConfigFuncs ...func(*repconfig.RepConfig)) *CellHandle {
+	return newCellHandle(maker.commonComponentMaker, n, maker.RepN, modifyConfigFuncs...)
+}
+
 func (maker v0ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.RepConfig)) *ginkgomon.Runner {
 	host, portString, err := net.SplitHostPort(maker.addresses.Rep)
 	Expect(err).NotTo(HaveOccurred())
@@ -1382,7 +1530,7 @@ func (maker v0ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.R
 			GardenHealthcheckProcessUser: "vcap",
 			GardenNetwork:                "tcp",
 			TempDir:                      executorTempDir,
-			VolmanDriverPaths:            path.Join(maker.volmanDriverConfigDir, fmt.Sprintf("node-%d", config.GinkgoConfig.ParallelNode)),
+			VolmanDriverPaths:            maker.VolmanDriverPathN(n),
 		},
 		ListenAddr:          fmt.Sprintf("%s:%d", host, offsetPort(port, n)),
 		ListenAddrSecurable: fmt.Sprintf("%s:%d", host, offsetPort(port+100, n)),
@@ -1454,12 +1602,10 @@ func (maker v0ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.R
 	}
 
 	return ginkgomon.New(ginkgomon.Config{
-		Name:          name,
-		AnsiColorCode: "33m",
-		StartCheck:    `"` + name + `.started"`,
-		// rep is not started until it can ping an executor and run a healthcheck
-		// container on garden; this can take a bit to start, so account for it
-		StartCheckTimeout: 2 * time.Minute,
+		Name:              name,
+		AnsiColorCode:     "33m",
+		StartCheck:        `"` + name + `.started"`,
+		StartCheckTimeout: maker.repStartCheckTimeout,
 		Command: exec.Command(
 			maker.artifacts.Executables["rep"],
 			args...,
@@ -1468,8 +1614,18 @@ func (maker v0ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.R
 }
 
 func (maker v1ComponentMaker) BBS(modifyConfigFuncs ...func(*bbsconfig.BBSConfig)) ifrit.Runner {
+	return maker.BBSN(0, modifyConfigFuncs...).Runner
+}
+
+// BBSN makes the nth of several BBSes competing for the "bbs" lock. The
+// first listens on ComponentAddresses.BBS, so that BBSClient reaches it; the
+// others listen on ports from the allocator (see BBSClientAt).
+func (maker v1ComponentMaker) BBSN(n int, modifyConfigFuncs ...func(*bbsconfig.BBSConfig)) ControlPlaneInstance {
+	name := instanceName("bbs", n)
+	addresses := maker.instanceAddresses(n, maker.addresses.BBS, maker.addresses.Health)
+
 	config := bbsconfig.BBSConfig{
-		SessionName:                     "bbs",
+		SessionName:                     name,
 		CommunicationTimeout:            durationjson.Duration(10 * time.Second),
 		DesiredLRPCreationTimeout:       durationjson.Duration(1 * time.Minute),
 		ExpireCompletedTaskDuration:     durationjson.Duration(2 * time.Minute),
@@ -1503,8 +1659,8 @@ func (maker v1ComponentMaker) BBS(modifyConfigFuncs ...func(*bbsconfig.BBSConfig
 		LocksLocketEnabled:             true,
 		CellRegistrationsLocketEnabled: true,
 		AuctioneerAddress:              "https://" + maker.addresses.Auctioneer,
-		ListenAddress:                  maker.addresses.BBS,
-		HealthAddress:                  maker.addresses.Health,
+		ListenAddress:                  addresses[0],
+		HealthAddress:                  addresses[1],
 		RequireSSL:                     true,
 		CertFile:                       maker.bbsSSL.ServerCert,
 		KeyFile:                        maker.bbsSSL.ServerKey,
@@ -1517,11 +1673,11 @@ func (maker v1ComponentMaker) BBS(modifyConfigFuncs ...func(*bbsconfig.BBSConfig
 		AuctioneerClientKey:            maker.auctioneerSSL.ClientKey,
 		DatabaseConnectionString:       maker.addresses.SQL,
 		DatabaseDriver:                 maker.dbDriverName,
-		DetectConsulCellRegistrations:  true,
+		DetectConsulCellRegistrations:  maker.ConsulEnabled(),
 		AuctioneerRequireTLS:           true,
 		SQLCACertFile:                  maker.sqlCACertFile,
 		ClientLocketConfig:             maker.locketClientConfig(),
-		UUID:                           "bbs-inigo-lock-owner",
+		UUID:                           lockOwner(name),
 	}
 
 	for _, modifyConfig := range modifyConfigFuncs {
@@ -1529,15 +1685,28 @@ func (maker v1ComponentMaker) BBS(modifyConfigFuncs ...func(*bbsconfig.BBSConfig
 	}
 
 	runner := bbsrunner.New(maker.artifacts.Executables["bbs"], config)
+	runner.Name = name
+	runner.StartCheck = name + ".started"
 	runner.AnsiColorCode = "32m"
 	runner.StartCheckTimeout = maker.startCheckTimeout
-	return runner
+
+	return ControlPlaneInstance{
+		Name:          name,
+		UUID:          config.UUID,
+		Address:       config.ListenAddress,
+		HealthAddress: config.HealthAddress,
+		Runner:        runner,
+	}
 }
 
 func (maker v1ComponentMaker) Rep(modifyConfigFuncs ...func(*repconfig.RepConfig)) *ginkgomon.Runner {
 	return maker.RepN(0, modifyConfigFuncs...)
 }
 
+func (maker v1ComponentMaker) Cell(n int, modifyConfigFuncs ...func(*repconfig.RepConfig)) *CellHandle {
+	return newCellHandle(maker.commonComponentMaker, n, maker.RepN, modifyConfigFuncs...)
+}
+
 func (maker v1ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.RepConfig)) *ginkgomon.Runner {
 	host, portString, err := net.SplitHostPort(maker.addresses.Rep)
 	Expect(err).NotTo(HaveOccurred())
@@ -1620,7 +1789,7 @@ func (maker v1ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.R
 			CachePath:                     cachePath,
 			TempDir:                       executorTempDir,
 			GardenHealthcheckProcessUser:  "vcap",
-			VolmanDriverPaths:             path.Join(maker.volmanDriverConfigDir, fmt.Sprintf("node-%d", config.GinkgoConfig.ParallelNode)),
+			VolmanDriverPaths:             maker.VolmanDriverPathN(n),
 			ContainerOwnerName:            "executor-" + strconv.Itoa(n),
 			HealthCheckContainerOwnerName: "executor-health-check-" + strconv.Itoa(n),
 			PathToTLSCert:                 maker.repSSL.ServerCert,
@@ -1641,6 +1810,10 @@ func (maker v1ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.R
 		repConfig.GardenHealthcheckProcessArgs = []string{"-c", "echo", "foo"}
 	}
 
+	if !maker.ConsulEnabled() {
+		repConfig.ClientLocketConfig = maker.locketClientConfig()
+	}
+
 	for _, modifyConfig := range modifyConfigFuncs {
 		modifyConfig(&repConfig)
 	}
@@ -1654,12 +1827,10 @@ func (maker v1ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.R
 	Expect(err).NotTo(HaveOccurred())
 
 	return ginkgomon.New(ginkgomon.Config{
-		Name:          name,
-		AnsiColorCode: "33m",
-		StartCheck:    `"` + name + `.started"`,
-		// rep is not started until it can ping an executor and run a healthcheck
-		// container on garden; this can take a bit to start, so account for it
-		StartCheckTimeout: 2 * time.Minute,
+		Name:              name,
+		AnsiColorCode:     "33m",
+		StartCheck:        `"` + name + `.started"`,
+		StartCheckTimeout: maker.repStartCheckTimeout,
 		Command: exec.Command(
 			maker.artifacts.Executables["rep"],
 			"-config", configFile.Name()),
@@ -1667,6 +1838,16 @@ func (maker v1ComponentMaker) RepN(n int, modifyConfigFuncs ...func(*repconfig.R
 }
 
 func (maker v1ComponentMaker) Auctioneer(modifyConfigFuncs ...func(cfg *auctioneerconfig.AuctioneerConfig)) ifrit.Runner {
+	return maker.AuctioneerN(0, modifyConfigFuncs...).Runner
+}
+
+// AuctioneerN makes the nth of several auctioneers competing for the
+// "auctioneer" lock. The BBS sends auctions to ComponentAddresses.Auctioneer,
+// where the first one listens; point its AuctioneerAddress at another
+// instance to auction through it.
+func (maker v1ComponentMaker) AuctioneerN(n int, modifyConfigFuncs ...func(cfg *auctioneerconfig.AuctioneerConfig)) ControlPlaneInstance {
+	name := instanceName("auctioneer", n)
+
 	auctioneerConfig := auctioneerconfig.AuctioneerConfig{
 		AuctionRunnerWorkers:          1000,
 		CellStateTimeout:              durationjson.Duration(1 * time.Second),
@@ -1676,7 +1857,7 @@ func (maker v1ComponentMaker) Auctioneer(modifyConfigFuncs ...func(cfg *auctione
 		StartingContainerCountMaximum: 0,
 
 		BBSAddress:              maker.BBSURL(),
-		ListenAddress:           maker.addresses.Auctioneer,
+		ListenAddress:           maker.instanceAddresses(n, maker.addresses.Auctioneer)[0],
 		LockRetryInterval:       durationjson.Duration(time.Second),
 		ConsulCluster:           maker.ConsulCluster(),
 		BBSClientCertFile:       maker.bbsSSL.ClientCert,
@@ -1695,7 +1876,7 @@ func (maker v1ComponentMaker) Auctioneer(modifyConfigFuncs ...func(cfg *auctione
 		},
 		LocksLocketEnabled: true,
 		ClientLocketConfig: maker.locketClientConfig(),
-		UUID:               "auctioneer-inigo-lock-owner",
+		UUID:               lockOwner(name),
 	}
 
 	for _, modifyConfig := range modifyConfigFuncs {
@@ -1708,8 +1889,8 @@ func (maker v1ComponentMaker) Auctioneer(modifyConfigFuncs ...func(cfg *auctione
 	err = json.NewEncoder(configFile).Encode(auctioneerConfig)
 	Expect(err).NotTo(HaveOccurred())
 
-	return ginkgomon.New(ginkgomon.Config{
-		Name:              "auctioneer",
+	runner := ginkgomon.New(ginkgomon.Config{
+		Name:              name,
 		AnsiColorCode:     "35m",
 		StartCheck:        `"auctioneer.started"`,
 		StartCheckTimeout: maker.startCheckTimeout,
@@ -1718,48 +1899,72 @@ func (maker v1ComponentMaker) Auctioneer(modifyConfigFuncs ...func(cfg *auctione
 			"-config", configFile.Name(),
 		),
 	})
+
+	return ControlPlaneInstance{
+		Name:    name,
+		UUID:    auctioneerConfig.UUID,
+		Address: auctioneerConfig.ListenAddress,
+		Runner:  runner,
+	}
 }
 
 func (maker v1ComponentMaker) RouteEmitter(modifyConfigFuncs ...func(config *routeemitterconfig.RouteEmitterConfig)) ifrit.Runner {
 	return maker.RouteEmitterN(0, modifyConfigFuncs...)
 }
 
+// BuildLifecycles builds (or takes from the build cache) the binaries that
+// make up lifeCycle and the tarball they are served in.
 func (blc *BuiltLifecycles) BuildLifecycles(lifeCycle string, tmpDir string) {
 	lifeCyclePath := filepath.Join("code.cloudfoundry.org", lifeCycle)
 
-	builderPath, err := gexec.Build(filepath.Join(lifeCyclePath, "builder"), "-race")
+	cache, err := buildcache.Shared()
 	Expect(err).NotTo(HaveOccurred())
 
-	launcherPath, err := gexec.Build(filepath.Join(lifeCyclePath, "launcher"), "-race")
+	builderPath, err := cache.Build(filepath.Join(lifeCyclePath, "builder"), "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	healthcheckPath, err := gexec.Build("code.cloudfoundry.org/healthcheck/cmd/healthcheck", "-race")
+	launcherPath, err := cache.Build(filepath.Join(lifeCyclePath, "launcher"), "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	os.Setenv("CGO_ENABLED", "0")
-	diegoSSHPath, err := gexec.Build("code.cloudfoundry.org/diego-ssh/cmd/sshd", "-a", "-installsuffix", "static")
-	os.Unsetenv("CGO_ENABLED")
+	healthcheckPath, err := cache.Build("code.cloudfoundry.org/healthcheck/cmd/healthcheck", "-race")
 	Expect(err).NotTo(HaveOccurred())
 
-	lifecycleDir := TempDirWithParent(tmpDir, lifeCycle)
-
-	err = os.Rename(builderPath, filepath.Join(lifecycleDir, "builder"))
+	diegoSSHPath, err := cache.BuildWithEnvironment("code.cloudfoundry.org/diego-ssh/cmd/sshd", []string{"CGO_ENABLED=0"}, "-a", "-installsuffix", "static")
 	Expect(err).NotTo(HaveOccurred())
 
-	err = os.Rename(healthcheckPath, filepath.Join(lifecycleDir, "healthcheck"))
-	Expect(err).NotTo(HaveOccurred())
+	binaries := map[string]string{
+		"builder":     builderPath,
+		"launcher":    launcherPath,
+		"healthcheck": healthcheckPath,
+		"diego-sshd":  diegoSSHPath,
+	}
 
-	err = os.Rename(launcherPath, filepath.Join(lifecycleDir, "launcher"))
-	Expect(err).NotTo(HaveOccurred())
+	// cached binaries live under their content hash, so their paths key the tarball
+	key := buildcache.Key(lifeCycle, builderPath, launcherPath, healthcheckPath, diegoSSHPath)
+	tarballPath, err := cache.Derive(key, LifecycleFilename, func(path string) error {
+		stagingDir, err := ioutil.TempDir("", lifeCycle)
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(stagingDir)
+
+		for name, binaryPath := range binaries {
+			err := buildcache.Link(binaryPath, filepath.Join(stagingDir, name))
+			if err != nil {
+				return err
+			}
+		}
 
-	err = os.Rename(diegoSSHPath, filepath.Join(lifecycleDir, "diego-sshd"))
+		cmd := exec.Command("tar", "-czf", path, "builder", "launcher", "healthcheck", "diego-sshd")
+		cmd.Stderr = GinkgoWriter
+		cmd.Stdout = GinkgoWriter
+		cmd.Dir = stagingDir
+		return cmd.Run()
+	})
 	Expect(err).NotTo(HaveOccurred())
 
-	cmd := exec.Command("tar", "-czf", "lifecycle.tar.gz", "builder", "launcher", "healthcheck", "diego-sshd")
-	cmd.Stderr = GinkgoWriter
-	cmd.Stdout = GinkgoWriter
-	cmd.Dir = lifecycleDir
-	err = cmd.Run()
+	lifecycleDir := TempDirWithParent(tmpDir, lifeCycle)
+	err = buildcache.Link(tarballPath, filepath.Join(lifecycleDir, LifecycleFilename))
 	Expect(err).NotTo(HaveOccurred())
 
 	(*blc)[lifeCycle] = filepath.Join(lifecycleDir, LifecycleFilename)
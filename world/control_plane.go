@@ -0,0 +1,153 @@
+package world
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/locket"
+	locketmodels "code.cloudfoundry.org/locket/models"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/gomega"
+)
+
+// Locket lock keys taken by the control plane components.
+const (
+	BBSLockKey        = "bbs"
+	AuctioneerLockKey = "auctioneer"
+)
+
+// ControlPlaneInstance is one of several BBSes, auctioneers or Locket servers,
+// as made by BBSN, AuctioneerN and LocketN. The BBS and auctioneer only report
+// that they have started once they hold their lock, so start all but the
+// first instance with ifrit.Background rather than ginkgomon.Invoke.
+type ControlPlaneInstance struct {
+	Name string
+	// UUID is the owner the instance takes its lock as; empty for Locket
+	UUID string
+
+	Address string
+	// HealthAddress is only set for the BBS
+	HealthAddress string
+
+	Runner ifrit.Runner
+}
+
+// LocketClient returns a client for the Locket server at
+// ComponentAddresses.Locket, e.g. to find out who holds a lock.
+func (maker commonComponentMaker) LocketClient(logger lager.Logger) locketmodels.LocketClient {
+	return maker.LocketClientAt(logger, maker.addresses.Locket)
+}
+
+// LocketClientAt returns a client for the Locket server listening on address,
+// e.g. the Address of a ControlPlaneInstance from LocketN.
+func (maker commonComponentMaker) LocketClientAt(logger lager.Logger, address string) locketmodels.LocketClient {
+	config := maker.locketClientConfig()
+	config.LocketAddress = address
+
+	client, err := locket.NewClient(logger, config)
+	Expect(err).NotTo(HaveOccurred())
+	return client
+}
+
+// FailoverProxy forwards each TCP connection to the first of backends that
+// accepts it, the way a load balancer in front of a cluster would, and
+// returns the address it listens on. Point clients at it to have them fail
+// over once the backend they are connected to dies:
+//
+//	proxy, locketAddress := componentMaker.FailoverProxy(lockets[0].Address, lockets[1].Address)
+func (maker commonComponentMaker) FailoverProxy(backends ...string) (ifrit.Runner, string) {
+	port, err := maker.portAllocator.ClaimPorts(1)
+	Expect(err).NotTo(HaveOccurred())
+
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	return failoverProxy{address: address, backends: backends}, address
+}
+
+type failoverProxy struct {
+	address  string
+	backends []string
+}
+
+func (p failoverProxy) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	listener, err := net.Listen("tcp", p.address)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go p.forward(conn)
+		}
+	}()
+
+	close(ready)
+	<-signals
+	return listener.Close()
+}
+
+func (p failoverProxy) forward(conn net.Conn) {
+	defer conn.Close()
+
+	for _, backend := range p.backends {
+		backendConn, err := net.Dial("tcp", backend)
+		if err != nil {
+			continue
+		}
+		defer backendConn.Close()
+
+		// closing both ends as soon as either side hangs up is what makes
+		// the client reconnect, and so fail over
+		done := make(chan struct{}, 2)
+		go func() {
+			io.Copy(backendConn, conn)
+			done <- struct{}{}
+		}()
+		go func() {
+			io.Copy(conn, backendConn)
+			done <- struct{}{}
+		}()
+		<-done
+		return
+	}
+}
+
+// instanceAddresses returns the addresses the nth instance of a component
+// listens on. The first instance keeps the component's configured addresses;
+// the others get the same hosts with ports from the allocator.
+func (maker commonComponentMaker) instanceAddresses(n int, addresses ...string) []string {
+	if n == 0 {
+		return addresses
+	}
+
+	ports, err := maker.portAllocator.ClaimPorts(len(addresses))
+	Expect(err).NotTo(HaveOccurred())
+
+	instanceAddresses := make([]string, len(addresses))
+	for i, address := range addresses {
+		host, _, err := net.SplitHostPort(address)
+		Expect(err).NotTo(HaveOccurred())
+		instanceAddresses[i] = net.JoinHostPort(host, fmt.Sprint(int(ports)+i))
+	}
+	return instanceAddresses
+}
+
+// instanceName gives the first instance the plain component name, which
+// BBS and Auctioneer start checks and lock owners rely on.
+func instanceName(component string, n int) string {
+	if n == 0 {
+		return component
+	}
+	return fmt.Sprintf("%s-%d", component, n)
+}
+
+func lockOwner(name string) string {
+	return name + "-inigo-lock-owner"
+}
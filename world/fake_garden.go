@@ -0,0 +1,48 @@
+package world
+
+import (
+	"fmt"
+	"os"
+
+	"code.cloudfoundry.org/inigo/helpers/fakegarden"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/tedsuo/ifrit"
+
+	. "github.com/onsi/gomega"
+)
+
+// UseFakeGarden reports whether $INIGO_FAKE_GARDEN asks for the in-process
+// fake Garden instead of gdn. The fake needs no root, grootfs or rootfs, so
+// $GARDEN_BINPATH, $GROOTFS_BINPATH and $GARDEN_ROOTFS become optional; see
+// fakegarden.Backend for what it does and does not emulate.
+func UseFakeGarden() bool {
+	return os.Getenv("INIGO_FAKE_GARDEN") == "true"
+}
+
+func (maker commonComponentMaker) FakeGardenEnabled() bool {
+	return maker.fakeGarden
+}
+
+func (maker commonComponentMaker) fakeGardenRunner() ifrit.Runner {
+	runner := fakegarden.NewRunner(
+		lagertest.NewTestLogger("fake-garden"),
+		maker.addresses.Garden,
+		TempDirWithParent(maker.tmpDir, "fake-garden"),
+	)
+	runner.StartTimeout = maker.startCheckTimeout
+	return runner
+}
+
+// GardenProxy serves proxy on a port of its own and returns its address.
+// Point a rep (RepConfig.GardenAddr) or executor at it to have faults
+// injected between them and the garden behind the proxy.
+func (maker commonComponentMaker) GardenProxy(proxy *fakegarden.Proxy) (ifrit.Runner, string) {
+	port, err := maker.portAllocator.ClaimPorts(1)
+	Expect(err).NotTo(HaveOccurred())
+
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+
+	runner := fakegarden.NewProxyRunner(lagertest.NewTestLogger("garden-proxy"), address, proxy)
+	runner.StartTimeout = maker.startCheckTimeout
+	return runner, address
+}
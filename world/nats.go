@@ -0,0 +1,129 @@
+package world
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+
+	. "github.com/onsi/gomega"
+)
+
+// NATSConfig is how a nats-server is started. The defaults require the
+// credentials every component is configured with (see NATSCredentials).
+type NATSConfig struct {
+	Host string
+	Port int
+
+	Username string
+	Password string
+
+	// TLS serves clients with the certificate in ComponentMaker.NATSSSLConfig,
+	// and requires client certificates when TLSVerify is set
+	TLS       bool
+	TLSVerify bool
+
+	// ClusterPort is where the server listens for other nodes, and Routes
+	// are the cluster URLs (nats://host:port) of the nodes it connects to
+	ClusterPort int
+	Routes      []string
+
+	// Args are passed to nats-server as they are
+	Args []string
+}
+
+func (maker commonComponentMaker) NATS(argv ...string) ifrit.Runner {
+	runner, _ := maker.NATSN(0, func(cfg *NATSConfig) {
+		cfg.Args = append(cfg.Args, argv...)
+	})
+	return runner
+}
+
+// NATSN makes the nth node of a NATS cluster and returns it along with the
+// address clients connect to. The first node listens on
+// ComponentAddresses.NATS; every other node routes to it.
+func (maker commonComponentMaker) NATSN(n int, modifyConfigFuncs ...func(*NATSConfig)) (ifrit.Runner, string) {
+	host, port, err := net.SplitHostPort(maker.instanceAddresses(n, maker.addresses.NATS)[0])
+	Expect(err).NotTo(HaveOccurred())
+	portInt, err := strconv.Atoi(port)
+	Expect(err).NotTo(HaveOccurred())
+
+	natsHost, _, err := net.SplitHostPort(maker.addresses.NATS)
+	Expect(err).NotTo(HaveOccurred())
+	seedRoute := fmt.Sprintf("nats://%s:%d", natsHost, maker.natsClusterPort)
+
+	cfg := NATSConfig{
+		Host:        host,
+		Port:        portInt,
+		Username:    maker.natsUser,
+		Password:    maker.natsPassword,
+		ClusterPort: int(maker.natsClusterPort),
+	}
+
+	if n > 0 {
+		clusterPort, err := maker.portAllocator.ClaimPorts(1)
+		Expect(err).NotTo(HaveOccurred())
+		cfg.ClusterPort = int(clusterPort)
+		cfg.Routes = []string{seedRoute}
+	}
+
+	for _, f := range modifyConfigFuncs {
+		f(&cfg)
+	}
+
+	args := []string{
+		"--addr", cfg.Host,
+		"--port", strconv.Itoa(cfg.Port),
+	}
+
+	if cfg.Username != "" {
+		args = append(args, "--user", cfg.Username, "--pass", cfg.Password)
+	}
+
+	if cfg.TLS {
+		args = append(args,
+			"--tls",
+			"--tlscert", maker.natsSSL.ServerCert,
+			"--tlskey", maker.natsSSL.ServerKey,
+			"--tlscacert", maker.natsSSL.CACert,
+		)
+		if cfg.TLSVerify {
+			args = append(args, "--tlsverify")
+		}
+	}
+
+	if cfg.ClusterPort != 0 {
+		args = append(args, "--cluster", fmt.Sprintf("nats://%s:%d", cfg.Host, cfg.ClusterPort))
+	}
+
+	if len(cfg.Routes) > 0 {
+		args = append(args, "--routes", strings.Join(cfg.Routes, ","))
+	}
+
+	runner := ginkgomon.New(ginkgomon.Config{
+		Name:              instanceName("nats-server", n),
+		AnsiColorCode:     "30m",
+		StartCheck:        "Server is ready",
+		StartCheckTimeout: maker.startCheckTimeout,
+		Command: exec.Command(
+			"nats-server",
+			append(args, cfg.Args...)...,
+		),
+	})
+
+	return runner, net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+}
+
+// NATSCredentials returns the user and password that the NATS servers
+// require and that the router and route-emitter connect with.
+func (maker commonComponentMaker) NATSCredentials() (string, string) {
+	return maker.natsUser, maker.natsPassword
+}
+
+func (maker commonComponentMaker) NATSSSLConfig() SSLConfig {
+	return maker.natsSSL
+}
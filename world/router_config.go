@@ -0,0 +1,111 @@
+package world
+
+import (
+	"time"
+)
+
+// RouterConfig is the subset of gorouter's configuration that inigo sets.
+// ComponentMaker.Router renders it to YAML; field names follow gorouter's
+// config keys. Certificates and keys are PEM contents, not paths, as gorouter
+// expects.
+type RouterConfig struct {
+	Status  RouterStatusConfig  `yaml:"status"`
+	Nats    RouterNatsConfig    `yaml:"nats"`
+	Logging RouterLoggingConfig `yaml:"logging"`
+
+	Port  uint16 `yaml:"port"`
+	Index uint   `yaml:"index"`
+	Zone  string `yaml:"zone"`
+
+	AccessLog         RouterAccessLogConfig `yaml:"access_log"`
+	ExtraHeadersToLog []string              `yaml:"extra_headers_to_log"`
+
+	EnableSSL            bool                 `yaml:"enable_ssl"`
+	SSLPort              uint16               `yaml:"ssl_port"`
+	TLSPEM               []RouterTLSPem       `yaml:"tls_pem,omitempty"`
+	CipherSuites         string               `yaml:"cipher_suites"`
+	MinTLSVersion        string               `yaml:"min_tls_version,omitempty"`
+	ClientCertValidation string               `yaml:"client_cert_validation,omitempty"`
+	CACerts              string               `yaml:"ca_certs,omitempty"`
+	SkipSSLValidation    bool                 `yaml:"skip_ssl_validation"`
+	Backends             RouterBackendsConfig `yaml:"backends"`
+
+	RouteServicesSecret            string        `yaml:"route_services_secret"`
+	RouteServicesSecretDecryptOnly string        `yaml:"route_services_secret_decrypt_only"`
+	RouteServicesRecommendHTTPS    bool          `yaml:"route_services_recommend_https"`
+	RouteServicesTimeout           time.Duration `yaml:"route_services_timeout"`
+	RouteServicesHairpinning       bool          `yaml:"route_services_hairpinning"`
+
+	OAuth                            RouterOAuthConfig      `yaml:"oauth"`
+	RoutingAPI                       RouterRoutingAPIConfig `yaml:"routing_api"`
+	TokenFetcherMaxRetries           uint32                 `yaml:"token_fetcher_max_retries"`
+	TokenFetcherRetryInterval        time.Duration          `yaml:"token_fetcher_retry_interval"`
+	TokenFetcherExpirationBufferTime int64                  `yaml:"token_fetcher_expiration_buffer_time"`
+
+	SuspendPruningIfNatsUnavailable bool          `yaml:"suspend_pruning_if_nats_unavailable"`
+	PruneStaleDropletsInterval      time.Duration `yaml:"prune_stale_droplets_interval"`
+	DropletStaleThreshold           time.Duration `yaml:"droplet_stale_threshold"`
+	PublishActiveAppsInterval       time.Duration `yaml:"publish_active_apps_interval"`
+	StartResponseDelayInterval      time.Duration `yaml:"start_response_delay_interval"`
+	EndpointTimeout                 time.Duration `yaml:"endpoint_timeout"`
+	SecureCookies                   bool          `yaml:"secure_cookies"`
+	PidFile                         string        `yaml:"pid_file"`
+}
+
+type RouterStatusConfig struct {
+	Port uint16 `yaml:"port"`
+	User string `yaml:"user"`
+	Pass string `yaml:"pass"`
+}
+
+type RouterNatsConfig struct {
+	Hosts []RouterNatsHost `yaml:"hosts"`
+	User  string           `yaml:"user"`
+	Pass  string           `yaml:"pass"`
+}
+
+type RouterNatsHost struct {
+	Hostname string `yaml:"hostname"`
+	Port     uint16 `yaml:"port"`
+}
+
+type RouterLoggingConfig struct {
+	File               string `yaml:"file"`
+	Syslog             string `yaml:"syslog"`
+	Level              string `yaml:"level"`
+	LoggregatorEnabled bool   `yaml:"loggregator_enabled"`
+	MetronAddress      string `yaml:"metron_address"`
+}
+
+type RouterAccessLogConfig struct {
+	File            string `yaml:"file"`
+	EnableStreaming bool   `yaml:"enable_streaming"`
+}
+
+type RouterTLSPem struct {
+	CertChain  string `yaml:"cert_chain"`
+	PrivateKey string `yaml:"private_key"`
+}
+
+// RouterBackendsConfig is the client certificate gorouter presents to
+// backends that require mutual TLS.
+type RouterBackendsConfig struct {
+	EnableTLS  bool   `yaml:"enable_tls"`
+	CertChain  string `yaml:"cert_chain,omitempty"`
+	PrivateKey string `yaml:"private_key,omitempty"`
+}
+
+type RouterOAuthConfig struct {
+	TokenEndpoint     string `yaml:"token_endpoint"`
+	Port              int    `yaml:"port"`
+	SkipSSLValidation bool   `yaml:"skip_ssl_validation"`
+	ClientName        string `yaml:"client_name"`
+	ClientSecret      string `yaml:"client_secret"`
+	CACerts           string `yaml:"ca_certs"`
+}
+
+type RouterRoutingAPIConfig struct {
+	URI          string `yaml:"uri"`
+	Port         int    `yaml:"port"`
+	AuthDisabled bool   `yaml:"auth_disabled"`
+}